@@ -0,0 +1,162 @@
+package gonetable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrAccessPatternName    = errors.New("access pattern name is empty or already used")
+	ErrAccessPatternSegment = errors.New("access pattern segment must be literal or {Parameter}")
+	ErrUnknownAccessPattern = errors.New("access pattern not declared in schema")
+	ErrPatternParameter     = errors.New("access pattern parameter missing or unknown")
+
+	patternParamRE = regexp.MustCompile(`^\{([a-zA-Z0-9_]+)\}$`)
+)
+
+// AccessPattern is a named query of the application, e.g. "orders by
+// customer, newest first". Declaring access patterns in the schema
+// documents why the keys and indexes are what they are, and lets
+// them be queried by name.
+//
+// Segments are literals or parameters in braces, like in key
+// templates:
+//
+//	gonetable.AccessPattern{
+//		Name:        "orders by customer, newest first",
+//		Hash:        []string{"customer", "{Customer}"},
+//		RangePrefix: []string{"order"},
+//		Descending:  true,
+//	}
+type AccessPattern struct {
+	Name string `json:"name"`
+	// Index to query, empty for the table
+	Index       string   `json:"index,omitempty"`
+	Hash        []string `json:"hash"`
+	RangePrefix []string `json:"rangePrefix,omitempty"`
+	Descending  bool     `json:"descending,omitempty"`
+}
+
+// WithAccessPattern declares access pattern of the schema. Can be used
+// multiple times. Index of the pattern must be defined by at least one
+// of the document samples.
+func WithAccessPattern(pattern AccessPattern) SchemaOption {
+	return func(o *schemaOptions) {
+		o.accessPatterns = append(o.accessPatterns, pattern)
+	}
+}
+
+// Parameters returns names of the parameters of the pattern in the
+// order they appear.
+func (p AccessPattern) Parameters() []string {
+	rv := []string{}
+	seen := map[string]bool{}
+	for _, segment := range append(append([]string{}, p.Hash...), p.RangePrefix...) {
+		if m := patternParamRE.FindStringSubmatch(segment); m != nil && !seen[m[1]] {
+			seen[m[1]] = true
+			rv = append(rv, m[1])
+		}
+	}
+	return rv
+}
+
+// Query returns query of the pattern with parameters replaced by the
+// values in params. All parameters must be given, and nothing else.
+func (p AccessPattern) Query(params map[string]string) (Query, error) {
+	q := Query{Index: p.Index, Descending: p.Descending}
+	used := map[string]bool{}
+	var err error
+	if q.HashSegments, err = p.fill(p.Hash, params, used); err != nil {
+		return q, err
+	}
+	if len(p.RangePrefix) > 0 {
+		if q.RangePrefix, err = p.fill(p.RangePrefix, params, used); err != nil {
+			return q, err
+		}
+	}
+	for _, name := range sortedKeys(params) {
+		if !used[name] {
+			return q, fmt.Errorf("access pattern %q: %w: %s is not used", p.Name, ErrPatternParameter, name)
+		}
+	}
+	return q, nil
+}
+
+func (p AccessPattern) fill(segments []string, params map[string]string, used map[string]bool) ([]string, error) {
+	rv := make([]string, len(segments))
+	for i, segment := range segments {
+		m := patternParamRE.FindStringSubmatch(segment)
+		if m == nil {
+			rv[i] = segment
+			continue
+		}
+		value, ok := params[m[1]]
+		if !ok {
+			return nil, fmt.Errorf("access pattern %q: %w: %s is missing", p.Name, ErrPatternParameter, m[1])
+		}
+		used[m[1]] = true
+		rv[i] = value
+	}
+	return rv, nil
+}
+
+// validate returns the first problem of the pattern
+func (p AccessPattern) validate(names map[string]bool, indexes map[string]bool) error {
+	reason := func(err error) error {
+		return fmt.Errorf("access pattern %q: %w", p.Name, err)
+	}
+	if p.Name == "" || names[p.Name] {
+		return reason(ErrAccessPatternName)
+	}
+	if p.Index != "" && !indexes[p.Index] {
+		return reason(ErrUnknownIndex)
+	}
+	if len(p.Hash) == 0 {
+		return reason(ErrKeyNoSegments)
+	}
+	for _, segment := range append(append([]string{}, p.Hash...), p.RangePrefix...) {
+		if patternParamRE.MatchString(segment) {
+			continue
+		}
+		if strings.ContainsAny(segment, "{}") {
+			return reason(fmt.Errorf("%w: %s", ErrAccessPatternSegment, segment))
+		}
+		if strings.Contains(segment, KeyDelimiter) {
+			return reason(fmt.Errorf("%w: %s", ErrKeyDelimiter, segment))
+		}
+	}
+	return nil
+}
+
+// AccessPatterns returns the access patterns of the schema in the
+// order they were declared.
+func (s *Schema) AccessPatterns() []AccessPattern {
+	return append([]AccessPattern{}, s.accessPatterns...)
+}
+
+// AccessPattern returns the access pattern with the name.
+func (s *Schema) AccessPattern(name string) (AccessPattern, bool) {
+	for _, p := range s.accessPatterns {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return AccessPattern{}, false
+}
+
+// QueryPattern runs the named access pattern of the schema with the
+// parameters. See Query for how documents are read from the indexes.
+func (t *Table) QueryPattern(ctx context.Context, name string, params map[string]string) ([]Document, error) {
+	p, ok := t.schema.AccessPattern(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccessPattern, name)
+	}
+	q, err := p.Query(params)
+	if err != nil {
+		return nil, err
+	}
+	return t.Query(ctx, q)
+}
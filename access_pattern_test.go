@@ -0,0 +1,130 @@
+package gonetable_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+)
+
+var ordersByCustomer = gonetable.AccessPattern{
+	Name:        "orders by customer, newest first",
+	Hash:        []string{"customer", "{Customer}"},
+	RangePrefix: []string{"order"},
+	Descending:  true,
+}
+
+func TestAccessPattern_Query(t *testing.T) {
+	q, err := ordersByCustomer.Query(map[string]string{"Customer": "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gonetable.Query{
+		HashSegments: []string{"customer", "c1"},
+		RangePrefix:  []string{"order"},
+		Descending:   true,
+	}
+	if !reflect.DeepEqual(q, want) {
+		t.Errorf("Query() = %+v, want %+v", q, want)
+	}
+	if got := ordersByCustomer.Parameters(); !reflect.DeepEqual(got, []string{"Customer"}) {
+		t.Errorf("Parameters() = %v", got)
+	}
+	for _, params := range []map[string]string{
+		{},
+		{"Customer": "c1", "Year": "2022"},
+	} {
+		if _, err := ordersByCustomer.Query(params); !errors.Is(err, gonetable.ErrPatternParameter) {
+			t.Errorf("Query(%v) error = %v, want %v", params, err, gonetable.ErrPatternParameter)
+		}
+	}
+}
+
+func TestNewSchema_AccessPatternErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern gonetable.AccessPattern
+		want    error
+	}{
+		{
+			name:    "duplicate name",
+			pattern: gonetable.AccessPattern{Name: ordersByCustomer.Name, Hash: []string{"customer"}},
+			want:    gonetable.ErrAccessPatternName,
+		},
+		{
+			name:    "unknown index",
+			pattern: gonetable.AccessPattern{Name: "x", Index: "GSI9", Hash: []string{"x"}},
+			want:    gonetable.ErrUnknownIndex,
+		},
+		{
+			name:    "no hash",
+			pattern: gonetable.AccessPattern{Name: "x"},
+			want:    gonetable.ErrKeyNoSegments,
+		},
+		{
+			name:    "partial parameter",
+			pattern: gonetable.AccessPattern{Name: "x", Hash: []string{"customer-{Customer}"}},
+			want:    gonetable.ErrAccessPatternSegment,
+		},
+		{
+			name:    "delimiter",
+			pattern: gonetable.AccessPattern{Name: "x", Hash: []string{"customer#1"}},
+			want:    gonetable.ErrKeyDelimiter,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := gonetable.NewSchema(
+				[]gonetable.Document{&Order{}},
+				gonetable.WithAccessPattern(ordersByCustomer),
+				gonetable.WithAccessPattern(tt.pattern),
+			)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTable_QueryPattern(t *testing.T) {
+	s, err := gonetable.NewSchema(
+		[]gonetable.Document{&Order{}},
+		gonetable.WithAccessPattern(ordersByCustomer),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &queryStub{
+		queryItems: []map[string]types.AttributeValue{
+			{
+				"PK":       MustMarshal("customer#c1"),
+				"SK":       MustMarshal("order#2"),
+				"Customer": MustMarshal("c1"),
+				"Number":   MustMarshal(2),
+				"_Type":    MustMarshal("order"),
+			},
+		},
+	}
+	table := gonetable.NewTable("test", s, stub)
+	docs, err := table.QueryPattern(context.Background(), ordersByCustomer.Name, map[string]string{"Customer": "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []gonetable.Document{&Order{Customer: "c1", Number: 2}}; !reflect.DeepEqual(docs, want) {
+		t.Errorf("docs = %v, want %v", docs, want)
+	}
+	input := stub.queries[0]
+	if *input.KeyConditionExpression != "#pk = :pk AND begins_with(#sk, :sk)" ||
+		aws.ToBool(input.ScanIndexForward) ||
+		input.ExpressionAttributeValues[":pk"].(*types.AttributeValueMemberS).Value != "customer#c1" {
+		t.Errorf("query input = %+v", input)
+	}
+	_, err = table.QueryPattern(context.Background(), "orders by date", nil)
+	if !errors.Is(err, gonetable.ErrUnknownAccessPattern) {
+		t.Errorf("error = %v, want %v", err, gonetable.ErrUnknownAccessPattern)
+	}
+}
@@ -0,0 +1,325 @@
+// Package doccheck defines an analyzer that checks gonetable document
+// types at compile time.
+//
+// Document types are types that have Gonetable_TypeID and
+// Gonetable_Key methods. The analyzer reports
+//
+//   - Gonetable methods with pointer receiver of types whose values,
+//     not pointers, are used as gonetable.Document in the package,
+//     because the schema doesn't find methods outside the method set
+//     of the registered value
+//   - key methods with wrong signature, which the schema ignores or
+//     rejects
+//   - struct fields whose attribute names collide with PK, SK, _Type,
+//     _V, or the key attributes of the indexes of the type
+//   - string literals in key segments that contain the key delimiter
+//
+// The runtime counterpart is gonetabletest.CheckDocument.
+package doccheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juranki/gonetable/internal/fields"
+	"golang.org/x/tools/go/analysis"
+)
+
+const (
+	gonetablePath = "github.com/juranki/gonetable"
+	// same as gonetable.KeyDelimiter
+	keyDelimiter = "#"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name: "gonetabledoc",
+	Doc:  "check gonetable document types for receiver, key method and attribute name problems",
+	Run:  run,
+}
+
+var (
+	keyMethodRE     = regexp.MustCompile(`^Gonetable_([a-zA-Z0-9]*)Key$`)
+	sortKeyMethodRE = regexp.MustCompile(`^Gonetable_([a-zA-Z0-9]+)SortKey$`)
+)
+
+// method is a Gonetable method declared in the package
+type method struct {
+	decl    *ast.FuncDecl
+	pointer bool
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	methods := map[*types.TypeName]map[string]method{}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || !strings.HasPrefix(fd.Name.Name, "Gonetable_") {
+				continue
+			}
+			recv, pointer := receiverType(pass, fd)
+			if recv == nil {
+				continue
+			}
+			if methods[recv] == nil {
+				methods[recv] = map[string]method{}
+			}
+			methods[recv][fd.Name.Name] = method{decl: fd, pointer: pointer}
+		}
+	}
+	values := valueDocuments(pass)
+	for typeName, ms := range methods {
+		if _, ok := ms["Gonetable_TypeID"]; !ok {
+			continue
+		}
+		if _, ok := ms["Gonetable_Key"]; !ok {
+			continue
+		}
+		if values[typeName] {
+			checkReceivers(pass, typeName, ms)
+		}
+		indexes, localIndexes := checkKeyMethods(pass, ms)
+		checkFields(pass, typeName, indexes, localIndexes)
+	}
+	return nil, nil
+}
+
+// receiverType returns the named type of method receiver and whether
+// the receiver is a pointer
+func receiverType(pass *analysis.Pass, fd *ast.FuncDecl) (*types.TypeName, bool) {
+	fn, ok := pass.TypesInfo.Defs[fd.Name].(*types.Func)
+	if !ok {
+		return nil, false
+	}
+	t := fn.Type().(*types.Signature).Recv().Type()
+	ptr, pointer := t.(*types.Pointer)
+	if pointer {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil, false
+	}
+	return named.Obj(), pointer
+}
+
+// valueDocuments returns the named types whose values are used as
+// gonetable.Document in elements of composite literals, like the
+// document samples of NewSchema, or in arguments of function calls.
+func valueDocuments(pass *analysis.Pass) map[*types.TypeName]bool {
+	rv := map[*types.TypeName]bool{}
+	record := func(expr ast.Expr) {
+		if named, ok := pass.TypesInfo.TypeOf(expr).(*types.Named); ok {
+			rv[named.Obj()] = true
+		}
+	}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CompositeLit:
+				var elem types.Type
+				switch t := pass.TypesInfo.TypeOf(n).Underlying().(type) {
+				case *types.Slice:
+					elem = t.Elem()
+				case *types.Array:
+					elem = t.Elem()
+				}
+				if elem != nil && isDocument(elem) {
+					for _, elt := range n.Elts {
+						record(elt)
+					}
+				}
+			case *ast.CallExpr:
+				sig, ok := pass.TypesInfo.TypeOf(n.Fun).(*types.Signature)
+				if !ok {
+					return true
+				}
+				params := sig.Params()
+				for i, arg := range n.Args {
+					var param types.Type
+					switch {
+					case sig.Variadic() && i >= params.Len()-1:
+						param = params.At(params.Len() - 1).Type()
+						if !n.Ellipsis.IsValid() {
+							param = param.(*types.Slice).Elem()
+						}
+					case i < params.Len():
+						param = params.At(i).Type()
+					}
+					if param != nil && isDocument(param) {
+						record(arg)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return rv
+}
+
+// checkReceivers reports Gonetable methods with pointer receiver of
+// a type that is used as document by value
+func checkReceivers(pass *analysis.Pass, typeName *types.TypeName, ms map[string]method) {
+	for _, name := range sortedNames(ms) {
+		if m := ms[name]; m.pointer {
+			pass.Reportf(m.decl.Name.Pos(),
+				"%s of %s has pointer receiver, but %s value is used as gonetable.Document",
+				name, typeName.Name(), typeName.Name())
+		}
+	}
+}
+
+// checkKeyMethods reports key methods with wrong signature and
+// returns the indexes of the valid ones
+func checkKeyMethods(pass *analysis.Pass, ms map[string]method) (indexes, localIndexes []string) {
+	for _, name := range sortedNames(ms) {
+		m := ms[name]
+		sig := pass.TypesInfo.Defs[m.decl.Name].(*types.Func).Type().(*types.Signature)
+		switch {
+		case name == "Gonetable_TypeID":
+			if !hasResult(sig, isString) {
+				pass.Reportf(m.decl.Name.Pos(), "%s must have no parameters and return string", name)
+			}
+		case sortKeyMethodRE.MatchString(name):
+			if !hasResult(sig, isStringSlice) {
+				pass.Reportf(m.decl.Name.Pos(), "%s must have no parameters and return []string", name)
+				continue
+			}
+			localIndexes = append(localIndexes, sortKeyMethodRE.FindStringSubmatch(name)[1])
+			checkSegmentLiterals(pass, m.decl)
+		case keyMethodRE.MatchString(name):
+			if !hasResult(sig, isCompositeKey) {
+				pass.Reportf(m.decl.Name.Pos(), "%s must have no parameters and return gonetable.CompositeKey", name)
+				continue
+			}
+			if index := keyMethodRE.FindStringSubmatch(name)[1]; index != "" {
+				indexes = append(indexes, index)
+			}
+			checkSegmentLiterals(pass, m.decl)
+		}
+	}
+	return indexes, localIndexes
+}
+
+func hasResult(sig *types.Signature, check func(types.Type) bool) bool {
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && check(sig.Results().At(0).Type())
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.(*types.Basic)
+	return ok && b.Kind() == types.String
+}
+
+func isStringSlice(t types.Type) bool {
+	s, ok := t.(*types.Slice)
+	return ok && isString(s.Elem())
+}
+
+func isDocument(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == gonetablePath && named.Obj().Name() == "Document"
+}
+
+func isCompositeKey(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == gonetablePath && named.Obj().Name() == "CompositeKey"
+}
+
+// checkSegmentLiterals reports string literals with key delimiter in
+// the elements of []string literals of key method. Function calls are
+// not followed.
+func checkSegmentLiterals(pass *analysis.Pass, fd *ast.FuncDecl) {
+	if fd.Body == nil {
+		return
+	}
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || !isStringSlice(pass.TypesInfo.TypeOf(lit)) {
+			return true
+		}
+		for _, elt := range lit.Elts {
+			ast.Inspect(elt, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.CallExpr, *ast.FuncLit:
+					return false
+				case *ast.BasicLit:
+					if n.Kind != token.STRING {
+						return false
+					}
+					if s, err := strconv.Unquote(n.Value); err == nil && strings.Contains(s, keyDelimiter) {
+						pass.Reportf(n.Pos(), "key segment literal %s contains key delimiter %q", n.Value, keyDelimiter)
+					}
+				}
+				return true
+			})
+		}
+		return false
+	})
+}
+
+// checkFields reports struct fields whose attribute names collide with
+// the attributes that the schema adds
+func checkFields(pass *analysis.Pass, typeName *types.TypeName, indexes, localIndexes []string) {
+	st, ok := typeName.Type().Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	reserved := fields.Reserved(indexes, localIndexes)
+	eachAttribute(st, func(field *types.Var, name string) {
+		what, ok := reserved[name]
+		if !ok {
+			return
+		}
+		pos := field.Pos()
+		if field.Pkg() != pass.Pkg {
+			// field of embedded struct from another package
+			pos = typeName.Pos()
+		}
+		pass.Reportf(pos, "attribute %s of %s collides with the %s attribute", name, typeName.Name(), what)
+	})
+}
+
+// eachAttribute calls fn for struct fields that attributevalue
+// marshals, with their attribute names
+func eachAttribute(st *types.Struct, fn func(field *types.Var, name string)) {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		name, _, _ := strings.Cut(reflect.StructTag(st.Tag(i)).Get("dynamodbav"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Embedded() && name == "" {
+			t := field.Type()
+			if ptr, ok := t.(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if embedded, ok := t.Underlying().(*types.Struct); ok {
+				eachAttribute(embedded, fn)
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name()
+		}
+		fn(field, name)
+	}
+}
+
+func sortedNames(ms map[string]method) []string {
+	rv := make([]string, 0, len(ms))
+	for name := range ms {
+		rv = append(rv, name)
+	}
+	sort.Strings(rv)
+	return rv
+}
@@ -0,0 +1,119 @@
+package doccheck_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/juranki/gonetable/analysis/doccheck"
+	"golang.org/x/tools/go/analysis"
+)
+
+// importer resolves imports to packages type-checked from testdata
+type importer map[string]*types.Package
+
+func (imp importer) Import(path string) (*types.Package, error) {
+	if pkg, ok := imp[path]; ok {
+		return pkg, nil
+	}
+	return nil, fmt.Errorf("package %s not in testdata", path)
+}
+
+// check parses and type-checks package from testdata/src
+func check(t *testing.T, fset *token.FileSet, imp importer, path string) (*types.Package, []*ast.File, *types.Info) {
+	dir := filepath.Join("testdata", "src", filepath.FromSlash(path))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []*ast.File{}
+	for _, e := range entries {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	info := &types.Info{
+		Types: map[ast.Expr]types.TypeAndValue{},
+		Defs:  map[*ast.Ident]types.Object{},
+		Uses:  map[*ast.Ident]types.Object{},
+	}
+	pkg, err := (&types.Config{Importer: imp}).Check(path, fset, files, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp[path] = pkg
+	return pkg, files, info
+}
+
+var wantRE = regexp.MustCompile("// want `([^`]*)`")
+
+// TestAnalyzer runs the analyzer on testdata/src/a and compares the
+// diagnostics with the `// want` comments, like analysistest does.
+func TestAnalyzer(t *testing.T) {
+	fset := token.NewFileSet()
+	imp := importer{}
+	check(t, fset, imp, "github.com/juranki/gonetable")
+	pkg, files, info := check(t, fset, imp, "a")
+
+	got := map[int][]string{}
+	pass := &analysis.Pass{
+		Analyzer:  doccheck.Analyzer,
+		Fset:      fset,
+		Files:     files,
+		Pkg:       pkg,
+		TypesInfo: info,
+		Report: func(d analysis.Diagnostic) {
+			line := fset.Position(d.Pos).Line
+			got[line] = append(got[line], d.Message)
+		},
+	}
+	if _, err := doccheck.Analyzer.Run(pass); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]*regexp.Regexp{}
+	for _, f := range files {
+		for _, cg := range f.Comments {
+			for _, c := range cg.List {
+				if m := wantRE.FindStringSubmatch(c.Text); m != nil {
+					want[fset.Position(c.Pos()).Line] = regexp.MustCompile(m[1])
+				}
+			}
+		}
+	}
+	lines := []int{}
+	for line := range got {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	for _, line := range lines {
+		messages := got[line]
+		re, ok := want[line]
+		if !ok || len(messages) != 1 || !re.MatchString(messages[0]) {
+			t.Errorf("line %d: unexpected diagnostics %s", line, strings.Join(quoteAll(messages), ", "))
+		}
+		delete(want, line)
+	}
+	for line, re := range want {
+		t.Errorf("line %d: no diagnostic matching %s", line, re)
+	}
+}
+
+func quoteAll(s []string) []string {
+	rv := make([]string, len(s))
+	for i := range s {
+		rv[i] = strconv.Quote(s[i])
+	}
+	return rv
+}
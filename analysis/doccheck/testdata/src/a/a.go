@@ -0,0 +1,124 @@
+package a
+
+import "github.com/juranki/gonetable"
+
+func join(segments ...string) string {
+	s := ""
+	for _, seg := range segments {
+		s += seg
+	}
+	return s
+}
+
+type Good struct {
+	ID    string
+	Owner string
+	Note  string `dynamodbav:"PK_note"`
+}
+
+func (g *Good) Gonetable_TypeID() string { return "good" }
+func (g *Good) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"good", g.ID},
+		RangeSegments: []string{join(g.Owner, "#", g.ID)},
+	}
+}
+func (g *Good) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"owner", g.Owner}, RangeSegments: []string{"good"}}
+}
+
+type Mixed struct {
+	ID string
+}
+
+func (m Mixed) Gonetable_TypeID() string { return "mixed" }
+func (m Mixed) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"mixed", m.ID}, RangeSegments: []string{"mixed"}}
+}
+func (m *Mixed) Gonetable_GSI1Key() gonetable.CompositeKey { // want `Gonetable_GSI1Key of Mixed has pointer receiver, but Mixed value is used as gonetable.Document`
+	return gonetable.CompositeKey{HashSegments: []string{"mixed"}, RangeSegments: []string{m.ID}}
+}
+
+// MixedPointer has the same receivers as Mixed, but it is registered
+// as pointer, so all methods are found
+type MixedPointer struct {
+	ID string
+}
+
+func (m MixedPointer) Gonetable_TypeID() string { return "mixedptr" }
+func (m MixedPointer) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"mixedptr", m.ID}, RangeSegments: []string{"mixedptr"}}
+}
+func (m *MixedPointer) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"mixedptr"}, RangeSegments: []string{m.ID}}
+}
+
+// MixedArgument is used by value as function argument
+type MixedArgument struct {
+	ID string
+}
+
+func (m MixedArgument) Gonetable_TypeID() string { return "mixedarg" }
+func (m MixedArgument) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"mixedarg", m.ID}, RangeSegments: []string{"mixedarg"}}
+}
+func (m *MixedArgument) Gonetable_GSI1Key() gonetable.CompositeKey { // want `Gonetable_GSI1Key of MixedArgument has pointer receiver, but MixedArgument value is used as gonetable.Document`
+	return gonetable.CompositeKey{HashSegments: []string{"mixedarg"}, RangeSegments: []string{m.ID}}
+}
+
+func schema() (*gonetable.Schema, error) {
+	gonetable.Register(&MixedPointer{}, MixedArgument{})
+	return gonetable.NewSchema([]gonetable.Document{&Good{}, Mixed{}, &MixedPointer{}})
+}
+
+type Base struct {
+	SK string // want `attribute SK of Reserved collides with the table sort key attribute`
+}
+
+type Reserved struct {
+	Base
+	ID      string
+	Kind    string `dynamodbav:"_Type"` // want `attribute _Type of Reserved collides with the type id attribute`
+	Ignored string `dynamodbav:"-"`
+	GSI1PK  string // want `attribute GSI1PK of Reserved collides with the GSI1 partition key attribute`
+	LSI1SK  string // want `attribute LSI1SK of Reserved collides with the LSI1 sort key attribute`
+}
+
+func (r *Reserved) Gonetable_TypeID() string { return "reserved" }
+func (r *Reserved) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"reserved", r.ID}, RangeSegments: []string{"reserved"}}
+}
+func (r *Reserved) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"reserved"}, RangeSegments: []string{r.ID}}
+}
+func (r *Reserved) Gonetable_LSI1SortKey() []string {
+	return []string{"reserved", r.Kind}
+}
+
+type BadKeys struct {
+	ID string
+}
+
+func (b *BadKeys) Gonetable_TypeID() string { return "bad" }
+func (b *BadKeys) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"bad#" + b.ID}, // want `key segment literal "bad#" contains key delimiter "#"`
+		RangeSegments: []string{"bad"},
+	}
+}
+func (b *BadKeys) Gonetable_GSI1Key() []string { // want `Gonetable_GSI1Key must have no parameters and return gonetable.CompositeKey`
+	return []string{"bad"}
+}
+func (b *BadKeys) Gonetable_LSI1SortKey() string { // want `Gonetable_LSI1SortKey must have no parameters and return \[\]string`
+	return "bad"
+}
+
+// not a document, Gonetable_Key is missing
+type Partial struct {
+	PK string
+}
+
+func (p Partial) Gonetable_TypeID() string { return "partial" }
+func (p *Partial) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{}
+}
@@ -0,0 +1,17 @@
+package gonetable
+
+type CompositeKey struct {
+	HashSegments  []string
+	RangeSegments []string
+}
+
+type Document interface {
+	Gonetable_Key() CompositeKey
+	Gonetable_TypeID() string
+}
+
+type Schema struct{}
+
+func NewSchema(docSamples []Document) (*Schema, error) { return &Schema{}, nil }
+
+func Register(docs ...Document) {}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/infra"
+)
+
+var (
+	errUnknownCommand = errors.New("unknown command")
+	errArguments      = errors.New("wrong number of arguments")
+	errUnknownIndex   = errors.New("index not in schema snapshot")
+	errUnknownType    = errors.New("type not in schema snapshot")
+	errNotFound       = errors.New("item not found")
+)
+
+// commands that don't need DynamoDB client
+var offlineCommands = map[string]bool{
+	"cloudformation": true,
+	"terraform":      true,
+}
+
+// cli runs commands against one table
+type cli struct {
+	client   gonetable.Client
+	table    string
+	snapshot gonetable.SchemaSnapshot
+	out      io.Writer
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	switch args[0] {
+	case "get":
+		return c.get(ctx, args[1:])
+	case "query":
+		return c.query(ctx, args[1:])
+	case "scan":
+		return c.scan(ctx, args[1:])
+	case "delete":
+		return c.delete(ctx, args[1:])
+	case "cloudformation", "terraform":
+		return c.render(args[0], args[1:])
+	}
+	return fmt.Errorf("%w: %s", errUnknownCommand, args[0])
+}
+
+func (c *cli) get(ctx context.Context, args []string) error {
+	hash, rng, ok := c.keyArgs(args)
+	if !ok {
+		return fmt.Errorf("%w: get <hash segments> -- <range segments>", errArguments)
+	}
+	out, err := c.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(c.table),
+		Key:            c.key(hash, rng),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+	if out.Item == nil {
+		return fmt.Errorf("%w: %s / %s", errNotFound, hash, rng)
+	}
+	return c.print(out.Item)
+}
+
+func (c *cli) delete(ctx context.Context, args []string) error {
+	hash, rng, ok := c.keyArgs(args)
+	if !ok {
+		return fmt.Errorf("%w: delete <hash segments> -- <range segments>", errArguments)
+	}
+	out, err := c.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:    aws.String(c.table),
+		Key:          c.key(hash, rng),
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return err
+	}
+	if out.Attributes == nil {
+		return fmt.Errorf("%w: %s / %s", errNotFound, hash, rng)
+	}
+	return c.print(out.Attributes)
+}
+
+func (c *cli) query(ctx context.Context, args []string) error {
+	fs := c.flagSet("query")
+	prefix := fs.String("prefix", "", "range key prefix as stored")
+	index := fs.String("index", "", "secondary index")
+	desc := fs.Bool("desc", false, "descending order")
+	limit := fs.Int("limit", 0, "maximum number of items, 0 for no limit")
+	args, prefixSegments, hasPrefixSegments := cutArgs(args)
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 || (hasPrefixSegments && (len(prefixSegments) == 0 || *prefix != "")) {
+		return fmt.Errorf("%w: query <hash segments> [-- <range prefix segments>] [flags]", errArguments)
+	}
+	if hasPrefixSegments {
+		*prefix = c.join(prefixSegments)
+	}
+	pkName, skName := "PK", "SK"
+	if *index != "" {
+		is, ok := c.indexByName(*index)
+		if !ok {
+			return fmt.Errorf("%w: %s", errUnknownIndex, *index)
+		}
+		if !is.Local {
+			pkName = is.Name + "PK"
+		}
+		skName = is.Name + "SK"
+	}
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(c.table),
+		KeyConditionExpression: aws.String("#pk = :pk"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk": pkName,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: c.join(pos)},
+		},
+		ScanIndexForward: aws.Bool(!*desc),
+	}
+	if *index != "" {
+		input.IndexName = index
+	}
+	if *prefix != "" {
+		input.KeyConditionExpression = aws.String("#pk = :pk AND begins_with(#sk, :sk)")
+		input.ExpressionAttributeNames["#sk"] = skName
+		input.ExpressionAttributeValues[":sk"] = &types.AttributeValueMemberS{Value: *prefix}
+	}
+	if *limit > 0 {
+		input.Limit = aws.Int32(int32(*limit))
+	}
+	for n := 0; ; {
+		out, err := c.client.Query(ctx, input)
+		if err != nil {
+			return err
+		}
+		for _, item := range out.Items {
+			if err := c.print(item); err != nil {
+				return err
+			}
+			if n++; n == *limit {
+				return nil
+			}
+		}
+		if out.LastEvaluatedKey == nil {
+			return nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+func (c *cli) scan(ctx context.Context, args []string) error {
+	fs := c.flagSet("scan")
+	typeID := fs.String("type", "", "type id of the items")
+	limit := fs.Int("limit", 0, "maximum number of items, 0 for no limit")
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 0 {
+		return fmt.Errorf("%w: scan [flags]", errArguments)
+	}
+	input := &dynamodb.ScanInput{TableName: aws.String(c.table)}
+	if *typeID != "" {
+		if !c.hasType(*typeID) {
+			return fmt.Errorf("%w: %s", errUnknownType, *typeID)
+		}
+		input.FilterExpression = aws.String("#t = :t")
+		input.ExpressionAttributeNames = map[string]string{"#t": "_Type"}
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":t": &types.AttributeValueMemberS{Value: *typeID},
+		}
+	}
+	for n := 0; ; {
+		out, err := c.client.Scan(ctx, input)
+		if err != nil {
+			return err
+		}
+		for _, item := range out.Items {
+			if err := c.print(item); err != nil {
+				return err
+			}
+			if n++; n == *limit {
+				return nil
+			}
+		}
+		if out.LastEvaluatedKey == nil {
+			return nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// render prints the table definition for cloudformation or terraform
+func (c *cli) render(format string, args []string) error {
+	fs := c.flagSet(format)
+	logicalID := fs.String("id", "Table", "CloudFormation logical id")
+	name := fs.String("name", "table", "Terraform resource name")
+	read := fs.Int64("read", 0, "provisioned read capacity, 0 for on-demand")
+	write := fs.Int64("write", 0, "provisioned write capacity")
+	stream := fs.String("stream", "", "stream view type, e.g. NEW_IMAGE")
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 0 {
+		return fmt.Errorf("%w: %s [flags]", errArguments, format)
+	}
+	opts := []gonetable.CreateTableOption{}
+	if *read > 0 || *write > 0 {
+		opts = append(opts, gonetable.WithProvisionedThroughput(*read, *write))
+	}
+	if *stream != "" {
+		opts = append(opts, gonetable.WithStream(types.StreamViewType(*stream)))
+	}
+	input, err := c.snapshot.CreateTableInput(c.table, opts...)
+	if err != nil {
+		return err
+	}
+	if format == "cloudformation" {
+		return infra.CloudFormation(c.out, *logicalID, input)
+	}
+	return infra.Terraform(c.out, *name, input)
+}
+
+// keyArgs returns the hash and range key from arguments
+// "<hash segments> -- <range segments>", or from two arguments that
+// have the keys as stored.
+func (c *cli) keyArgs(args []string) (hash, rng string, ok bool) {
+	hashSegments, rangeSegments, found := cutArgs(args)
+	if !found {
+		if len(args) != 2 {
+			return "", "", false
+		}
+		hashSegments, rangeSegments = args[:1], args[1:]
+	}
+	if len(hashSegments) == 0 || len(rangeSegments) == 0 {
+		return "", "", false
+	}
+	return c.join(hashSegments), c.join(rangeSegments), true
+}
+
+// join joins key segments with the key delimiter of the snapshot
+func (c *cli) join(segments []string) string {
+	return strings.Join(segments, c.snapshot.KeyDelimiter)
+}
+
+func (c *cli) key(hash, rng string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: hash},
+		"SK": &types.AttributeValueMemberS{Value: rng},
+	}
+}
+
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func (c *cli) indexByName(name string) (gonetable.IndexSnapshot, bool) {
+	for _, is := range c.snapshot.Indexes {
+		if is.Name == name {
+			return is, true
+		}
+	}
+	return gonetable.IndexSnapshot{}, false
+}
+
+func (c *cli) hasType(typeID string) bool {
+	for _, ts := range c.snapshot.Types {
+		if ts.TypeID == typeID {
+			return true
+		}
+	}
+	return false
+}
+
+// cutArgs splits arguments around the first "--"
+func cutArgs(args []string) (before, after []string, found bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:], true
+		}
+	}
+	return args, nil, false
+}
+
+// parseInterspersed parses flags that may come after positional
+// arguments, e.g. "ed#1 -prefix post", and returns the positional
+// arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	pos := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos, nil
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
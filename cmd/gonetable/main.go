@@ -0,0 +1,130 @@
+// Command gonetable reads and deletes items of a single-table design
+// table by composite key, without writing Go code. Index names and the
+// key delimiter come from a schema snapshot written with
+// SchemaSnapshot.Write.
+//
+//	gonetable -schema schema.json -table app get ed 1 -- ed
+//	gonetable -schema schema.json -table app query ed 1 -- post
+//	gonetable -schema schema.json -table app query post -index GSI1
+//	gonetable -schema schema.json -table app scan -type ed
+//	gonetable -schema schema.json -table app delete ed 1 -- post hello
+//	gonetable -schema schema.json -table app terraform -stream NEW_IMAGE
+//
+// Keys are given as segments, hash segments first and range segments
+// after "--", and joined with the key delimiter of the snapshot. Get
+// and delete also accept the hash and range key as stored, e.g.
+// get 'ed#1' ed, and query accepts the stored range prefix with
+// -prefix.
+// Items are printed as indented JSON with _Type, the key attributes
+// split to segments, and the other attributes.
+//
+// Commands cloudformation and terraform print the table definition for
+// infrastructure tools, and don't connect to DynamoDB.
+//
+// Without -endpoint, AWS configuration is read from the environment
+// like in other AWS tools.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/juranki/gonetable"
+)
+
+const usage = `usage: gonetable [flags] <command> [args]
+
+commands:
+  get <hash segments> -- <range segments>
+                                          read one item
+  query <hash segments> [-- <range prefix segments>]
+        [-prefix stored range prefix] [-index name] [-desc] [-limit n]
+                                          read items with the hash key
+  scan [-type id] [-limit n]              read all items, or items of a type
+  delete <hash segments> -- <range segments>
+                                          delete one item and print it
+
+Segments are joined with the key delimiter of the schema snapshot.
+Get and delete also accept two arguments, the keys as stored.
+  cloudformation [-id TableID] [table flags]
+                                          print AWS::DynamoDB::Table resource
+  terraform [-name table] [table flags]   print aws_dynamodb_table resource
+
+table flags: [-read n -write n] [-stream view type]
+
+flags:
+`
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "gonetable:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("gonetable", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	schemaPath := fs.String("schema", "", "schema snapshot file (required)")
+	tableName := fs.String("table", "", "table name (required)")
+	endpoint := fs.String("endpoint", "", "DynamoDB endpoint, e.g. http://localhost:8000")
+	region := fs.String("region", "", "AWS region")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *schemaPath == "" || *tableName == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	snapshot, err := readSnapshot(*schemaPath)
+	if err != nil {
+		return err
+	}
+	c := &cli{table: *tableName, snapshot: snapshot, out: stdout}
+	if !offlineCommands[fs.Arg(0)] {
+		if c.client, err = newClient(ctx, *endpoint, *region); err != nil {
+			return err
+		}
+	}
+	return c.run(ctx, fs.Args())
+}
+
+func readSnapshot(path string) (gonetable.SchemaSnapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return gonetable.SchemaSnapshot{}, err
+	}
+	defer f.Close()
+	return gonetable.ReadSnapshot(f)
+}
+
+func newClient(ctx context.Context, endpoint, region string) (*dynamodb.Client, error) {
+	opts := []func(*config.LoadOptions) error{}
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	if endpoint != "" {
+		opts = append(opts, config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(
+			func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+				return aws.Endpoint{URL: endpoint}, nil
+			})))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return dynamodb.NewFromConfig(cfg), nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/memddb"
+)
+
+type Editor struct {
+	ID   string
+	Name string
+}
+
+func (e *Editor) Gonetable_TypeID() string { return "ed" }
+func (e *Editor) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"ed", e.ID},
+		RangeSegments: []string{"ed"},
+	}
+}
+
+type Post struct {
+	EditorID string
+	Slug     string
+	Views    int
+}
+
+func (p *Post) Gonetable_TypeID() string { return "post" }
+func (p *Post) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"ed", p.EditorID},
+		RangeSegments: []string{"post", p.Slug},
+	}
+}
+func (p *Post) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"post"},
+		RangeSegments: []string{p.Slug},
+	}
+}
+
+func newCLI(t *testing.T) (*cli, *bytes.Buffer) {
+	ctx := context.Background()
+	s, err := gonetable.NewSchema([]gonetable.Document{&Editor{}, &Post{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := gonetable.NewTable("test", s, memddb.New())
+	if _, err := table.Ensure(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range []gonetable.Document{
+		&Editor{ID: "1", Name: "x"},
+		&Post{EditorID: "1", Slug: "a", Views: 3},
+		&Post{EditorID: "1", Slug: "b"},
+	} {
+		if err := table.Put(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := &bytes.Buffer{}
+	return &cli{client: table.Client(), table: "test", snapshot: s.Snapshot(), out: out}, out
+}
+
+// decodeItems decodes the printed items
+func decodeItems(t *testing.T, out *bytes.Buffer) []printedItem {
+	rv := []printedItem{}
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		p := printedItem{}
+		if err := dec.Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		rv = append(rv, p)
+	}
+	return rv
+}
+
+func TestGet(t *testing.T) {
+	c, out := newCLI(t)
+	if err := c.run(context.Background(), []string{"get", "ed#1", "post#a"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "_Type": "post",
+  "keys": {
+    "GSI1PK": [
+      "post"
+    ],
+    "GSI1SK": [
+      "a"
+    ],
+    "PK": [
+      "ed",
+      "1"
+    ],
+    "SK": [
+      "post",
+      "a"
+    ]
+  },
+  "attributes": {
+    "EditorID": "1",
+    "Slug": "a",
+    "Views": 3
+  }
+}
+`
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"query", "ed#1"}, []string{"ed", "post", "post"}},
+		{[]string{"query", "ed#1", "--prefix", "post"}, []string{"post", "post"}},
+		{[]string{"query", "ed", "1", "--", "post", "b"}, []string{"post"}},
+		{[]string{"query", "ed", "-desc", "1", "--", "post"}, []string{"post", "post"}},
+		{[]string{"get", "ed", "1", "--", "post", "a"}, []string{"post"}},
+		{[]string{"query", "-desc", "-limit", "1", "ed#1"}, []string{"post"}},
+		{[]string{"query", "post", "-index", "GSI1"}, []string{"post", "post"}},
+		{[]string{"scan"}, []string{"ed", "post", "post"}},
+		{[]string{"scan", "-type", "ed"}, []string{"ed"}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			c, out := newCLI(t)
+			if err := c.run(context.Background(), tt.args); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, p := range decodeItems(t, out) {
+				got = append(got, p.TypeID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("types = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	ctx := context.Background()
+	c, out := newCLI(t)
+	if err := c.run(ctx, []string{"delete", "ed", "1", "--", "post", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if items := decodeItems(t, out); len(items) != 1 || items[0].Attributes["Slug"] != "b" {
+		t.Errorf("deleted = %+v", items)
+	}
+	if err := c.run(ctx, []string{"get", "ed#1", "post#b"}); !errors.Is(err, errNotFound) {
+		t.Errorf("get after delete error = %v, want %v", err, errNotFound)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{
+			[]string{"cloudformation", "-id", "Posts"},
+			[]string{"Posts:\n  Type: AWS::DynamoDB::Table\n", "TableName: test\n", "IndexName: GSI1\n"},
+		},
+		{
+			[]string{"terraform", "-read", "5", "-write", "1", "-stream", "NEW_IMAGE"},
+			[]string{`resource "aws_dynamodb_table" "table" {`, `read_capacity    = 5`, `stream_view_type = "NEW_IMAGE"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			c, out := newCLI(t)
+			c.client = nil
+			if err := c.run(context.Background(), tt.args); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output doesn't contain %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want error
+	}{
+		{[]string{"put"}, errUnknownCommand},
+		{[]string{"get", "ed#1"}, errArguments},
+		{[]string{"get", "ed", "1", "post"}, errArguments},
+		{[]string{"get", "ed", "1", "--"}, errArguments},
+		{[]string{"query", "ed", "1", "-prefix", "post", "--", "post"}, errArguments},
+		{[]string{"get", "ed#2", "ed"}, errNotFound},
+		{[]string{"delete", "ed#2", "ed"}, errNotFound},
+		{[]string{"query", "post", "-index", "GSI2"}, errUnknownIndex},
+		{[]string{"scan", "-type", "foo"}, errUnknownType},
+	}
+	c, _ := newCLI(t)
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			if err := c.run(context.Background(), tt.args); !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
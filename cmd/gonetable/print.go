@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// printedItem is the JSON form of an item
+type printedItem struct {
+	TypeID string `json:"_Type"`
+	// Key attributes split to segments
+	Keys       map[string][]string    `json:"keys"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// print writes the item as indented JSON
+func (c *cli) print(item map[string]types.AttributeValue) error {
+	keyNames := c.keyAttributes()
+	p := printedItem{Keys: map[string][]string{}, Attributes: map[string]interface{}{}}
+	for name, av := range item {
+		s, isString := av.(*types.AttributeValueMemberS)
+		switch {
+		case name == "_Type" && isString:
+			p.TypeID = s.Value
+		case keyNames[name] && isString:
+			p.Keys[name] = strings.Split(s.Value, c.snapshot.KeyDelimiter)
+		default:
+			p.Attributes[name] = plainValue(av)
+		}
+	}
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = c.out.Write(append(b, '\n'))
+	return err
+}
+
+// keyAttributes returns names of the table and index key attributes
+func (c *cli) keyAttributes() map[string]bool {
+	rv := map[string]bool{"PK": true, "SK": true}
+	for _, is := range c.snapshot.Indexes {
+		if !is.Local {
+			rv[is.Name+"PK"] = true
+		}
+		rv[is.Name+"SK"] = true
+	}
+	return rv
+}
+
+// plainValue converts attribute value to a value that encodes to JSON
+// naturally. Numbers are kept as they are stored, and sets become
+// arrays.
+func plainValue(av types.AttributeValue) interface{} {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case *types.AttributeValueMemberN:
+		return json.Number(v.Value)
+	case *types.AttributeValueMemberB:
+		return v.Value
+	case *types.AttributeValueMemberBOOL:
+		return v.Value
+	case *types.AttributeValueMemberNULL:
+		return nil
+	case *types.AttributeValueMemberSS:
+		return v.Value
+	case *types.AttributeValueMemberNS:
+		rv := make([]json.Number, len(v.Value))
+		for i, n := range v.Value {
+			rv[i] = json.Number(n)
+		}
+		return rv
+	case *types.AttributeValueMemberBS:
+		return v.Value
+	case *types.AttributeValueMemberL:
+		rv := make([]interface{}, len(v.Value))
+		for i, elem := range v.Value {
+			rv[i] = plainValue(elem)
+		}
+		return rv
+	case *types.AttributeValueMemberM:
+		rv := make(map[string]interface{}, len(v.Value))
+		for k, elem := range v.Value {
+			rv[k] = plainValue(elem)
+		}
+		return rv
+	}
+	return nil
+}
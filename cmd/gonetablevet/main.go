@@ -0,0 +1,15 @@
+// Command gonetablevet checks gonetable document types. It can be run
+// standalone or as a vet tool:
+//
+//	go install github.com/juranki/gonetable/cmd/gonetablevet
+//	go vet -vettool=$(which gonetablevet) ./...
+package main
+
+import (
+	"github.com/juranki/gonetable/analysis/doccheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(doccheck.Analyzer)
+}
@@ -0,0 +1,149 @@
+package gonetable
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	ErrBillingMode          = errors.New("index throughput requires provisioned billing mode")
+	ErrLocalIndexThroughput = errors.New("local secondary index uses throughput of the table")
+)
+
+type createTableOptions struct {
+	throughput      *types.ProvisionedThroughput
+	indexThroughput map[string]*types.ProvisionedThroughput
+	stream          *types.StreamSpecification
+	sse             *types.SSESpecification
+	tableClass      types.TableClass
+	tags            map[string]string
+}
+
+// CreateTableOption modifies table definition returned by
+// Schema.CreateTableInput.
+type CreateTableOption func(*createTableOptions)
+
+// WithProvisionedThroughput switches the table from on-demand to
+// provisioned billing mode. GSIs get the same throughput unless
+// WithIndexThroughput is used.
+func WithProvisionedThroughput(read, write int64) CreateTableOption {
+	return func(o *createTableOptions) {
+		o.throughput = &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(read),
+			WriteCapacityUnits: aws.Int64(write),
+		}
+	}
+}
+
+// WithIndexThroughput sets provisioned throughput of a GSI.
+func WithIndexThroughput(index string, read, write int64) CreateTableOption {
+	return func(o *createTableOptions) {
+		if o.indexThroughput == nil {
+			o.indexThroughput = map[string]*types.ProvisionedThroughput{}
+		}
+		o.indexThroughput[index] = &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(read),
+			WriteCapacityUnits: aws.Int64(write),
+		}
+	}
+}
+
+// WithStream enables DynamoDB stream with given view type.
+func WithStream(viewType types.StreamViewType) CreateTableOption {
+	return func(o *createTableOptions) {
+		o.stream = &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: viewType,
+		}
+	}
+}
+
+// WithSSE enables server side encryption with KMS key. Empty key id
+// uses the AWS managed key. The key can be given as id, ARN or alias,
+// but infra.Terraform needs the ARN.
+func WithSSE(kmsKeyID string) CreateTableOption {
+	return func(o *createTableOptions) {
+		o.sse = &types.SSESpecification{
+			Enabled: aws.Bool(true),
+			SSEType: types.SSETypeKms,
+		}
+		if kmsKeyID != "" {
+			o.sse.KMSMasterKeyId = aws.String(kmsKeyID)
+		}
+	}
+}
+
+// WithTableClass sets the table class.
+func WithTableClass(class types.TableClass) CreateTableOption {
+	return func(o *createTableOptions) {
+		o.tableClass = class
+	}
+}
+
+// WithTags adds tags to the table. Can be used multiple times.
+func WithTags(tags map[string]string) CreateTableOption {
+	return func(o *createTableOptions) {
+		if o.tags == nil {
+			o.tags = map[string]string{}
+		}
+		for k, v := range tags {
+			o.tags[k] = v
+		}
+	}
+}
+
+// Returns complete input for creating a table for the schema.
+//
+// Table uses on-demand billing mode unless WithProvisionedThroughput
+// is given. Tags are sorted by key.
+func (s *Schema) CreateTableInput(name string, opts ...CreateTableOption) (*dynamodb.CreateTableInput, error) {
+	o := createTableOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for _, idx := range sortedKeys(o.indexThroughput) {
+		switch {
+		case contains(s.localIndeces, idx):
+			return nil, &SchemaError{Index: idx, Reason: ErrLocalIndexThroughput}
+		case !contains(s.indeces, idx):
+			return nil, &SchemaError{Index: idx, Reason: ErrUnknownIndex}
+		case o.throughput == nil:
+			return nil, &SchemaError{Index: idx, Reason: ErrBillingMode}
+		}
+	}
+	input := &dynamodb.CreateTableInput{
+		TableName:              aws.String(name),
+		AttributeDefinitions:   s.AttributeDefinitions(),
+		KeySchema:              s.KeySchema(),
+		GlobalSecondaryIndexes: s.GlobalSecondaryIndexes(),
+		LocalSecondaryIndexes:  s.LocalSecondaryIndexes(),
+		BillingMode:            types.BillingModePayPerRequest,
+		StreamSpecification:    o.stream,
+		SSESpecification:       o.sse,
+		TableClass:             o.tableClass,
+	}
+	if o.throughput != nil {
+		input.BillingMode = types.BillingModeProvisioned
+		input.ProvisionedThroughput = o.throughput
+		for i, gsi := range input.GlobalSecondaryIndexes {
+			throughput, ok := o.indexThroughput[*gsi.IndexName]
+			if !ok {
+				throughput = o.throughput
+			}
+			input.GlobalSecondaryIndexes[i].ProvisionedThroughput = &types.ProvisionedThroughput{
+				ReadCapacityUnits:  throughput.ReadCapacityUnits,
+				WriteCapacityUnits: throughput.WriteCapacityUnits,
+			}
+		}
+	}
+	for _, k := range sortedKeys(o.tags) {
+		input.Tags = append(input.Tags, types.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(o.tags[k]),
+		})
+	}
+	return input, nil
+}
@@ -0,0 +1,109 @@
+package gonetable_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+)
+
+func TestSchema_CreateTableInput(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&WithTwoIndeces{}, &WithLocalIndex{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input, err := s.CreateTableInput("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *input.TableName != "test" || input.BillingMode != types.BillingModePayPerRequest {
+		t.Errorf("TableName = %s, BillingMode = %s", *input.TableName, input.BillingMode)
+	}
+	if len(input.GlobalSecondaryIndexes) != 2 || len(input.LocalSecondaryIndexes) != 1 {
+		t.Errorf("got %d GSIs and %d LSIs, want 2 and 1",
+			len(input.GlobalSecondaryIndexes), len(input.LocalSecondaryIndexes))
+	}
+
+	input, err = s.CreateTableInput("test",
+		gonetable.WithProvisionedThroughput(5, 1),
+		gonetable.WithIndexThroughput("GSI2", 2, 1),
+		gonetable.WithStream(types.StreamViewTypeNewAndOldImages),
+		gonetable.WithSSE("key"),
+		gonetable.WithTableClass(types.TableClassStandardInfrequentAccess),
+		gonetable.WithTags(map[string]string{"b": "2", "a": "1"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.BillingMode != types.BillingModeProvisioned {
+		t.Errorf("BillingMode = %s", input.BillingMode)
+	}
+	wantThroughput := map[string]int64{"GSI1": 5, "GSI2": 2}
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		if got := *gsi.ProvisionedThroughput.ReadCapacityUnits; got != wantThroughput[*gsi.IndexName] {
+			t.Errorf("%s read capacity = %d, want %d", *gsi.IndexName, got, wantThroughput[*gsi.IndexName])
+		}
+	}
+	if input.StreamSpecification.StreamViewType != types.StreamViewTypeNewAndOldImages {
+		t.Errorf("StreamSpecification = %v", input.StreamSpecification)
+	}
+	if *input.SSESpecification.KMSMasterKeyId != "key" {
+		t.Errorf("SSESpecification = %v", input.SSESpecification)
+	}
+	if input.TableClass != types.TableClassStandardInfrequentAccess {
+		t.Errorf("TableClass = %v", input.TableClass)
+	}
+	wantTags := []types.Tag{
+		{Key: aws.String("a"), Value: aws.String("1")},
+		{Key: aws.String("b"), Value: aws.String("2")},
+	}
+	if !reflect.DeepEqual(input.Tags, wantTags) {
+		t.Errorf("Tags = %v, want %v", input.Tags, wantTags)
+	}
+}
+
+func TestSchema_CreateTableInputErrors(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&WithIndex{}, &WithLocalIndex{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		opts    []gonetable.CreateTableOption
+		wantErr error
+	}{
+		{
+			name:    "on-demand",
+			opts:    []gonetable.CreateTableOption{gonetable.WithIndexThroughput("GSI1", 1, 1)},
+			wantErr: gonetable.ErrBillingMode,
+		},
+		{
+			name: "local index",
+			opts: []gonetable.CreateTableOption{
+				gonetable.WithProvisionedThroughput(1, 1),
+				gonetable.WithIndexThroughput("LSI1", 1, 1),
+			},
+			wantErr: gonetable.ErrLocalIndexThroughput,
+		},
+		{
+			name: "unknown index",
+			opts: []gonetable.CreateTableOption{
+				gonetable.WithProvisionedThroughput(1, 1),
+				gonetable.WithIndexThroughput("GSI9", 1, 1),
+			},
+			wantErr: gonetable.ErrUnknownIndex,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CreateTableInput("test", tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
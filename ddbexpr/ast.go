@@ -0,0 +1,263 @@
+// Package ddbexpr parses DynamoDB expressions, with #name and :value
+// placeholders, and evaluates them against attribute value maps.
+//
+// Condition, filter and key condition expressions are parsed with
+// ParseCondition, update expressions with ParseUpdate and projection
+// expressions with ParseProjection. Placeholders are resolved when the
+// expression is evaluated, so a parsed expression can be reused with
+// different values.
+package ddbexpr
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Placeholders are the ExpressionAttributeNames and
+// ExpressionAttributeValues of a request.
+type Placeholders struct {
+	Names  map[string]string
+	Values map[string]types.AttributeValue
+}
+
+// PathElement is attribute name or list index of a document path.
+// Name can be a #name placeholder. Index is used when Name is empty.
+type PathElement struct {
+	Name  string
+	Index int
+}
+
+// Path is a document path, e.g. a.b[1].c
+type Path []PathElement
+
+func (p Path) String() string {
+	b := strings.Builder{}
+	for i, elem := range p {
+		switch {
+		case elem.Name == "":
+			b.WriteString("[" + strconv.Itoa(elem.Index) + "]")
+		case i > 0:
+			b.WriteString("." + elem.Name)
+		default:
+			b.WriteString(elem.Name)
+		}
+	}
+	return b.String()
+}
+
+// Resolve replaces #name placeholders with attribute names.
+func (p Path) Resolve(names map[string]string) (Path, error) {
+	rv := make(Path, len(p))
+	for i, elem := range p {
+		if strings.HasPrefix(elem.Name, "#") {
+			name, ok := names[elem.Name]
+			if !ok {
+				return nil, &ValidationError{
+					Msg: "An expression attribute name used in the document path is not defined; attribute name: " + elem.Name,
+				}
+			}
+			elem.Name = name
+		}
+		rv[i] = elem
+	}
+	return rv, nil
+}
+
+// Expression is a parsed expression: Condition, *Update or Projection.
+type Expression interface {
+	String() string
+	placeholders(u *usage)
+}
+
+// Operand is a value in an expression: PathOperand, ValueOperand,
+// FunctionOperand or Arithmetic.
+type Operand interface {
+	String() string
+	placeholders(u *usage)
+}
+
+type PathOperand struct {
+	Path Path
+}
+
+// ValueOperand is a :value placeholder
+type ValueOperand struct {
+	Name string
+}
+
+// FunctionOperand is a function that returns a value, size in
+// conditions and if_not_exists and list_append in updates.
+type FunctionOperand struct {
+	Name string
+	Args []Operand
+}
+
+// Arithmetic is addition or subtraction of numbers in SET action.
+type Arithmetic struct {
+	Op          string
+	Left, Right Operand
+}
+
+func (o PathOperand) String() string  { return o.Path.String() }
+func (o ValueOperand) String() string { return o.Name }
+func (o FunctionOperand) String() string {
+	return o.Name + "(" + joinOperands(o.Args) + ")"
+}
+func (o Arithmetic) String() string { return o.Left.String() + " " + o.Op + " " + o.Right.String() }
+
+func joinOperands(operands []Operand) string {
+	parts := make([]string, len(operands))
+	for i, o := range operands {
+		parts[i] = o.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Condition is a parsed condition, filter or key condition expression.
+type Condition interface {
+	Expression
+	eval(env *env) (bool, error)
+}
+
+// Comparison is one of =, <>, <, <=, > and >=
+type Comparison struct {
+	Op          string
+	Left, Right Operand
+}
+
+type Between struct {
+	Operand   Operand
+	Low, High Operand
+}
+
+type In struct {
+	Operand Operand
+	List    []Operand
+}
+
+type And struct {
+	Left, Right Condition
+}
+
+type Or struct {
+	Left, Right Condition
+}
+
+type Not struct {
+	Condition Condition
+}
+
+// FunctionCondition is attribute_exists, attribute_not_exists,
+// attribute_type, begins_with or contains.
+type FunctionCondition struct {
+	Name string
+	Args []Operand
+}
+
+func (c Comparison) String() string { return c.Left.String() + " " + c.Op + " " + c.Right.String() }
+func (c Between) String() string {
+	return c.Operand.String() + " BETWEEN " + c.Low.String() + " AND " + c.High.String()
+}
+func (c In) String() string  { return c.Operand.String() + " IN (" + joinOperands(c.List) + ")" }
+func (c And) String() string { return "(" + c.Left.String() + " AND " + c.Right.String() + ")" }
+func (c Or) String() string  { return "(" + c.Left.String() + " OR " + c.Right.String() + ")" }
+func (c Not) String() string { return "NOT " + c.Condition.String() }
+func (c FunctionCondition) String() string {
+	return c.Name + "(" + joinOperands(c.Args) + ")"
+}
+
+// Update is a parsed update expression.
+type Update struct {
+	Set    []SetAction
+	Remove []Path
+	Add    []AddAction
+	Delete []DeleteAction
+}
+
+// SetAction sets attribute at Path to Value
+type SetAction struct {
+	Path  Path
+	Value Operand
+}
+
+// AddAction adds Value to number or set at Path
+type AddAction struct {
+	Path  Path
+	Value Operand
+}
+
+// DeleteAction removes elements of Value from set at Path
+type DeleteAction struct {
+	Path  Path
+	Value Operand
+}
+
+func (u *Update) String() string {
+	clauses := []string{}
+	if len(u.Set) > 0 {
+		actions := make([]string, len(u.Set))
+		for i, a := range u.Set {
+			actions[i] = a.Path.String() + " = " + a.Value.String()
+		}
+		clauses = append(clauses, "SET "+strings.Join(actions, ", "))
+	}
+	if len(u.Remove) > 0 {
+		paths := make([]string, len(u.Remove))
+		for i, p := range u.Remove {
+			paths[i] = p.String()
+		}
+		clauses = append(clauses, "REMOVE "+strings.Join(paths, ", "))
+	}
+	if len(u.Add) > 0 {
+		actions := make([]string, len(u.Add))
+		for i, a := range u.Add {
+			actions[i] = a.Path.String() + " " + a.Value.String()
+		}
+		clauses = append(clauses, "ADD "+strings.Join(actions, ", "))
+	}
+	if len(u.Delete) > 0 {
+		actions := make([]string, len(u.Delete))
+		for i, a := range u.Delete {
+			actions[i] = a.Path.String() + " " + a.Value.String()
+		}
+		clauses = append(clauses, "DELETE "+strings.Join(actions, ", "))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// paths returns the paths that the update modifies
+func (u *Update) paths() []Path {
+	rv := []Path{}
+	for _, a := range u.Set {
+		rv = append(rv, a.Path)
+	}
+	rv = append(rv, u.Remove...)
+	for _, a := range u.Add {
+		rv = append(rv, a.Path)
+	}
+	for _, a := range u.Delete {
+		rv = append(rv, a.Path)
+	}
+	return rv
+}
+
+// Projection is a parsed projection expression.
+type Projection []Path
+
+func (p Projection) String() string {
+	paths := make([]string, len(p))
+	for i, path := range p {
+		paths[i] = path.String()
+	}
+	return strings.Join(paths, ", ")
+}
+
+// ValidationError is an invalid expression or placeholder. The
+// messages follow the messages of DynamoDB ValidationException.
+type ValidationError struct {
+	Msg string
+}
+
+func (e *ValidationError) Error() string { return e.Msg }
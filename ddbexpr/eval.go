@@ -0,0 +1,356 @@
+package ddbexpr
+
+import (
+	"bytes"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var attributeTypes = []string{"B", "NULL", "SS", "BOOL", "L", "BS", "N", "NS", "S", "M"}
+
+var errMissingAttribute = &ValidationError{Msg: "The provided expression refers to an attribute that does not exist in the item"}
+
+type env struct {
+	item map[string]types.AttributeValue
+	ph   Placeholders
+}
+
+// Evaluate tells whether item satisfies the condition. Item that
+// doesn't exist is an empty map.
+func Evaluate(c Condition, item map[string]types.AttributeValue, ph Placeholders) (bool, error) {
+	return c.eval(&env{item: item, ph: ph})
+}
+
+// Get returns the value at path in item. Path must not have
+// placeholders.
+func Get(item map[string]types.AttributeValue, path Path) (types.AttributeValue, bool) {
+	var current types.AttributeValue = &types.AttributeValueMemberM{Value: item}
+	for _, elem := range path {
+		switch v := current.(type) {
+		case *types.AttributeValueMemberM:
+			if elem.Name == "" {
+				return nil, false
+			}
+			next, ok := v.Value[elem.Name]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case *types.AttributeValueMemberL:
+			if elem.Name != "" || elem.Index >= len(v.Value) {
+				return nil, false
+			}
+			current = v.Value[elem.Index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func (e *env) value(o Operand) (types.AttributeValue, bool, error) {
+	switch o := o.(type) {
+	case ValueOperand:
+		v, ok := e.ph.Values[o.Name]
+		if !ok {
+			return nil, false, &ValidationError{
+				Msg: "An expression attribute value used in expression is not defined; attribute value: " + o.Name,
+			}
+		}
+		return v, true, nil
+	case PathOperand:
+		path, err := o.Path.Resolve(e.ph.Names)
+		if err != nil {
+			return nil, false, err
+		}
+		v, ok := Get(e.item, path)
+		return v, ok, nil
+	case FunctionOperand:
+		return e.function(o)
+	case Arithmetic:
+		return e.arithmetic(o)
+	}
+	panic("unknown operand")
+}
+
+// requirePath returns error if operand of function is not a path
+func requirePath(function string, o Operand) error {
+	if _, ok := o.(PathOperand); !ok {
+		return &ValidationError{Msg: "Operator or function requires a document path; operator or function: " + function}
+	}
+	return nil
+}
+
+func operandTypeError(function string, v types.AttributeValue) error {
+	return &ValidationError{Msg: "Incorrect operand type for operator or function; operator or function: " + function + ", operand type: " + typeName(v)}
+}
+
+var errUpdateOperandType = &ValidationError{Msg: "An operand in the update expression has an incorrect data type"}
+
+func (e *env) arithmetic(a Arithmetic) (types.AttributeValue, bool, error) {
+	operands := [2]types.AttributeValue{}
+	for i, o := range []Operand{a.Left, a.Right} {
+		v, ok, err := e.value(o)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			return nil, false, errMissingAttribute
+		}
+		operands[i] = v
+	}
+	rv, err := sum(operands[0], operands[1], a.Op == "-")
+	return rv, err == nil, err
+}
+
+// sum adds or subtracts two number values
+func sum(a, b types.AttributeValue, subtract bool) (types.AttributeValue, error) {
+	operands := [2]*big.Rat{}
+	for i, v := range []types.AttributeValue{a, b} {
+		n, isN := v.(*types.AttributeValueMemberN)
+		if !isN {
+			return nil, errUpdateOperandType
+		}
+		var ok bool
+		if operands[i], ok = new(big.Rat).SetString(n.Value); !ok {
+			return nil, errUpdateOperandType
+		}
+	}
+	result := new(big.Rat)
+	if subtract {
+		result.Sub(operands[0], operands[1])
+	} else {
+		result.Add(operands[0], operands[1])
+	}
+	return &types.AttributeValueMemberN{Value: formatNumber(result)}, nil
+}
+
+// formatNumber formats decimal number without exponent and trailing
+// zeros
+func formatNumber(r *big.Rat) string {
+	scale := 0
+	scaled := new(big.Rat).Set(r)
+	for !scaled.IsInt() && scale < 40 {
+		scaled.Mul(scaled, big.NewRat(10, 1))
+		scale++
+	}
+	return r.FloatString(scale)
+}
+
+func (e *env) function(f FunctionOperand) (types.AttributeValue, bool, error) {
+	switch f.Name {
+	case "size":
+		if err := requirePath(f.Name, f.Args[0]); err != nil {
+			return nil, false, err
+		}
+		v, ok, err := e.value(f.Args[0])
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		size := 0
+		switch v := v.(type) {
+		case *types.AttributeValueMemberS:
+			size = utf8.RuneCountInString(v.Value)
+		case *types.AttributeValueMemberB:
+			size = len(v.Value)
+		case *types.AttributeValueMemberSS:
+			size = len(v.Value)
+		case *types.AttributeValueMemberNS:
+			size = len(v.Value)
+		case *types.AttributeValueMemberBS:
+			size = len(v.Value)
+		case *types.AttributeValueMemberL:
+			size = len(v.Value)
+		case *types.AttributeValueMemberM:
+			size = len(v.Value)
+		default:
+			return nil, false, nil
+		}
+		return &types.AttributeValueMemberN{Value: strconv.Itoa(size)}, true, nil
+	case "if_not_exists":
+		if err := requirePath(f.Name, f.Args[0]); err != nil {
+			return nil, false, err
+		}
+		v, ok, err := e.value(f.Args[0])
+		if err != nil || ok {
+			return v, ok, err
+		}
+		return e.value(f.Args[1])
+	case "list_append":
+		list := &types.AttributeValueMemberL{Value: []types.AttributeValue{}}
+		for _, arg := range f.Args {
+			v, ok, err := e.value(arg)
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				return nil, false, errMissingAttribute
+			}
+			l, isL := v.(*types.AttributeValueMemberL)
+			if !isL {
+				return nil, false, errUpdateOperandType
+			}
+			list.Value = append(list.Value, l.Value...)
+		}
+		return list, true, nil
+	}
+	return nil, false, &ValidationError{Msg: "Invalid function name; function: " + f.Name}
+}
+
+func (c Comparison) eval(e *env) (bool, error) {
+	left, okLeft, err := e.value(c.Left)
+	if err != nil {
+		return false, err
+	}
+	right, okRight, err := e.value(c.Right)
+	if err != nil {
+		return false, err
+	}
+	switch c.Op {
+	case "=":
+		return okLeft && okRight && Equal(left, right), nil
+	case "<>":
+		return !(okLeft && okRight && Equal(left, right)), nil
+	}
+	if !okLeft || !okRight {
+		return false, nil
+	}
+	cmp, ok := Compare(left, right)
+	if !ok {
+		return false, nil
+	}
+	switch c.Op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	}
+	return cmp >= 0, nil
+}
+
+func (c Between) eval(e *env) (bool, error) {
+	low, err := Comparison{Op: ">=", Left: c.Operand, Right: c.Low}.eval(e)
+	if err != nil || !low {
+		return false, err
+	}
+	return Comparison{Op: "<=", Left: c.Operand, Right: c.High}.eval(e)
+}
+
+func (c In) eval(e *env) (bool, error) {
+	for _, o := range c.List {
+		eq, err := Comparison{Op: "=", Left: c.Operand, Right: o}.eval(e)
+		if err != nil || eq {
+			return eq, err
+		}
+	}
+	return false, nil
+}
+
+func (c And) eval(e *env) (bool, error) {
+	left, err := c.Left.eval(e)
+	if err != nil || !left {
+		return false, err
+	}
+	return c.Right.eval(e)
+}
+
+func (c Or) eval(e *env) (bool, error) {
+	left, err := c.Left.eval(e)
+	if err != nil || left {
+		return left, err
+	}
+	return c.Right.eval(e)
+}
+
+func (c Not) eval(e *env) (bool, error) {
+	rv, err := c.Condition.eval(e)
+	return !rv, err
+}
+
+func (c FunctionCondition) eval(e *env) (bool, error) {
+	if err := requirePath(c.Name, c.Args[0]); err != nil {
+		return false, err
+	}
+	v, ok, err := e.value(c.Args[0])
+	if err != nil {
+		return false, err
+	}
+	var arg types.AttributeValue
+	if len(c.Args) > 1 {
+		if arg, _, err = e.value(c.Args[1]); err != nil {
+			return false, err
+		}
+	}
+	switch c.Name {
+	case "attribute_exists":
+		return ok, nil
+	case "attribute_not_exists":
+		return !ok, nil
+	case "attribute_type":
+		t, isS := arg.(*types.AttributeValueMemberS)
+		if !isS {
+			return false, operandTypeError(c.Name, arg)
+		}
+		if !contains(attributeTypes, t.Value) {
+			return false, &ValidationError{Msg: "Invalid attribute type name found; type: " + t.Value + ", valid types: { B,NULL,SS,BOOL,L,BS,N,NS,S,M }"}
+		}
+		return ok && typeName(v) == t.Value, nil
+	case "begins_with":
+		switch arg.(type) {
+		case *types.AttributeValueMemberS, *types.AttributeValueMemberB:
+		default:
+			return false, operandTypeError(c.Name, arg)
+		}
+		if !ok {
+			return false, nil
+		}
+		switch v := v.(type) {
+		case *types.AttributeValueMemberS:
+			prefix, isS := arg.(*types.AttributeValueMemberS)
+			return isS && strings.HasPrefix(v.Value, prefix.Value), nil
+		case *types.AttributeValueMemberB:
+			prefix, isB := arg.(*types.AttributeValueMemberB)
+			return isB && bytes.HasPrefix(v.Value, prefix.Value), nil
+		}
+		return false, nil
+	case "contains":
+		if !ok {
+			return false, nil
+		}
+		return containsValue(v, arg), nil
+	}
+	return false, &ValidationError{Msg: "Invalid function name; function: " + c.Name}
+}
+
+func containsValue(v, arg types.AttributeValue) bool {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberS:
+		s, ok := arg.(*types.AttributeValueMemberS)
+		return ok && strings.Contains(v.Value, s.Value)
+	case *types.AttributeValueMemberB:
+		b, ok := arg.(*types.AttributeValueMemberB)
+		return ok && bytes.Contains(v.Value, b.Value)
+	case *types.AttributeValueMemberSS:
+		s, ok := arg.(*types.AttributeValueMemberS)
+		return ok && indexOf(v.Value, s.Value, func(x, y string) bool { return x == y }) >= 0
+	case *types.AttributeValueMemberNS:
+		n, ok := arg.(*types.AttributeValueMemberN)
+		return ok && indexOf(v.Value, n.Value, func(x, y string) bool { return compareNumbers(x, y) == 0 }) >= 0
+	case *types.AttributeValueMemberBS:
+		b, ok := arg.(*types.AttributeValueMemberB)
+		return ok && indexOf(v.Value, b.Value, bytes.Equal) >= 0
+	case *types.AttributeValueMemberL:
+		for _, item := range v.Value {
+			if Equal(item, arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,281 @@
+package ddbexpr_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/ddbexpr"
+)
+
+func mustMarshal(in interface{}) types.AttributeValue {
+	v, err := attributevalue.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func testItem() map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK":         mustMarshal("order#1"),
+		"Total":      mustMarshal(15),
+		"Tags":       &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"OrderLines": mustMarshal([]map[string]interface{}{{"SKU": "x", "Qty": 1}, {"SKU": "y", "Qty": 2}}),
+		"Ship":       mustMarshal(map[string]string{"City": "Espoo"}),
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	ph := ddbexpr.Placeholders{
+		Names: map[string]string{"#t": "Total", "#c": "City"},
+		Values: map[string]types.AttributeValue{
+			":pk":   mustMarshal("order#1"),
+			":p":    mustMarshal("order#"),
+			":ten":  mustMarshal(10),
+			":20":   mustMarshal(20.0),
+			":a":    mustMarshal("a"),
+			":y":    mustMarshal("y"),
+			":s":    mustMarshal("S"),
+			":city": mustMarshal("Espoo"),
+		},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"PK = :pk", true},
+		{"PK <> :pk", false},
+		{"Absent <> :pk", true},
+		{"#t > :ten AND #t < :20", true},
+		{"#t BETWEEN :ten AND :20", true},
+		{"#t IN (:ten, :20)", false},
+		{"begins_with(PK, :p)", true},
+		{"NOT begins_with(PK, :a) AND contains(Tags, :a)", true},
+		{"attribute_exists(OrderLines[1].SKU) AND OrderLines[1].SKU = :y", true},
+		{"attribute_not_exists(OrderLines[2])", true},
+		{"Ship.#c = :city OR Absent = :a", true},
+		{"size(Tags) < :ten AND attribute_type(PK, :s)", true},
+		{"(PK = :a OR PK = :pk) AND #t >= :20", false},
+		{"PK > :ten", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := ddbexpr.ParseCondition(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ddbexpr.Evaluate(c, testItem(), ph)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCondition_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"PK = ", `Syntax error; token: "<EOF>", near: "="`},
+		{"PK == :v", `Syntax error; token: "=", near: "=="`},
+		{"begins_with(PK)", "Incorrect number of operands for operator or function; operator or function: begins_with, number of operands: 1"},
+		{"foo(PK) = :v", "Invalid function name; function: foo"},
+	}
+	for _, tt := range tests {
+		_, err := ddbexpr.ParseCondition(tt.expr)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseCondition(%q) error = %v, want %v", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	u, err := ddbexpr.ParseUpdate("SET Ship.Zip = :zip, OrderLines[5] = :line, #t = if_not_exists(Absent, :ten) REMOVE Tags, OrderLines[0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := testItem()
+	got, err := ddbexpr.Apply(u, item, ddbexpr.Placeholders{
+		Names: map[string]string{"#t": "Total"},
+		Values: map[string]types.AttributeValue{
+			":zip":  mustMarshal("02100"),
+			":line": mustMarshal(map[string]interface{}{"SKU": "z", "Qty": 3}),
+			":ten":  mustMarshal(10),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]types.AttributeValue{
+		"PK":         mustMarshal("order#1"),
+		"Total":      mustMarshal(10),
+		"OrderLines": mustMarshal([]map[string]interface{}{{"SKU": "y", "Qty": 2}, {"SKU": "z", "Qty": 3}}),
+		"Ship":       mustMarshal(map[string]string{"City": "Espoo", "Zip": "02100"}),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(item, testItem()) {
+		t.Error("Apply() modified the original item")
+	}
+}
+
+func TestProject(t *testing.T) {
+	paths, err := ddbexpr.ParseProjection("PK, OrderLines[1].SKU, #s.City")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ddbexpr.Project(testItem(), paths, map[string]string{"#s": "Ship"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]types.AttributeValue{
+		"PK":         mustMarshal("order#1"),
+		"OrderLines": mustMarshal([]map[string]interface{}{{"SKU": "y"}}),
+		"Ship":       mustMarshal(map[string]string{"City": "Espoo"}),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Project() = %v, want %v", got, want)
+	}
+}
+
+func TestApply_ArithmeticAndSets(t *testing.T) {
+	u, err := ddbexpr.ParseUpdate("SET #t = #t - :half, OrderLines = list_append(OrderLines, :lines) ADD Tags :bc, Visits :one DELETE Labels :a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := testItem()
+	item["Labels"] = &types.AttributeValueMemberSS{Value: []string{"a"}}
+	got, err := ddbexpr.Apply(u, item, ddbexpr.Placeholders{
+		Names: map[string]string{"#t": "Total"},
+		Values: map[string]types.AttributeValue{
+			":half":  mustMarshal(0.5),
+			":lines": mustMarshal([]map[string]interface{}{{"SKU": "z", "Qty": 3}}),
+			":bc":    &types.AttributeValueMemberSS{Value: []string{"b", "c"}},
+			":one":   mustMarshal(1),
+			":a":     &types.AttributeValueMemberSS{Value: []string{"a"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]types.AttributeValue{
+		"PK":     mustMarshal("order#1"),
+		"Total":  &types.AttributeValueMemberN{Value: "14.5"},
+		"Tags":   &types.AttributeValueMemberSS{Value: []string{"a", "b", "c"}},
+		"Visits": mustMarshal(1),
+		"OrderLines": mustMarshal([]map[string]interface{}{
+			{"SKU": "x", "Qty": 1}, {"SKU": "y", "Qty": 2}, {"SKU": "z", "Qty": 3},
+		}),
+		"Ship": mustMarshal(map[string]string{"City": "Espoo"}),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	ph := ddbexpr.Placeholders{
+		Names: map[string]string{"#t": "Total"},
+		Values: map[string]types.AttributeValue{
+			":s":   mustMarshal("s"),
+			":one": mustMarshal(1),
+		},
+	}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"SET Ship = :s REMOVE Ship.City", "Two document paths overlap with each other; must remove or rewrite one of these paths; path one: [Ship], path two: [Ship, City]"},
+		{"SET #t = PK + :one", "An operand in the update expression has an incorrect data type"},
+		{"SET #t = Absent + :one", "The provided expression refers to an attribute that does not exist in the item"},
+		{"ADD Tags :s", "Incorrect operand type for operator or function; operator: ADD, operand type: STRING, typeSet: ALLOWED_FOR_ADD_OPERAND"},
+		{"DELETE Tags :one", "Incorrect operand type for operator or function; operator: DELETE, operand type: NUMBER, typeSet: ALLOWED_FOR_DELETE_OPERAND"},
+	}
+	for _, tt := range tests {
+		u, err := ddbexpr.ParseUpdate(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseUpdate(%q) error = %v", tt.expr, err)
+		}
+		_, err = ddbexpr.Apply(u, testItem(), ph)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Apply(%q) error = %v, want %v", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestParse_ReservedWord(t *testing.T) {
+	_, err := ddbexpr.ParseCondition("Total > :v")
+	want := "Attribute name is a reserved keyword; reserved keyword: Total"
+	if err == nil || err.Error() != want {
+		t.Errorf("ParseCondition() error = %v, want %v", err, want)
+	}
+}
+
+func TestCheckPlaceholders(t *testing.T) {
+	cond, err := ddbexpr.ParseCondition("#t > :v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proj, err := ddbexpr.ParseProjection("PK, #s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := mustMarshal(1)
+	tests := []struct {
+		name  string
+		ph    ddbexpr.Placeholders
+		exprs []ddbexpr.Expression
+		want  string
+	}{
+		{
+			name:  "ok",
+			ph:    ddbexpr.Placeholders{Names: map[string]string{"#t": "Total", "#s": "Ship"}, Values: map[string]types.AttributeValue{":v": v}},
+			exprs: []ddbexpr.Expression{cond, proj},
+		},
+		{
+			name:  "undefined name",
+			ph:    ddbexpr.Placeholders{Names: map[string]string{"#t": "Total"}, Values: map[string]types.AttributeValue{":v": v}},
+			exprs: []ddbexpr.Expression{cond, proj},
+			want:  "An expression attribute name used in the document path is not defined; attribute name: #s",
+		},
+		{
+			name:  "undefined value",
+			ph:    ddbexpr.Placeholders{Names: map[string]string{"#t": "Total"}},
+			exprs: []ddbexpr.Expression{cond},
+			want:  "An expression attribute value used in expression is not defined; attribute value: :v",
+		},
+		{
+			name:  "unused",
+			ph:    ddbexpr.Placeholders{Names: map[string]string{"#t": "Total", "#x": "X", "#a": "A"}, Values: map[string]types.AttributeValue{":v": v}},
+			exprs: []ddbexpr.Expression{cond},
+			want:  "Value provided in ExpressionAttributeNames unused in expressions: keys: {#a, #x}",
+		},
+		{
+			name:  "no expressions",
+			ph:    ddbexpr.Placeholders{Names: map[string]string{"#t": "Total"}},
+			exprs: []ddbexpr.Expression{nil},
+			want:  "ExpressionAttributeNames can only be specified when using expressions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ddbexpr.CheckPlaceholders(tt.ph, tt.exprs...)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("CheckPlaceholders() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("CheckPlaceholders() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
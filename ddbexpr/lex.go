@@ -0,0 +1,125 @@
+package ddbexpr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	// attribute name or keyword
+	tokIdent
+	// #name
+	tokName
+	// :value
+	tokValue
+	// list index
+	tokNumber
+	// punctuation and comparators
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// is reports whether token is the keyword or symbol, keywords are case
+// insensitive
+func (t token) is(text string) bool {
+	if t.kind == tokIdent {
+		return strings.EqualFold(t.text, text)
+	}
+	return t.kind == tokSymbol && t.text == text
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "<EOF>"
+	}
+	return t.text
+}
+
+var symbols = []string{"<>", "<=", ">=", "=", "<", ">", "(", ")", "[", "]", ",", ".", "+", "-"}
+
+func lex(expr string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '#' || c == ':' || isIdentChar(c):
+			start := i
+			i++
+			for i < len(expr) && isIdentChar(rune(expr[i])) {
+				i++
+			}
+			text := expr[start:i]
+			kind := tokIdent
+			switch {
+			case c == '#':
+				kind = tokName
+			case c == ':':
+				kind = tokValue
+			case isNumber(text):
+				kind = tokNumber
+			}
+			if (kind == tokName || kind == tokValue) && len(text) == 1 {
+				return nil, &SyntaxError{Pos: start, Token: text, Near: near(expr, start, i)}
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start})
+		default:
+			matched := false
+			for _, sym := range symbols {
+				if strings.HasPrefix(expr[i:], sym) {
+					tokens = append(tokens, token{kind: tokSymbol, text: sym, pos: i})
+					i += len(sym)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, &SyntaxError{Pos: i, Token: string(c), Near: near(expr, i, i+1)}
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(expr)}), nil
+}
+
+func isIdentChar(c rune) bool {
+	return c == '_' || c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c))
+}
+
+func isNumber(s string) bool {
+	for _, c := range s {
+		if !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// near returns expr[start:end] with the preceding word, like DynamoDB
+// shows the context of syntax errors
+func near(expr string, start, end int) string {
+	before := strings.TrimRightFunc(expr[:start], unicode.IsSpace)
+	from := strings.LastIndexFunc(before, unicode.IsSpace) + 1
+	return strings.TrimSpace(expr[from:end])
+}
+
+// SyntaxError tells where expression couldn't be parsed. Token is
+// "<EOF>" if the expression ended too early.
+type SyntaxError struct {
+	Pos   int
+	Token string
+	Near  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("Syntax error; token: %q, near: %q", e.Token, e.Near)
+}
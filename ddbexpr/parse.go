@@ -0,0 +1,433 @@
+package ddbexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	comparators = []string{"=", "<>", "<", "<=", ">", ">="}
+	keywords    = []string{"AND", "OR", "NOT", "BETWEEN", "IN", "SET", "REMOVE", "ADD", "DELETE"}
+
+	// functions by name and number of arguments
+	conditionFunctions = map[string]int{
+		"attribute_exists":     1,
+		"attribute_not_exists": 1,
+		"attribute_type":       2,
+		"begins_with":          2,
+		"contains":             2,
+	}
+	conditionOperandFunctions = map[string]int{"size": 1}
+	updateOperandFunctions    = map[string]int{"if_not_exists": 2, "list_append": 2}
+)
+
+type parser struct {
+	expr   string
+	tokens []token
+	pos    int
+}
+
+func newParser(expr string) (*parser, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{expr: expr, tokens: tokens}, nil
+}
+
+func (p *parser) peek() token     { return p.tokens[p.pos] }
+func (p *parser) peekNext() token { return p.tokens[min(p.pos+1, len(p.tokens)-1)] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) syntaxError(t token) error {
+	return &SyntaxError{Pos: t.pos, Token: t.String(), Near: near(p.expr, t.pos, t.pos+len(t.text))}
+}
+
+func (p *parser) expect(text string) error {
+	if t := p.next(); !t.is(text) {
+		return p.syntaxError(t)
+	}
+	return nil
+}
+
+func (p *parser) end() error {
+	if t := p.peek(); t.kind != tokEOF {
+		return p.syntaxError(t)
+	}
+	return nil
+}
+
+// ParseCondition parses condition, filter or key condition expression.
+func ParseCondition(expr string) (Condition, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	return c, p.end()
+}
+
+func (p *parser) or() (Condition, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("OR") {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Condition, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("AND") {
+		p.next()
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (Condition, error) {
+	if p.peek().is("NOT") {
+		p.next()
+		c, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Condition: c}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Condition, error) {
+	if p.peek().is("(") {
+		p.next()
+		c, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return c, p.expect(")")
+	}
+	if t := p.peek(); t.kind == tokIdent && p.peekNext().is("(") {
+		if arity, ok := conditionFunctions[t.text]; ok {
+			p.next()
+			args, err := p.arguments(t.text, arity, conditionOperandFunctions)
+			if err != nil {
+				return nil, err
+			}
+			return FunctionCondition{Name: t.text, Args: args}, nil
+		}
+	}
+	left, err := p.operand(conditionOperandFunctions)
+	if err != nil {
+		return nil, err
+	}
+	t := p.next()
+	switch {
+	case t.kind == tokSymbol && contains(comparators, t.text):
+		right, err := p.operand(conditionOperandFunctions)
+		if err != nil {
+			return nil, err
+		}
+		return Comparison{Op: t.text, Left: left, Right: right}, nil
+	case t.is("BETWEEN"):
+		low, err := p.operand(conditionOperandFunctions)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.operand(conditionOperandFunctions)
+		if err != nil {
+			return nil, err
+		}
+		return Between{Operand: left, Low: low, High: high}, nil
+	case t.is("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		list, err := p.operandList(conditionOperandFunctions)
+		if err != nil {
+			return nil, err
+		}
+		return In{Operand: left, List: list}, p.expect(")")
+	}
+	return nil, p.syntaxError(t)
+}
+
+// arguments parses parenthesized arguments of a function
+func (p *parser) arguments(name string, arity int, functions map[string]int) ([]Operand, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args, err := p.operandList(functions)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if len(args) != arity {
+		return nil, &ValidationError{Msg: fmt.Sprintf(
+			"Incorrect number of operands for operator or function; operator or function: %s, number of operands: %d",
+			name, len(args))}
+	}
+	return args, nil
+}
+
+func (p *parser) operandList(functions map[string]int) ([]Operand, error) {
+	list := []Operand{}
+	for {
+		o, err := p.operand(functions)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, o)
+		if !p.peek().is(",") {
+			return list, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) operand(functions map[string]int) (Operand, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokValue:
+		p.next()
+		return ValueOperand{Name: t.text}, nil
+	case t.kind == tokIdent && p.peekNext().is("("):
+		p.next()
+		arity, ok := functions[t.text]
+		if !ok {
+			return nil, &ValidationError{Msg: "Invalid function name; function: " + t.text}
+		}
+		args, err := p.arguments(t.text, arity, functions)
+		if err != nil {
+			return nil, err
+		}
+		return FunctionOperand{Name: t.text, Args: args}, nil
+	}
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	return PathOperand{Path: path}, nil
+}
+
+func (p *parser) path() (Path, error) {
+	path := Path{}
+	elem, err := p.pathName()
+	if err != nil {
+		return nil, err
+	}
+	path = append(path, elem)
+	for {
+		switch {
+		case p.peek().is("."):
+			p.next()
+			elem, err := p.pathName()
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, elem)
+		case p.peek().is("["):
+			p.next()
+			t := p.next()
+			if t.kind != tokNumber {
+				return nil, p.syntaxError(t)
+			}
+			index, err := strconv.Atoi(t.text)
+			if err != nil {
+				return nil, p.syntaxError(t)
+			}
+			path = append(path, PathElement{Index: index})
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		default:
+			return path, nil
+		}
+	}
+}
+
+func (p *parser) pathName() (PathElement, error) {
+	t := p.next()
+	if !p.isName(t) {
+		return PathElement{}, p.syntaxError(t)
+	}
+	if t.kind == tokIdent && isReserved(t.text) {
+		return PathElement{}, &ValidationError{Msg: "Attribute name is a reserved keyword; reserved keyword: " + t.text}
+	}
+	return PathElement{Name: t.text}, nil
+}
+
+func (p *parser) isName(t token) bool {
+	if t.kind == tokName {
+		return true
+	}
+	if t.kind != tokIdent {
+		return false
+	}
+	for _, kw := range keywords {
+		if strings.EqualFold(t.text, kw) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseUpdate parses update expression.
+func ParseUpdate(expr string) (*Update, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return nil, err
+	}
+	u := &Update{}
+	seen := map[string]bool{}
+	for p.peek().kind != tokEOF {
+		t := p.next()
+		clause := strings.ToUpper(t.text)
+		if t.kind != tokIdent || !contains([]string{"SET", "REMOVE", "ADD", "DELETE"}, clause) {
+			return nil, p.syntaxError(t)
+		}
+		if seen[clause] {
+			return nil, &ValidationError{Msg: fmt.Sprintf(
+				"The %q section can only be used once in an update expression;", clause)}
+		}
+		seen[clause] = true
+		for {
+			switch clause {
+			case "SET":
+				action, err := p.setAction()
+				if err != nil {
+					return nil, err
+				}
+				u.Set = append(u.Set, action)
+			case "REMOVE":
+				path, err := p.path()
+				if err != nil {
+					return nil, err
+				}
+				u.Remove = append(u.Remove, path)
+			case "ADD":
+				path, value, err := p.pathAndValue()
+				if err != nil {
+					return nil, err
+				}
+				u.Add = append(u.Add, AddAction{Path: path, Value: value})
+			case "DELETE":
+				path, value, err := p.pathAndValue()
+				if err != nil {
+					return nil, err
+				}
+				u.Delete = append(u.Delete, DeleteAction{Path: path, Value: value})
+			}
+			if !p.peek().is(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if len(seen) == 0 {
+		return nil, p.syntaxError(p.peek())
+	}
+	return u, nil
+}
+
+func (p *parser) setAction() (SetAction, error) {
+	path, err := p.path()
+	if err != nil {
+		return SetAction{}, err
+	}
+	if err := p.expect("="); err != nil {
+		return SetAction{}, err
+	}
+	value, err := p.operand(updateOperandFunctions)
+	if err != nil {
+		return SetAction{}, err
+	}
+	if t := p.peek(); t.is("+") || t.is("-") {
+		p.next()
+		right, err := p.operand(updateOperandFunctions)
+		if err != nil {
+			return SetAction{}, err
+		}
+		value = Arithmetic{Op: t.text, Left: value, Right: right}
+	}
+	return SetAction{Path: path, Value: value}, nil
+}
+
+// pathAndValue parses action of ADD or DELETE clause
+func (p *parser) pathAndValue() (Path, Operand, error) {
+	path, err := p.path()
+	if err != nil {
+		return nil, nil, err
+	}
+	t := p.next()
+	if t.kind != tokValue {
+		return nil, nil, p.syntaxError(t)
+	}
+	return path, ValueOperand{Name: t.text}, nil
+}
+
+// ParseProjection parses projection expression.
+func ParseProjection(expr string) (Projection, error) {
+	p, err := newParser(expr)
+	if err != nil {
+		return nil, err
+	}
+	paths := Projection{}
+	for {
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		if !p.peek().is(",") {
+			break
+		}
+		p.next()
+	}
+	return paths, p.end()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
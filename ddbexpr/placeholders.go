@@ -0,0 +1,163 @@
+package ddbexpr
+
+import (
+	"sort"
+	"strings"
+)
+
+// usage collects placeholders and document paths used in expressions
+type usage struct {
+	names  map[string]bool
+	values map[string]bool
+	paths  []Path
+}
+
+func newUsage() *usage {
+	return &usage{names: map[string]bool{}, values: map[string]bool{}}
+}
+
+func (u *usage) path(p Path) {
+	u.paths = append(u.paths, p)
+	for _, elem := range p {
+		if strings.HasPrefix(elem.Name, "#") {
+			u.names[elem.Name] = true
+		}
+	}
+}
+
+func (u *usage) operands(operands []Operand) {
+	for _, o := range operands {
+		o.placeholders(u)
+	}
+}
+
+func (o PathOperand) placeholders(u *usage)     { u.path(o.Path) }
+func (o ValueOperand) placeholders(u *usage)    { u.values[o.Name] = true }
+func (o FunctionOperand) placeholders(u *usage) { u.operands(o.Args) }
+func (o Arithmetic) placeholders(u *usage)      { u.operands([]Operand{o.Left, o.Right}) }
+
+func (c Comparison) placeholders(u *usage) { u.operands([]Operand{c.Left, c.Right}) }
+func (c Between) placeholders(u *usage)    { u.operands([]Operand{c.Operand, c.Low, c.High}) }
+func (c In) placeholders(u *usage) {
+	c.Operand.placeholders(u)
+	u.operands(c.List)
+}
+func (c And) placeholders(u *usage) {
+	c.Left.placeholders(u)
+	c.Right.placeholders(u)
+}
+func (c Or) placeholders(u *usage) {
+	c.Left.placeholders(u)
+	c.Right.placeholders(u)
+}
+func (c Not) placeholders(u *usage)               { c.Condition.placeholders(u) }
+func (c FunctionCondition) placeholders(u *usage) { u.operands(c.Args) }
+
+func (up *Update) placeholders(u *usage) {
+	for _, a := range up.Set {
+		u.path(a.Path)
+		a.Value.placeholders(u)
+	}
+	for _, p := range up.Remove {
+		u.path(p)
+	}
+	for _, a := range up.Add {
+		u.path(a.Path)
+		a.Value.placeholders(u)
+	}
+	for _, a := range up.Delete {
+		u.path(a.Path)
+		a.Value.placeholders(u)
+	}
+}
+
+func (p Projection) placeholders(u *usage) {
+	for _, path := range p {
+		u.path(path)
+	}
+}
+
+// CheckPlaceholders validates placeholders of a request against all
+// its expressions, like DynamoDB does before evaluating anything:
+// every placeholder used must be defined and every placeholder defined
+// must be used. Nil expressions are ignored.
+func CheckPlaceholders(ph Placeholders, exprs ...Expression) error {
+	u := newUsage()
+	n := 0
+	for _, expr := range exprs {
+		if isNil(expr) {
+			continue
+		}
+		expr.placeholders(u)
+		n++
+	}
+	if n == 0 {
+		switch {
+		case len(ph.Names) > 0:
+			return &ValidationError{Msg: "ExpressionAttributeNames can only be specified when using expressions"}
+		case len(ph.Values) > 0:
+			return &ValidationError{Msg: "ExpressionAttributeValues can only be specified when using expressions"}
+		}
+		return nil
+	}
+	for _, name := range sortedSet(u.names) {
+		if _, ok := ph.Names[name]; !ok {
+			return &ValidationError{Msg: "An expression attribute name used in the document path is not defined; attribute name: " + name}
+		}
+	}
+	for _, value := range sortedSet(u.values) {
+		if _, ok := ph.Values[value]; !ok {
+			return &ValidationError{Msg: "An expression attribute value used in expression is not defined; attribute value: " + value}
+		}
+	}
+	if unused := unusedKeys(ph.Names, u.names); len(unused) > 0 {
+		return &ValidationError{Msg: "Value provided in ExpressionAttributeNames unused in expressions: keys: {" + strings.Join(unused, ", ") + "}"}
+	}
+	if unused := unusedKeys(ph.Values, u.values); len(unused) > 0 {
+		return &ValidationError{Msg: "Value provided in ExpressionAttributeValues unused in expressions: keys: {" + strings.Join(unused, ", ") + "}"}
+	}
+	return nil
+}
+
+// Paths returns the document paths that expression refers to, with
+// placeholders unresolved.
+func Paths(expr Expression) []Path {
+	if isNil(expr) {
+		return nil
+	}
+	u := newUsage()
+	expr.placeholders(u)
+	return u.paths
+}
+
+func isNil(expr Expression) bool {
+	switch e := expr.(type) {
+	case nil:
+		return true
+	case *Update:
+		return e == nil
+	case Projection:
+		return e == nil
+	}
+	return false
+}
+
+func sortedSet(set map[string]bool) []string {
+	rv := make([]string, 0, len(set))
+	for k := range set {
+		rv = append(rv, k)
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+func unusedKeys[V any](defined map[string]V, used map[string]bool) []string {
+	rv := []string{}
+	for k := range defined {
+		if !used[k] {
+			rv = append(rv, k)
+		}
+	}
+	sort.Strings(rv)
+	return rv
+}
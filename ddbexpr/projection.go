@@ -0,0 +1,59 @@
+package ddbexpr
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Project returns copy of item with only the attributes at paths.
+// Projected list elements keep their order but not their indexes.
+func Project(item map[string]types.AttributeValue, paths []Path, names map[string]string) (map[string]types.AttributeValue, error) {
+	rv := map[string]types.AttributeValue{}
+	for _, p := range paths {
+		path, err := p.Resolve(names)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := Get(item, path); !ok {
+			continue
+		}
+		project(rv, item, path)
+	}
+	return rv, nil
+}
+
+// project copies value at path from src to dst, creating the
+// containers on the way
+func project(dst, src map[string]types.AttributeValue, path Path) {
+	name := path[0].Name
+	if len(path) == 1 {
+		dst[name] = CopyValue(src[name])
+		return
+	}
+	switch v := src[name].(type) {
+	case *types.AttributeValueMemberM:
+		m, ok := dst[name].(*types.AttributeValueMemberM)
+		if !ok {
+			m = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}
+			dst[name] = m
+		}
+		if path[1].Name != "" {
+			project(m.Value, v.Value, path[1:])
+		}
+	case *types.AttributeValueMemberL:
+		l, ok := dst[name].(*types.AttributeValueMemberL)
+		if !ok {
+			l = &types.AttributeValueMemberL{}
+			dst[name] = l
+		}
+		elem, _ := Get(src, path[:2])
+		if len(path) == 2 {
+			l.Value = append(l.Value, CopyValue(elem))
+			return
+		}
+		if m, ok := elem.(*types.AttributeValueMemberM); ok && path[2].Name != "" {
+			projected := map[string]types.AttributeValue{}
+			project(projected, m.Value, path[2:])
+			l.Value = append(l.Value, &types.AttributeValueMemberM{Value: projected})
+		}
+	}
+}
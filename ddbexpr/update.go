@@ -0,0 +1,341 @@
+package ddbexpr
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var errInvalidPath = &ValidationError{Msg: "The document path provided in the update expression is invalid for update"}
+
+// Apply returns updated copy of the item. Operands are evaluated
+// against the item before the update, like in DynamoDB.
+func Apply(u *Update, item map[string]types.AttributeValue, ph Placeholders) (map[string]types.AttributeValue, error) {
+	resolved := []Path{}
+	for _, p := range u.paths() {
+		path, err := p.Resolve(ph.Names)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, path)
+	}
+	if err := checkOverlap(resolved); err != nil {
+		return nil, err
+	}
+	e := &env{item: item, ph: ph}
+	type assignment struct {
+		path  Path
+		value types.AttributeValue
+	}
+	assignments := []assignment{}
+	for i, action := range u.Set {
+		value, ok, err := e.value(action.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errMissingAttribute
+		}
+		assignments = append(assignments, assignment{path: resolved[i], value: value})
+	}
+	removals := append([]Path{}, resolved[len(u.Set):len(u.Set)+len(u.Remove)]...)
+	offset := len(u.Set) + len(u.Remove)
+	for i, action := range u.Add {
+		path := resolved[offset+i]
+		value, _, err := e.value(action.Value)
+		if err != nil {
+			return nil, err
+		}
+		current, exists := Get(item, path)
+		if value, err = add(current, exists, value); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, assignment{path: path, value: value})
+	}
+	offset += len(u.Add)
+	for i, action := range u.Delete {
+		path := resolved[offset+i]
+		value, _, err := e.value(action.Value)
+		if err != nil {
+			return nil, err
+		}
+		current, exists := Get(item, path)
+		if !exists {
+			if !isSet(value) {
+				return nil, updateOperandTypeError("DELETE", value)
+			}
+			continue
+		}
+		if value, err = deleteFromSet(current, value); err != nil {
+			return nil, err
+		}
+		if value == nil {
+			removals = append(removals, path)
+			continue
+		}
+		assignments = append(assignments, assignment{path: path, value: value})
+	}
+
+	updated := CopyItem(item)
+	for _, a := range assignments {
+		if err := set(updated, a.path, CopyValue(a.value)); err != nil {
+			return nil, err
+		}
+	}
+	// remove list elements from the end, so that indexes stay valid
+	sort.SliceStable(removals, func(i, j int) bool { return comparePaths(removals[i], removals[j]) > 0 })
+	for _, path := range removals {
+		if err := remove(updated, path); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+// checkOverlap returns error if a path is modified by more than one
+// action, or if a path is inside another modified path
+func checkOverlap(paths []Path) error {
+	for i, a := range paths {
+		for _, b := range paths[i+1:] {
+			n := len(a)
+			if len(b) < n {
+				n = len(b)
+			}
+			if comparePaths(a[:n], b[:n]) == 0 {
+				return &ValidationError{Msg: fmt.Sprintf(
+					"Two document paths overlap with each other; must remove or rewrite one of these paths; path one: %s, path two: %s",
+					pathElements(a), pathElements(b))}
+			}
+		}
+	}
+	return nil
+}
+
+// pathElements formats path like DynamoDB error messages, e.g. [a, [1], b]
+func pathElements(p Path) string {
+	elems := make([]string, len(p))
+	for i, elem := range p {
+		if elem.Name == "" {
+			elems[i] = fmt.Sprintf("[%d]", elem.Index)
+		} else {
+			elems[i] = elem.Name
+		}
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+func updateOperandTypeError(operator string, v types.AttributeValue) error {
+	return &ValidationError{Msg: fmt.Sprintf(
+		"Incorrect operand type for operator or function; operator: %s, operand type: %s, typeSet: ALLOWED_FOR_%s_OPERAND",
+		operator, longTypeName(v), operator)}
+}
+
+// add returns number incremented by value, or union of sets. Missing
+// attribute is zero or empty set.
+func add(current types.AttributeValue, exists bool, value types.AttributeValue) (types.AttributeValue, error) {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberN:
+		if !exists {
+			return v, nil
+		}
+		return sum(current, v, false)
+	case *types.AttributeValueMemberSS, *types.AttributeValueMemberNS, *types.AttributeValueMemberBS:
+		if !exists {
+			return v, nil
+		}
+		if typeName(current) != typeName(v) {
+			return nil, errUpdateOperandType
+		}
+		return union(current, v), nil
+	}
+	return nil, updateOperandTypeError("ADD", value)
+}
+
+// deleteFromSet returns set without the elements of value, nil if the
+// result is empty
+func deleteFromSet(current, value types.AttributeValue) (types.AttributeValue, error) {
+	if !isSet(value) {
+		return nil, updateOperandTypeError("DELETE", value)
+	}
+	if typeName(current) != typeName(value) {
+		return nil, errUpdateOperandType
+	}
+	var rv types.AttributeValue
+	n := 0
+	switch c := current.(type) {
+	case *types.AttributeValueMemberSS:
+		remaining := without(c.Value, value.(*types.AttributeValueMemberSS).Value, func(x, y string) bool { return x == y })
+		rv, n = &types.AttributeValueMemberSS{Value: remaining}, len(remaining)
+	case *types.AttributeValueMemberNS:
+		remaining := without(c.Value, value.(*types.AttributeValueMemberNS).Value, func(x, y string) bool { return compareNumbers(x, y) == 0 })
+		rv, n = &types.AttributeValueMemberNS{Value: remaining}, len(remaining)
+	case *types.AttributeValueMemberBS:
+		remaining := without(c.Value, value.(*types.AttributeValueMemberBS).Value, bytes.Equal)
+		rv, n = &types.AttributeValueMemberBS{Value: remaining}, len(remaining)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	return rv, nil
+}
+
+func union(a, b types.AttributeValue) types.AttributeValue {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberSS:
+		extra := without(b.(*types.AttributeValueMemberSS).Value, a.Value, func(x, y string) bool { return x == y })
+		return &types.AttributeValueMemberSS{Value: append(append([]string{}, a.Value...), extra...)}
+	case *types.AttributeValueMemberNS:
+		extra := without(b.(*types.AttributeValueMemberNS).Value, a.Value, func(x, y string) bool { return compareNumbers(x, y) == 0 })
+		return &types.AttributeValueMemberNS{Value: append(append([]string{}, a.Value...), extra...)}
+	case *types.AttributeValueMemberBS:
+		extra := without(b.(*types.AttributeValueMemberBS).Value, a.Value, bytes.Equal)
+		return &types.AttributeValueMemberBS{Value: append(append([][]byte{}, a.Value...), extra...)}
+	}
+	return a
+}
+
+// without returns elements of list that are not in remove
+func without[T any](list, remove []T, eq func(x, y T) bool) []T {
+	rv := []T{}
+	for _, x := range list {
+		if indexOf(remove, x, eq) < 0 {
+			rv = append(rv, x)
+		}
+	}
+	return rv
+}
+
+func isSet(v types.AttributeValue) bool {
+	switch v.(type) {
+	case *types.AttributeValueMemberSS, *types.AttributeValueMemberNS, *types.AttributeValueMemberBS:
+		return true
+	}
+	return false
+}
+
+func comparePaths(a, b Path) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := strings.Compare(a[i].Name, b[i].Name); c != 0 {
+			return c
+		}
+		if a[i].Index != b[i].Index {
+			return a[i].Index - b[i].Index
+		}
+	}
+	return len(a) - len(b)
+}
+
+// parent returns the container of the last element of path
+func parent(item map[string]types.AttributeValue, path Path) (types.AttributeValue, error) {
+	if len(path) == 1 {
+		return &types.AttributeValueMemberM{Value: item}, nil
+	}
+	container, ok := Get(item, path[:len(path)-1])
+	if !ok {
+		return nil, errInvalidPath
+	}
+	return container, nil
+}
+
+func set(item map[string]types.AttributeValue, path Path, value types.AttributeValue) error {
+	container, err := parent(item, path)
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	switch c := container.(type) {
+	case *types.AttributeValueMemberM:
+		if last.Name == "" {
+			return errInvalidPath
+		}
+		c.Value[last.Name] = value
+	case *types.AttributeValueMemberL:
+		if last.Name != "" {
+			return errInvalidPath
+		}
+		if last.Index >= len(c.Value) {
+			c.Value = append(c.Value, value)
+		} else {
+			c.Value[last.Index] = value
+		}
+	default:
+		return errInvalidPath
+	}
+	return nil
+}
+
+func remove(item map[string]types.AttributeValue, path Path) error {
+	container, err := parent(item, path)
+	if err != nil {
+		// removing from missing container is a no-op
+		return nil
+	}
+	last := path[len(path)-1]
+	switch c := container.(type) {
+	case *types.AttributeValueMemberM:
+		if last.Name == "" {
+			return errInvalidPath
+		}
+		delete(c.Value, last.Name)
+	case *types.AttributeValueMemberL:
+		if last.Name != "" {
+			return errInvalidPath
+		}
+		if last.Index < len(c.Value) {
+			c.Value = append(c.Value[:last.Index], c.Value[last.Index+1:]...)
+		}
+	default:
+		return errInvalidPath
+	}
+	return nil
+}
+
+// CopyItem returns deep copy of item.
+func CopyItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+	rv := make(map[string]types.AttributeValue, len(item))
+	for k, v := range item {
+		rv[k] = CopyValue(v)
+	}
+	return rv
+}
+
+// CopyValue returns deep copy of attribute value.
+func CopyValue(av types.AttributeValue) types.AttributeValue {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: v.Value}
+	case *types.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: v.Value}
+	case *types.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: append([]byte{}, v.Value...)}
+	case *types.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: v.Value}
+	case *types.AttributeValueMemberNULL:
+		return &types.AttributeValueMemberNULL{Value: v.Value}
+	case *types.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: append([]string{}, v.Value...)}
+	case *types.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: append([]string{}, v.Value...)}
+	case *types.AttributeValueMemberBS:
+		bs := make([][]byte, len(v.Value))
+		for i, b := range v.Value {
+			bs[i] = append([]byte{}, b...)
+		}
+		return &types.AttributeValueMemberBS{Value: bs}
+	case *types.AttributeValueMemberL:
+		l := make([]types.AttributeValue, len(v.Value))
+		for i, item := range v.Value {
+			l[i] = CopyValue(item)
+		}
+		return &types.AttributeValueMemberL{Value: l}
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: CopyItem(v.Value)}
+	}
+	return av
+}
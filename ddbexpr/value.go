@@ -0,0 +1,167 @@
+package ddbexpr
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Equal tells whether attribute values are equal. Numbers are compared
+// by value and sets without regard to order.
+func Equal(a, b types.AttributeValue) bool {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS:
+		b, ok := b.(*types.AttributeValueMemberS)
+		return ok && a.Value == b.Value
+	case *types.AttributeValueMemberN:
+		b, ok := b.(*types.AttributeValueMemberN)
+		return ok && compareNumbers(a.Value, b.Value) == 0
+	case *types.AttributeValueMemberB:
+		b, ok := b.(*types.AttributeValueMemberB)
+		return ok && bytes.Equal(a.Value, b.Value)
+	case *types.AttributeValueMemberBOOL:
+		b, ok := b.(*types.AttributeValueMemberBOOL)
+		return ok && a.Value == b.Value
+	case *types.AttributeValueMemberNULL:
+		_, ok := b.(*types.AttributeValueMemberNULL)
+		return ok
+	case *types.AttributeValueMemberSS:
+		b, ok := b.(*types.AttributeValueMemberSS)
+		return ok && sameSet(a.Value, b.Value, func(x, y string) bool { return x == y })
+	case *types.AttributeValueMemberNS:
+		b, ok := b.(*types.AttributeValueMemberNS)
+		return ok && sameSet(a.Value, b.Value, func(x, y string) bool { return compareNumbers(x, y) == 0 })
+	case *types.AttributeValueMemberBS:
+		b, ok := b.(*types.AttributeValueMemberBS)
+		return ok && sameSet(a.Value, b.Value, bytes.Equal)
+	case *types.AttributeValueMemberL:
+		b, ok := b.(*types.AttributeValueMemberL)
+		if !ok || len(a.Value) != len(b.Value) {
+			return false
+		}
+		for i := range a.Value {
+			if !Equal(a.Value[i], b.Value[i]) {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberM:
+		b, ok := b.(*types.AttributeValueMemberM)
+		if !ok || len(a.Value) != len(b.Value) {
+			return false
+		}
+		for k, v := range a.Value {
+			if other, ok := b.Value[k]; !ok || !Equal(v, other) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func sameSet[T any](a, b []T, eq func(x, y T) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, x := range a {
+		if indexOf(b, x, eq) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func indexOf[T any](list []T, x T, eq func(x, y T) bool) int {
+	for i, y := range list {
+		if eq(x, y) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Compare orders scalar values of the same type, S, N or B, like
+// DynamoDB orders sort keys. ok is false for other types.
+func Compare(a, b types.AttributeValue) (rv int, ok bool) {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberS:
+		if b, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(a.Value, b.Value), true
+		}
+	case *types.AttributeValueMemberN:
+		if b, ok := b.(*types.AttributeValueMemberN); ok {
+			return compareNumbers(a.Value, b.Value), true
+		}
+	case *types.AttributeValueMemberB:
+		if b, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(a.Value, b.Value), true
+		}
+	}
+	return 0, false
+}
+
+func compareNumbers(a, b string) int {
+	x, okX := new(big.Rat).SetString(a)
+	y, okY := new(big.Rat).SetString(b)
+	if !okX || !okY {
+		return strings.Compare(a, b)
+	}
+	return x.Cmp(y)
+}
+
+// typeName returns the DynamoDB type descriptor of value, e.g. "S"
+func typeName(av types.AttributeValue) string {
+	switch av.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	}
+	return ""
+}
+
+// longTypeName returns the type name used in update operand errors
+func longTypeName(av types.AttributeValue) string {
+	switch av.(type) {
+	case *types.AttributeValueMemberS:
+		return "STRING"
+	case *types.AttributeValueMemberN:
+		return "NUMBER"
+	case *types.AttributeValueMemberB:
+		return "BINARY"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOLEAN"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return "STRING_SET"
+	case *types.AttributeValueMemberNS:
+		return "NUMBER_SET"
+	case *types.AttributeValueMemberBS:
+		return "BINARY_SET"
+	case *types.AttributeValueMemberL:
+		return "LIST"
+	case *types.AttributeValueMemberM:
+		return "MAP"
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+package gonetable
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Plan lists differences between two versions of a schema.
+type Plan struct {
+	DelimiterChanged bool
+	AddedIndexes     []string
+	RemovedIndexes   []string
+	// Indeces whose kind (local or global) or projection changed.
+	// Such indeces must be dropped and recreated.
+	ChangedIndexes []string
+	AddedTypes     []string
+	RemovedTypes   []string
+	// Type ids that changed, while the Go type stayed the same
+	RenamedTypes []TypeRename
+	KeyChanges   []KeyChange
+}
+
+// TypeRename tells that Go type is now registered with a new type id.
+type TypeRename struct {
+	From string
+	To   string
+}
+
+// KeyChange tells that key of a document type changed. Index is empty
+// for the table key. Old is nil if the type didn't populate the index
+// before, and New is nil if it doesn't populate it anymore.
+type KeyChange struct {
+	TypeID string
+	Index  string
+	Old    *KeyTemplate
+	New    *KeyTemplate
+}
+
+// Diff compares two schemas.
+func Diff(old, new *Schema) Plan {
+	return DiffSnapshots(old.Snapshot(), new.Snapshot())
+}
+
+// DiffSnapshots compares two schema snapshots, typically the snapshot
+// of the last release and the snapshot of the current code.
+func DiffSnapshots(old, new SchemaSnapshot) Plan {
+	p := Plan{
+		DelimiterChanged: old.KeyDelimiter != new.KeyDelimiter,
+		AddedIndexes:     []string{},
+		RemovedIndexes:   []string{},
+		ChangedIndexes:   []string{},
+		AddedTypes:       []string{},
+		RemovedTypes:     []string{},
+		RenamedTypes:     []TypeRename{},
+		KeyChanges:       []KeyChange{},
+	}
+	for _, newIdx := range new.Indexes {
+		oldIdx, ok := old.indexByName(newIdx.Name)
+		switch {
+		case !ok:
+			p.AddedIndexes = append(p.AddedIndexes, newIdx.Name)
+		case oldIdx.Local != newIdx.Local ||
+			oldIdx.Projection != newIdx.Projection ||
+			strings.Join(oldIdx.NonKeyAttributes, ",") != strings.Join(newIdx.NonKeyAttributes, ","):
+			p.ChangedIndexes = append(p.ChangedIndexes, newIdx.Name)
+		}
+	}
+	for _, oldIdx := range old.Indexes {
+		if _, ok := new.indexByName(oldIdx.Name); !ok {
+			p.RemovedIndexes = append(p.RemovedIndexes, oldIdx.Name)
+		}
+	}
+
+	removed := map[string]TypeSnapshot{}
+	for _, oldType := range old.Types {
+		if _, ok := new.typeByID(oldType.TypeID); !ok {
+			removed[oldType.GoType] = oldType
+		}
+	}
+	renamedFrom := map[string]bool{}
+	for _, newType := range new.Types {
+		oldType, ok := old.typeByID(newType.TypeID)
+		if !ok {
+			oldType, ok = removed[newType.GoType]
+			if !ok {
+				p.AddedTypes = append(p.AddedTypes, newType.TypeID)
+				continue
+			}
+			p.RenamedTypes = append(p.RenamedTypes, TypeRename{From: oldType.TypeID, To: newType.TypeID})
+			renamedFrom[oldType.TypeID] = true
+		}
+		p.KeyChanges = append(p.KeyChanges, keyChanges(newType.TypeID, oldType, newType)...)
+	}
+	for _, oldType := range old.Types {
+		if _, ok := new.typeByID(oldType.TypeID); !ok && !renamedFrom[oldType.TypeID] {
+			p.RemovedTypes = append(p.RemovedTypes, oldType.TypeID)
+		}
+	}
+	return p
+}
+
+func keyChanges(typeID string, old, new TypeSnapshot) []KeyChange {
+	rv := []KeyChange{}
+	if !old.Key.equal(new.Key) {
+		oldKey, newKey := old.Key, new.Key
+		rv = append(rv, KeyChange{TypeID: typeID, Old: &oldKey, New: &newKey})
+	}
+	indeces := map[string]bool{}
+	for idx := range old.Indexes {
+		indeces[idx] = true
+	}
+	for idx := range new.Indexes {
+		indeces[idx] = true
+	}
+	for _, idx := range sortedKeys(indeces) {
+		oldKey, oldOK := old.Indexes[idx]
+		newKey, newOK := new.Indexes[idx]
+		if oldOK && newOK && oldKey.equal(newKey) {
+			continue
+		}
+		change := KeyChange{TypeID: typeID, Index: idx}
+		if oldOK {
+			change.Old = &oldKey
+		}
+		if newOK {
+			change.New = &newKey
+		}
+		rv = append(rv, change)
+	}
+	return rv
+}
+
+// Empty reports whether there are no differences.
+func (p Plan) Empty() bool {
+	return !p.DelimiterChanged &&
+		len(p.AddedIndexes) == 0 &&
+		len(p.RemovedIndexes) == 0 &&
+		len(p.ChangedIndexes) == 0 &&
+		len(p.AddedTypes) == 0 &&
+		len(p.RemovedTypes) == 0 &&
+		len(p.RenamedTypes) == 0 &&
+		len(p.KeyChanges) == 0
+}
+
+// String returns one line per difference, suitable for CI output.
+func (p Plan) String() string {
+	lines := []string{}
+	if p.DelimiterChanged {
+		lines = append(lines, "key delimiter changed")
+	}
+	for _, idx := range p.AddedIndexes {
+		lines = append(lines, fmt.Sprintf("index %s added", idx))
+	}
+	for _, idx := range p.RemovedIndexes {
+		lines = append(lines, fmt.Sprintf("index %s removed", idx))
+	}
+	for _, idx := range p.ChangedIndexes {
+		lines = append(lines, fmt.Sprintf("index %s changed", idx))
+	}
+	for _, typeID := range p.AddedTypes {
+		lines = append(lines, fmt.Sprintf("type %s added", typeID))
+	}
+	for _, typeID := range p.RemovedTypes {
+		lines = append(lines, fmt.Sprintf("type %s removed", typeID))
+	}
+	for _, r := range p.RenamedTypes {
+		lines = append(lines, fmt.Sprintf("type %s renamed to %s", r.From, r.To))
+	}
+	for _, kc := range p.KeyChanges {
+		key := "key"
+		if kc.Index != "" {
+			key = kc.Index + " key"
+		}
+		switch {
+		case kc.Old == nil:
+			lines = append(lines, fmt.Sprintf("type %s %s added: %s", kc.TypeID, key, kc.New))
+		case kc.New == nil:
+			lines = append(lines, fmt.Sprintf("type %s %s removed: %s", kc.TypeID, key, kc.Old))
+		default:
+			lines = append(lines, fmt.Sprintf("type %s %s changed: %s -> %s", kc.TypeID, key, kc.Old, kc.New))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
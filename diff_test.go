@@ -0,0 +1,60 @@
+package gonetable_test
+
+import (
+	"testing"
+
+	"github.com/juranki/gonetable"
+)
+
+// RenamedOrder is Order with GSI and different type id
+type RenamedOrder struct {
+	Order
+}
+
+func (o *RenamedOrder) Gonetable_TypeID() string { return "ord" }
+func (o *RenamedOrder) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"order"},
+		RangeSegments: []string{o.Customer},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	v1, err := gonetable.NewSchema([]gonetable.Document{&MinimalDoc{}, &WithIndex{}, &Order{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := gonetable.Diff(v1, v1); !p.Empty() {
+		t.Errorf("Diff(v1, v1) = %v, want empty", p)
+	}
+	v2, err := gonetable.NewSchema([]gonetable.Document{&WithIndex{}, &WithLocalIndex{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `index LSI1 added
+type wli added
+type order removed
+type sd1 removed`
+	if got := gonetable.Diff(v1, v2).String(); got != want {
+		t.Errorf("Diff(v1, v2) =\n%s\nwant\n%s", got, want)
+	}
+
+	snapshot := v1.Snapshot()
+	// pretend that Order was registered as RenamedOrder in previous version
+	for i := range snapshot.Types {
+		if snapshot.Types[i].TypeID == "order" {
+			snapshot.Types[i].GoType = "*gonetable_test.RenamedOrder"
+			snapshot.Types[i].Key.Hash = []string{"{Customer}"}
+		}
+	}
+	v3, err := gonetable.NewSchema([]gonetable.Document{&MinimalDoc{}, &WithIndex{}, &RenamedOrder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `type order renamed to ord
+type ord key changed: {Customer} / order#{Number} -> customer#{Customer} / order#{Number}
+type ord GSI1 key added: order / {Customer}`
+	if got := gonetable.DiffSnapshots(snapshot, v3.Snapshot()).String(); got != want {
+		t.Errorf("DiffSnapshots() =\n%s\nwant\n%s", got, want)
+	}
+}
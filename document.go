@@ -11,6 +11,18 @@ package gonetable
 // keys for them. They must be named with following pattern
 //
 //	Gonetable_[Index]Key() returns composite key for Index
+//
+// Local secondary indeces share the partition key with the table, so
+// only sort key is needed for them. Methods returning sort key segments
+// for LSIs must be named with following pattern
+//
+//	Gonetable_[Index]SortKey() returns range segments for Index
+//
+// Because of this, names of GSIs can't end with Sort. NewSchema rejects
+// Gonetable_[Index]SortKey methods that return composite key.
+//
+// The table can have at most 5 LSIs and they can't be added
+// after the table is created.
 type Document interface {
 	Gonetable_Key() CompositeKey
 	Gonetable_TypeID() string
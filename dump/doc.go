@@ -0,0 +1,18 @@
+// Package dump exports gonetable tables to JSON Lines and imports them
+// back.
+//
+// Each line of an export is one item as a JSON object, with plain JSON
+// values instead of DynamoDB JSON wrappers. Types that plain JSON can't
+// tell apart are marked with a single-key object, so the export can be
+// imported back without changes:
+//
+//	{"PK": "ed#1", "SK": "ed", "_Type": "ed", "Name": "x", "Score": 1.5,
+//	 "Tags": {"$ss": ["a", "b"]}, "Avatar": {"$b": "iVBORw0K"}}
+//
+// The markers are $ss, $ns and $bs for sets and $b for binary. Maps
+// that have a single key starting with $ are wrapped in {"$m": {...}}.
+//
+// Import reads also the DynamoDB JSON format of table exports to S3,
+// where each line is {"Item": {"PK": {"S": "ed#1"}, ...}}. Gzipped
+// files are decompressed.
+package dump
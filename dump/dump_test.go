@@ -0,0 +1,252 @@
+package dump_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/dump"
+	"github.com/juranki/gonetable/memddb"
+)
+
+type Editor struct {
+	ID     string
+	Name   string
+	Score  float64
+	Tags   []string `dynamodbav:",stringset,omitempty"`
+	Avatar []byte
+	Meta   map[string]interface{}
+}
+
+func (e *Editor) Gonetable_TypeID() string { return "ed" }
+func (e *Editor) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"ed", e.ID},
+		RangeSegments: []string{"ed"},
+	}
+}
+func (e *Editor) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"editors"},
+		RangeSegments: []string{e.Name},
+	}
+}
+
+func newTable(t *testing.T) *gonetable.Table {
+	s, err := gonetable.NewSchema([]gonetable.Document{&Editor{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := gonetable.NewTable("test", s, memddb.New())
+	if _, err := table.Ensure(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func scanItems(t *testing.T, table *gonetable.Table) []map[string]types.AttributeValue {
+	out, err := table.Client().Scan(context.Background(), &dynamodb.ScanInput{TableName: aws.String(table.Name())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out.Items
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := newTable(t)
+	for i := 0; i < 30; i++ {
+		err := src.Put(ctx, &Editor{
+			ID:     fmt.Sprint(i),
+			Name:   fmt.Sprintf("name%d", i),
+			Score:  float64(i) / 4,
+			Tags:   []string{"a", "b"},
+			Avatar: []byte{1, 2, byte(i)},
+			Meta: map[string]interface{}{
+				"nested": map[string]interface{}{"$ss": "not a set"},
+				"list":   []interface{}{"x", 1.5, true, nil},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf := &bytes.Buffer{}
+	n, err := dump.Export(ctx, src, buf, dump.Segments(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 30 || strings.Count(buf.String(), "\n") != 30 {
+		t.Fatalf("exported %d items:\n%s", n, buf)
+	}
+	if !strings.Contains(buf.String(), `"Tags":{"$ss":["a","b"]}`) {
+		t.Errorf("export has no string set marker:\n%s", buf)
+	}
+
+	dst := newTable(t)
+	n, err = dump.Import(ctx, dst, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 30 {
+		t.Errorf("imported %d items, want 30", n)
+	}
+	if got, want := scanItems(t, dst), scanItems(t, src); !reflect.DeepEqual(got, want) {
+		t.Errorf("imported items differ:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+const s3Export = `{"Item":{"PK":{"S":"ed#1"},"SK":{"S":"ed"},"_Type":{"S":"ed"},"ID":{"S":"1"},"Name":{"S":"x"},"Score":{"N":"1.25"},"Tags":{"SS":["t"]}}}
+{"Item":{"PK":{"S":"ed#2"},"SK":{"S":"ed"},"_Type":{"S":"ed"},"ID":{"S":"2"},"Name":{"S":"y"},"Score":{"N":"0"}}}
+`
+
+func TestImport_S3Export(t *testing.T) {
+	ctx := context.Background()
+	gz := &bytes.Buffer{}
+	zw := gzip.NewWriter(gz)
+	zw.Write([]byte(s3Export))
+	zw.Close()
+	for name, in := range map[string]*bytes.Buffer{
+		"plain":   bytes.NewBufferString(s3Export),
+		"gzipped": gz,
+	} {
+		t.Run(name, func(t *testing.T) {
+			table := newTable(t)
+			n, err := dump.Import(ctx, table, in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 2 {
+				t.Errorf("imported %d items, want 2", n)
+			}
+			doc, err := table.Get(ctx, (&Editor{ID: "1"}).Gonetable_Key())
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := &Editor{ID: "1", Name: "x", Score: 1.25, Tags: []string{"t"}}
+			if !reflect.DeepEqual(doc, want) {
+				t.Errorf("doc = %+v, want %+v", doc, want)
+			}
+		})
+	}
+}
+
+// Meta has a nested map whose only key looks like a marker
+const s3MarkerLikeMap = `{"Item":{"PK":{"S":"ed#1"},"SK":{"S":"ed"},"_Type":{"S":"ed"},"ID":{"S":"1"},"Name":{"S":"x"},"Score":{"N":"0"},` +
+	`"Meta":{"M":{"nested":{"M":{"$ss":{"S":"not a set"}}}}}}}
+`
+
+func TestImport_S3MarkerLikeMap(t *testing.T) {
+	ctx := context.Background()
+	src := newTable(t)
+	if _, err := dump.Import(ctx, src, strings.NewReader(s3MarkerLikeMap)); err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if _, err := dump.Export(ctx, src, buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"nested":{"$m":{"$ss":"not a set"}}`) {
+		t.Errorf("export doesn't wrap the map with $m:\n%s", buf)
+	}
+	dst := newTable(t)
+	if _, err := dump.Import(ctx, dst, buf); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := dst.Get(ctx, (&Editor{ID: "1"}).Gonetable_Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"nested": map[string]interface{}{"$ss": "not a set"}}
+	if got := doc.(*Editor).Meta; !reflect.DeepEqual(got, want) {
+		t.Errorf("Meta = %v, want %v", got, want)
+	}
+	if got, want := scanItems(t, dst), scanItems(t, src); !reflect.DeepEqual(got, want) {
+		t.Errorf("imported items differ:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+		msg   string
+	}{
+		{
+			name:  "unknown type",
+			input: `{"PK": "x#1", "SK": "x", "_Type": "x"}`,
+			want:  gonetable.ErrUnknownType,
+		},
+		{
+			name:  "no type",
+			input: `{"PK": "ed#1", "SK": "ed", "ID": "1"}`,
+			want:  gonetable.ErrNoTypeAttribute,
+		},
+		{
+			name:  "key mismatch",
+			input: `{"PK": "ed#1", "SK": "ed", "_Type": "ed", "ID": "1"}` + "\n" + `{"PK": "ed#1", "SK": "ed", "_Type": "ed", "ID": "2"}`,
+			want:  dump.ErrKeyMismatch,
+			msg:   `item 1: item key differs from the key of the document: PK is "ed#1", want "ed#2"`,
+		},
+		{
+			// not S3 export, because Item isn't DynamoDB JSON
+			name:  "only Item attribute",
+			input: `{"Item": {"PK": "ed#1"}}`,
+			want:  gonetable.ErrNoTypeAttribute,
+		},
+		{
+			name:  "native after S3 export",
+			input: strings.TrimSpace(s3Export) + "\n" + `{"PK": "ed#3", "SK": "ed", "_Type": "ed", "ID": "3"}`,
+			want:  dump.ErrValue,
+			msg:   "item 2: invalid attribute value: S3 export line has no Item",
+		},
+		{
+			name:  "unknown marker",
+			input: `{"PK": "ed#1", "SK": "ed", "_Type": "ed", "ID": "1", "Tags": {"$set": []}}`,
+			want:  dump.ErrValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := dump.Import(context.Background(), newTable(t), strings.NewReader(tt.input))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			var itemErr *dump.ItemError
+			if !errors.As(err, &itemErr) {
+				t.Errorf("error = %v, want *ItemError", err)
+			}
+			if tt.msg != "" && (err == nil || err.Error() != tt.msg) {
+				t.Errorf("error = %v, want %s", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestImport_DuplicateKeys(t *testing.T) {
+	ctx := context.Background()
+	table := newTable(t)
+	in := `{"PK": "ed#1", "SK": "ed", "_Type": "ed", "ID": "1", "Name": "old"}
+{"PK": "ed#1", "SK": "ed", "_Type": "ed", "ID": "1", "Name": "new"}
+`
+	if _, err := dump.Import(ctx, table, strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := table.Get(ctx, (&Editor{ID: "1"}).Gonetable_Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.(*Editor).Name != "new" {
+		t.Errorf("doc = %+v, want the last one", doc)
+	}
+}
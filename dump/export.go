@@ -0,0 +1,102 @@
+package dump
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+)
+
+const defaultSegments = 4
+
+type options struct {
+	segments int
+}
+
+// Option modifies how Export scans the table.
+type Option func(*options)
+
+// Segments sets the number of parallel scan segments, default 4.
+func Segments(n int) Option {
+	return func(o *options) {
+		o.segments = n
+	}
+}
+
+// Export scans the table in parallel segments and writes each item as
+// one line of JSON. Items are written in no particular order. Returns
+// the number of exported items.
+func Export(ctx context.Context, table *gonetable.Table, w io.Writer, opts ...Option) (int, error) {
+	o := options{segments: defaultSegments}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.segments <= 0 {
+		o.segments = defaultSegments
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	enc := json.NewEncoder(w)
+	mu := sync.Mutex{}
+	count := 0
+	write := func(items []map[string]types.AttributeValue) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, item := range items {
+			if err := enc.Encode(encodeItem(item)); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	}
+	wg := sync.WaitGroup{}
+	errOnce := sync.Once{}
+	var firstErr error
+	for segment := 0; segment < o.segments; segment++ {
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+			if err := scanSegment(ctx, table, segment, o.segments, write); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(segment)
+	}
+	wg.Wait()
+	return count, firstErr
+}
+
+func scanSegment(
+	ctx context.Context,
+	table *gonetable.Table,
+	segment, segments int,
+	handle func(items []map[string]types.AttributeValue) error,
+) error {
+	input := &dynamodb.ScanInput{
+		TableName:      aws.String(table.Name()),
+		Segment:        aws.Int32(int32(segment)),
+		TotalSegments:  aws.Int32(int32(segments)),
+		ConsistentRead: aws.Bool(true),
+	}
+	for {
+		out, err := table.Client().Scan(ctx, input)
+		if err != nil {
+			return err
+		}
+		if err := handle(out.Items); err != nil {
+			return err
+		}
+		if out.LastEvaluatedKey == nil {
+			return nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,173 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/internal/batch"
+	"github.com/juranki/gonetable/internal/ddbjson"
+)
+
+var ErrKeyMismatch = errors.New("item key differs from the key of the document")
+
+// DynamoDB allows at most 25 writes in one BatchWriteItem call
+const maxBatchWrite = 25
+
+// ItemError tells which item of the input couldn't be imported.
+type ItemError struct {
+	// Zero based position of the item in the input
+	Index  int
+	Reason error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("item %d: %s", e.Index, e.Reason)
+}
+
+func (e *ItemError) Unwrap() error { return e.Reason }
+
+// Import reads items written by Export, or by a DynamoDB table export
+// to S3 in DynamoDB JSON format, and writes them to the table with
+// batch writes. Returns the number of imported items. The format is
+// detected from the first item: S3 export lines have only an "Item"
+// attribute with the item in DynamoDB JSON.
+//
+// Each item must decode to a document of the schema, and its PK and SK
+// must be the key of that document. Items are written as they are, so
+// index attributes and versions are preserved. Import stops at the
+// first invalid item; items before it have already been written.
+func Import(ctx context.Context, table *gonetable.Table, r io.Reader) (int, error) {
+	r, err := decompress(r)
+	if err != nil {
+		return 0, err
+	}
+	dec := json.NewDecoder(r)
+	s3 := false
+	count := 0
+	pending := make([]types.WriteRequest, 0, maxBatchWrite)
+	pendingKeys := map[string]bool{}
+	flush := func() error {
+		if err := batch.Write(ctx, table.Client(), table.Name(), pending); err != nil {
+			return err
+		}
+		count += len(pending)
+		pending = pending[:0]
+		pendingKeys = map[string]bool{}
+		return nil
+	}
+	for i := 0; ; i++ {
+		raw := json.RawMessage{}
+		if err := dec.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return count, &ItemError{Index: i, Reason: err}
+		}
+		if i == 0 {
+			s3 = isS3Item(raw)
+		}
+		item, err := parseItem(raw, s3)
+		if err != nil {
+			return count, &ItemError{Index: i, Reason: err}
+		}
+		if err := validate(table.Schema(), item); err != nil {
+			return count, &ItemError{Index: i, Reason: err}
+		}
+		// DynamoDB rejects batches with duplicate keys
+		key := itemKey(item)
+		if pendingKeys[key] || len(pending) == maxBatchWrite {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+		pending = append(pending, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		pendingKeys[key] = true
+	}
+	err = flush()
+	return count, err
+}
+
+// decompress returns reader of the uncompressed content, if r is
+// gzipped
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// isS3Item tells if the line is an item of S3 export, that has only
+// "Item" attribute with the item in DynamoDB JSON
+func isS3Item(raw json.RawMessage) bool {
+	wrapper := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &wrapper); err != nil || len(wrapper) != 1 {
+		return false
+	}
+	wrapped, ok := wrapper["Item"]
+	return ok && json.Unmarshal(wrapped, &ddbjson.Map{}) == nil
+}
+
+// parseItem converts one line of input to item. Lines of S3 exports
+// have the item in DynamoDB JSON under "Item".
+func parseItem(raw json.RawMessage, s3 bool) (map[string]types.AttributeValue, error) {
+	if s3 {
+		wrapper := struct{ Item ddbjson.Map }{}
+		if err := json.Unmarshal(raw, &wrapper); err != nil {
+			return nil, err
+		}
+		if wrapper.Item == nil {
+			return nil, fmt.Errorf("%w: S3 export line has no Item", ErrValue)
+		}
+		return wrapper.Item, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	obj := map[string]interface{}{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return decodeItem(obj)
+}
+
+// validate checks that the item is a document of the schema and it is
+// stored with the key of the document
+func validate(schema *gonetable.Schema, item map[string]types.AttributeValue) error {
+	doc, err := schema.Unmarshal(item)
+	if err != nil {
+		return err
+	}
+	marshaled, err := schema.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{"PK", "SK"} {
+		got, want := stringAttribute(item, name), stringAttribute(marshaled, name)
+		if got != want {
+			return fmt.Errorf("%w: %s is %q, want %q", ErrKeyMismatch, name, got, want)
+		}
+	}
+	return nil
+}
+
+func itemKey(item map[string]types.AttributeValue) string {
+	return stringAttribute(item, "PK") + "\x00" + stringAttribute(item, "SK")
+}
+
+func stringAttribute(item map[string]types.AttributeValue, name string) string {
+	if s, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
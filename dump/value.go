@@ -0,0 +1,191 @@
+package dump
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrValue = errors.New("invalid attribute value")
+
+// Markers of values that plain JSON can't represent
+const (
+	markerSS  = "$ss"
+	markerNS  = "$ns"
+	markerBS  = "$bs"
+	markerB   = "$b"
+	markerMap = "$m"
+)
+
+// encodeItem returns item as JSON value with type markers
+func encodeItem(item map[string]types.AttributeValue) map[string]interface{} {
+	rv := make(map[string]interface{}, len(item))
+	for k, v := range item {
+		rv[k] = encodeValue(v)
+	}
+	return rv
+}
+
+func encodeValue(av types.AttributeValue) interface{} {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case *types.AttributeValueMemberN:
+		return json.Number(v.Value)
+	case *types.AttributeValueMemberB:
+		return map[string]interface{}{markerB: v.Value}
+	case *types.AttributeValueMemberBOOL:
+		return v.Value
+	case *types.AttributeValueMemberNULL:
+		return nil
+	case *types.AttributeValueMemberSS:
+		return map[string]interface{}{markerSS: v.Value}
+	case *types.AttributeValueMemberNS:
+		ns := make([]json.Number, len(v.Value))
+		for i, n := range v.Value {
+			ns[i] = json.Number(n)
+		}
+		return map[string]interface{}{markerNS: ns}
+	case *types.AttributeValueMemberBS:
+		return map[string]interface{}{markerBS: v.Value}
+	case *types.AttributeValueMemberL:
+		rv := make([]interface{}, len(v.Value))
+		for i, elem := range v.Value {
+			rv[i] = encodeValue(elem)
+		}
+		return rv
+	case *types.AttributeValueMemberM:
+		m := encodeItem(v.Value)
+		if isMarked(m) {
+			return map[string]interface{}{markerMap: m}
+		}
+		return m
+	}
+	return nil
+}
+
+// isMarked tells if the map looks like a marked value
+func isMarked(m map[string]interface{}) bool {
+	if len(m) != 1 {
+		return false
+	}
+	for k := range m {
+		return strings.HasPrefix(k, "$")
+	}
+	return false
+}
+
+// decodeItem converts JSON object decoded with UseNumber to item
+func decodeItem(obj map[string]interface{}) (map[string]types.AttributeValue, error) {
+	rv := make(map[string]types.AttributeValue, len(obj))
+	for k, v := range obj {
+		av, err := decodeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		rv[k] = av
+	}
+	return rv, nil
+}
+
+func decodeValue(v interface{}) (types.AttributeValue, error) {
+	switch v := v.(type) {
+	case string:
+		return &types.AttributeValueMemberS{Value: v}, nil
+	case json.Number:
+		return &types.AttributeValueMemberN{Value: v.String()}, nil
+	case bool:
+		return &types.AttributeValueMemberBOOL{Value: v}, nil
+	case nil:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case []interface{}:
+		av := &types.AttributeValueMemberL{Value: make([]types.AttributeValue, len(v))}
+		for i, elem := range v {
+			var err error
+			if av.Value[i], err = decodeValue(elem); err != nil {
+				return nil, err
+			}
+		}
+		return av, nil
+	case map[string]interface{}:
+		if !isMarked(v) {
+			m, err := decodeItem(v)
+			if err != nil {
+				return nil, err
+			}
+			return &types.AttributeValueMemberM{Value: m}, nil
+		}
+		return decodeMarked(v)
+	}
+	return nil, fmt.Errorf("%w: %T", ErrValue, v)
+}
+
+func decodeMarked(v map[string]interface{}) (types.AttributeValue, error) {
+	switch {
+	case v[markerSS] != nil:
+		ss, err := stringList(v[markerSS])
+		return &types.AttributeValueMemberSS{Value: ss}, err
+	case v[markerNS] != nil:
+		ns := []string{}
+		elems, _ := v[markerNS].([]interface{})
+		for _, elem := range elems {
+			n, ok := elem.(json.Number)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s element %v", ErrValue, markerNS, elem)
+			}
+			ns = append(ns, n.String())
+		}
+		return &types.AttributeValueMemberNS{Value: ns}, nil
+	case v[markerBS] != nil:
+		ss, err := stringList(v[markerBS])
+		if err != nil {
+			return nil, err
+		}
+		bs := make([][]byte, len(ss))
+		for i, s := range ss {
+			if bs[i], err = base64.StdEncoding.DecodeString(s); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrValue, markerBS, err)
+			}
+		}
+		return &types.AttributeValueMemberBS{Value: bs}, nil
+	case v[markerB] != nil:
+		s, _ := v[markerB].(string)
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrValue, markerB, err)
+		}
+		return &types.AttributeValueMemberB{Value: b}, nil
+	case v[markerMap] != nil:
+		obj, ok := v[markerMap].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an object", ErrValue, markerMap)
+		}
+		m, err := decodeItem(obj)
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: m}, nil
+	}
+	for k := range v {
+		return nil, fmt.Errorf("%w: unknown marker %s", ErrValue, k)
+	}
+	return nil, ErrValue
+}
+
+func stringList(v interface{}) ([]string, error) {
+	elems, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: set is not an array", ErrValue)
+	}
+	rv := make([]string, len(elems))
+	for i, elem := range elems {
+		if rv[i], ok = elem.(string); !ok {
+			return nil, fmt.Errorf("%w: set element %v is not a string", ErrValue, elem)
+		}
+	}
+	return rv, nil
+}
@@ -0,0 +1,346 @@
+package gonetable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrIncompatibleTable = errors.New("table is incompatible with schema")
+
+// EnsurePlan lists changes that Table.Ensure makes, or would make
+// in dry-run mode.
+type EnsurePlan struct {
+	CreateTable bool
+	// GSIs that are added one at a time with UpdateTable
+	AddIndexes []string
+	// GSIs and LSIs of the table that schema doesn't define. They are
+	// left as is.
+	ExtraIndexes []string
+	// Differences that can't be fixed without destructive changes.
+	// Ensure doesn't touch the table if there are any.
+	Conflicts []string
+}
+
+// EnsureError is returned by Table.Ensure when the table exists, but
+// differs from the schema in a way that can't be fixed by adding
+// indeces. It matches ErrIncompatibleTable with errors.Is.
+type EnsureError struct {
+	Table     string
+	Conflicts []string
+}
+
+func (e *EnsureError) Error() string {
+	return fmt.Sprintf("%v %s:\n\t%s", ErrIncompatibleTable, e.Table, strings.Join(e.Conflicts, "\n\t"))
+}
+
+func (e *EnsureError) Unwrap() error { return ErrIncompatibleTable }
+
+type ensureOptions struct {
+	dryRun       bool
+	pollInterval time.Duration
+	createOpts   []CreateTableOption
+}
+
+// EnsureOption modifies how Table.Ensure works.
+type EnsureOption func(*ensureOptions)
+
+// EnsureDryRun makes Ensure only report the plan without changing
+// the table.
+func EnsureDryRun() EnsureOption {
+	return func(o *ensureOptions) {
+		o.dryRun = true
+	}
+}
+
+// EnsurePollInterval sets how often Ensure checks whether the table
+// or index has become active. Default is 2 seconds.
+func EnsurePollInterval(d time.Duration) EnsureOption {
+	return func(o *ensureOptions) {
+		o.pollInterval = d
+	}
+}
+
+// EnsureCreateTableOptions are used when the table is created. When
+// a GSI is added to a table with provisioned billing mode, the index
+// gets throughput from these options, or from the table if no
+// throughput is given. Throughput is ignored for GSIs of on-demand
+// tables.
+func EnsureCreateTableOptions(opts ...CreateTableOption) EnsureOption {
+	return func(o *ensureOptions) {
+		o.createOpts = append(o.createOpts, opts...)
+	}
+}
+
+// Ensure creates the table if it doesn't exist and waits until it is
+// active. If the table exists, Ensure waits until it is active and
+// then compares its key schema,
+// attribute definitions and indeces with the schema, and adds missing
+// GSIs one at a time, waiting for each to become active.
+//
+// Differences that would need destructive changes, like different key
+// schema, are returned as *EnsureError before anything is changed.
+func (t *Table) Ensure(ctx context.Context, opts ...EnsureOption) (*EnsurePlan, error) {
+	o := ensureOptions{pollInterval: 2 * time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	want, err := t.schema.CreateTableInput(t.name, o.createOpts...)
+	if err != nil {
+		return nil, err
+	}
+	current, err := t.describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		plan := &EnsurePlan{CreateTable: true}
+		if o.dryRun {
+			return plan, nil
+		}
+		if _, err := t.client.CreateTable(ctx, want); err != nil {
+			return plan, err
+		}
+		_, err := t.waitActive(ctx, o.pollInterval)
+		return plan, err
+	}
+	// tables can't be updated while they are being created or updated
+	if !o.dryRun && !isActive(current) {
+		if current, err = t.waitActive(ctx, o.pollInterval); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := comparePlan(current, want)
+	if len(plan.Conflicts) > 0 {
+		return plan, &EnsureError{Table: t.name, Conflicts: plan.Conflicts}
+	}
+	if o.dryRun {
+		return plan, nil
+	}
+	for _, idx := range plan.AddIndexes {
+		if err := t.addIndex(ctx, current, want, idx); err != nil {
+			return plan, err
+		}
+		if current, err = t.waitActive(ctx, o.pollInterval); err != nil {
+			return plan, err
+		}
+	}
+	return plan, nil
+}
+
+// describe returns description of the table, or nil if the table
+// doesn't exist.
+func (t *Table) describe(ctx context.Context) (*types.TableDescription, error) {
+	out, err := t.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: t.tableName(),
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Table, nil
+}
+
+// waitActive polls the table until it and all its GSIs are active,
+// and returns the description of the active table.
+func (t *Table) waitActive(ctx context.Context, interval time.Duration) (*types.TableDescription, error) {
+	for {
+		desc, err := t.describe(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if desc != nil && isActive(desc) {
+			return desc, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func isActive(desc *types.TableDescription) bool {
+	if desc.TableStatus != types.TableStatusActive {
+		return false
+	}
+	for _, gsi := range desc.GlobalSecondaryIndexes {
+		if gsi.IndexStatus != types.IndexStatusActive {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Table) addIndex(ctx context.Context, current *types.TableDescription, want *dynamodb.CreateTableInput, index string) error {
+	var gsi types.GlobalSecondaryIndex
+	for _, g := range want.GlobalSecondaryIndexes {
+		if *g.IndexName == index {
+			gsi = g
+		}
+	}
+	create := &types.CreateGlobalSecondaryIndexAction{
+		IndexName:  gsi.IndexName,
+		KeySchema:  gsi.KeySchema,
+		Projection: gsi.Projection,
+	}
+	// indeces of on-demand tables must not have throughput, even if
+	// the create options have it
+	if isProvisioned(current) {
+		create.ProvisionedThroughput = gsi.ProvisionedThroughput
+		if create.ProvisionedThroughput == nil && current.ProvisionedThroughput != nil {
+			create.ProvisionedThroughput = &types.ProvisionedThroughput{
+				ReadCapacityUnits:  current.ProvisionedThroughput.ReadCapacityUnits,
+				WriteCapacityUnits: current.ProvisionedThroughput.WriteCapacityUnits,
+			}
+		}
+		if create.ProvisionedThroughput == nil {
+			create.ProvisionedThroughput = want.ProvisionedThroughput
+		}
+	}
+	attributes := []types.AttributeDefinition{}
+	for _, ad := range want.AttributeDefinitions {
+		if *ad.AttributeName == index+"PK" || *ad.AttributeName == index+"SK" {
+			attributes = append(attributes, ad)
+		}
+	}
+	_, err := t.client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:            t.tableName(),
+		AttributeDefinitions: attributes,
+		GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+			{Create: create},
+		},
+	})
+	return err
+}
+
+func isProvisioned(desc *types.TableDescription) bool {
+	if desc.BillingModeSummary != nil {
+		return desc.BillingModeSummary.BillingMode == types.BillingModeProvisioned
+	}
+	// tables created with provisioned mode may lack billing mode summary
+	return desc.ProvisionedThroughput != nil &&
+		aws.ToInt64(desc.ProvisionedThroughput.ReadCapacityUnits) > 0
+}
+
+// comparePlan compares existing table with the table that schema
+// would create.
+func comparePlan(current *types.TableDescription, want *dynamodb.CreateTableInput) *EnsurePlan {
+	plan := &EnsurePlan{
+		AddIndexes:   []string{},
+		ExtraIndexes: []string{},
+		Conflicts:    []string{},
+	}
+	if !sameKeySchema(current.KeySchema, want.KeySchema) {
+		plan.Conflicts = append(plan.Conflicts, fmt.Sprintf(
+			"key schema is %s, want %s", formatKeySchema(current.KeySchema), formatKeySchema(want.KeySchema)))
+	}
+	attributeTypes := map[string]types.ScalarAttributeType{}
+	for _, ad := range current.AttributeDefinitions {
+		attributeTypes[*ad.AttributeName] = ad.AttributeType
+	}
+	for _, ad := range want.AttributeDefinitions {
+		if at, ok := attributeTypes[*ad.AttributeName]; ok && at != ad.AttributeType {
+			plan.Conflicts = append(plan.Conflicts, fmt.Sprintf(
+				"attribute %s has type %s, want %s", *ad.AttributeName, at, ad.AttributeType))
+		}
+	}
+
+	currentLSIs := map[string]types.LocalSecondaryIndexDescription{}
+	for _, lsi := range current.LocalSecondaryIndexes {
+		currentLSIs[*lsi.IndexName] = lsi
+	}
+	wantLSIs := map[string]bool{}
+	for _, lsi := range want.LocalSecondaryIndexes {
+		wantLSIs[*lsi.IndexName] = true
+		existing, ok := currentLSIs[*lsi.IndexName]
+		switch {
+		case !ok:
+			plan.Conflicts = append(plan.Conflicts, fmt.Sprintf(
+				"local index %s is missing, local indeces can only be created with the table", *lsi.IndexName))
+		case !sameKeySchema(existing.KeySchema, lsi.KeySchema):
+			plan.Conflicts = append(plan.Conflicts, fmt.Sprintf(
+				"local index %s key schema is %s, want %s",
+				*lsi.IndexName, formatKeySchema(existing.KeySchema), formatKeySchema(lsi.KeySchema)))
+		case !sameProjection(existing.Projection, lsi.Projection):
+			plan.Conflicts = append(plan.Conflicts, fmt.Sprintf(
+				"local index %s has different projection", *lsi.IndexName))
+		}
+	}
+
+	currentGSIs := map[string]types.GlobalSecondaryIndexDescription{}
+	for _, gsi := range current.GlobalSecondaryIndexes {
+		currentGSIs[*gsi.IndexName] = gsi
+	}
+	wantGSIs := map[string]bool{}
+	for _, gsi := range want.GlobalSecondaryIndexes {
+		wantGSIs[*gsi.IndexName] = true
+		existing, ok := currentGSIs[*gsi.IndexName]
+		switch {
+		case !ok:
+			plan.AddIndexes = append(plan.AddIndexes, *gsi.IndexName)
+		case !sameKeySchema(existing.KeySchema, gsi.KeySchema):
+			plan.Conflicts = append(plan.Conflicts, fmt.Sprintf(
+				"index %s key schema is %s, want %s",
+				*gsi.IndexName, formatKeySchema(existing.KeySchema), formatKeySchema(gsi.KeySchema)))
+		case !sameProjection(existing.Projection, gsi.Projection):
+			plan.Conflicts = append(plan.Conflicts, fmt.Sprintf(
+				"index %s has different projection", *gsi.IndexName))
+		}
+	}
+	for _, gsi := range current.GlobalSecondaryIndexes {
+		if !wantGSIs[*gsi.IndexName] {
+			plan.ExtraIndexes = append(plan.ExtraIndexes, *gsi.IndexName)
+		}
+	}
+	for _, lsi := range current.LocalSecondaryIndexes {
+		if !wantLSIs[*lsi.IndexName] {
+			plan.ExtraIndexes = append(plan.ExtraIndexes, *lsi.IndexName)
+		}
+	}
+	return plan
+}
+
+func sameKeySchema(a, b []types.KeySchemaElement) bool {
+	return formatKeySchema(a) == formatKeySchema(b)
+}
+
+func formatKeySchema(ks []types.KeySchemaElement) string {
+	parts := make([]string, len(ks))
+	for i, e := range ks {
+		parts[i] = fmt.Sprintf("%s %s", aws.ToString(e.AttributeName), e.KeyType)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+func sameProjection(a, b *types.Projection) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.ProjectionType != b.ProjectionType {
+		return false
+	}
+	attrs := map[string]bool{}
+	for _, attr := range a.NonKeyAttributes {
+		attrs[attr] = true
+	}
+	if len(attrs) != len(b.NonKeyAttributes) {
+		return false
+	}
+	for _, attr := range b.NonKeyAttributes {
+		if !attrs[attr] {
+			return false
+		}
+	}
+	return true
+}
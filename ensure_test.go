@@ -0,0 +1,254 @@
+package gonetable_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+)
+
+// tableAdminStub keeps one table description. Created tables and
+// indeces are reported as CREATING on first describe, and ACTIVE after
+// that. Updates are rejected unless the table was last described as
+// ACTIVE.
+type tableAdminStub struct {
+	gonetable.Client
+	table      *types.TableDescription
+	updates    []*dynamodb.UpdateTableInput
+	lastStatus types.TableStatus
+}
+
+func (ts *tableAdminStub) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	if ts.table == nil {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("not found")}
+	}
+	desc := *ts.table
+	ts.lastStatus = desc.TableStatus
+	ts.table.TableStatus = types.TableStatusActive
+	for i := range ts.table.GlobalSecondaryIndexes {
+		ts.table.GlobalSecondaryIndexes[i].IndexStatus = types.IndexStatusActive
+	}
+	return &dynamodb.DescribeTableOutput{Table: &desc}, nil
+}
+
+func (ts *tableAdminStub) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	ts.table = &types.TableDescription{
+		TableName:            params.TableName,
+		TableStatus:          types.TableStatusCreating,
+		KeySchema:            params.KeySchema,
+		AttributeDefinitions: params.AttributeDefinitions,
+	}
+	for _, gsi := range params.GlobalSecondaryIndexes {
+		ts.table.GlobalSecondaryIndexes = append(ts.table.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:   gsi.IndexName,
+			KeySchema:   gsi.KeySchema,
+			Projection:  gsi.Projection,
+			IndexStatus: types.IndexStatusCreating,
+		})
+	}
+	for _, lsi := range params.LocalSecondaryIndexes {
+		ts.table.LocalSecondaryIndexes = append(ts.table.LocalSecondaryIndexes, types.LocalSecondaryIndexDescription{
+			IndexName:  lsi.IndexName,
+			KeySchema:  lsi.KeySchema,
+			Projection: lsi.Projection,
+		})
+	}
+	return &dynamodb.CreateTableOutput{TableDescription: ts.table}, nil
+}
+
+func (ts *tableAdminStub) UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	if ts.lastStatus != types.TableStatusActive {
+		return nil, &types.ResourceInUseException{Message: aws.String("table is " + string(ts.lastStatus))}
+	}
+	ts.updates = append(ts.updates, params)
+	ts.table.AttributeDefinitions = append(ts.table.AttributeDefinitions, params.AttributeDefinitions...)
+	for _, u := range params.GlobalSecondaryIndexUpdates {
+		ts.table.GlobalSecondaryIndexes = append(ts.table.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:   u.Create.IndexName,
+			KeySchema:   u.Create.KeySchema,
+			Projection:  u.Create.Projection,
+			IndexStatus: types.IndexStatusCreating,
+		})
+	}
+	return &dynamodb.UpdateTableOutput{TableDescription: ts.table}, nil
+}
+
+func TestTable_Ensure(t *testing.T) {
+	ctx := context.Background()
+	stub := &tableAdminStub{}
+
+	v1, err := gonetable.NewSchema([]gonetable.Document{&WithLocalIndex{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := gonetable.NewTable("test", v1, stub).Ensure(ctx, gonetable.EnsurePollInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.CreateTable || stub.table.TableStatus != types.TableStatusActive {
+		t.Fatalf("plan = %+v, table = %+v", plan, stub.table)
+	}
+
+	v2, err := gonetable.NewSchema([]gonetable.Document{&WithLocalIndex{}, &WithTwoIndeces{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := gonetable.NewTable("test", v2, stub)
+	plan, err = table.Ensure(ctx, gonetable.EnsureDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plan.AddIndexes, []string{"GSI1", "GSI2"}) || len(stub.updates) != 0 {
+		t.Fatalf("dry run plan = %+v, updates = %d", plan, len(stub.updates))
+	}
+	_, err = table.Ensure(ctx, gonetable.EnsurePollInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.updates) != 2 {
+		t.Errorf("got %d updates, want 2", len(stub.updates))
+	}
+	plan, err = table.Ensure(ctx, gonetable.EnsureDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.AddIndexes) != 0 || plan.CreateTable {
+		t.Errorf("plan after update = %+v", plan)
+	}
+
+	v3, err := gonetable.NewSchema([]gonetable.Document{&WithTwoIndeces{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err = gonetable.NewTable("test", v3, stub).Ensure(ctx, gonetable.EnsureDryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plan.ExtraIndexes, []string{"LSI1"}) {
+		t.Errorf("extra indexes = %v, want [LSI1]", plan.ExtraIndexes)
+	}
+
+	// LSI can't be dropped or added
+	plan, err = gonetable.NewTable("test", v2, &tableAdminStub{table: &types.TableDescription{
+		TableStatus: types.TableStatusActive,
+		KeySchema:   v2.KeySchema(),
+	}}).Ensure(ctx)
+	if !errors.Is(err, gonetable.ErrIncompatibleTable) || len(plan.Conflicts) != 1 {
+		t.Errorf("error = %v, plan = %+v", err, plan)
+	}
+}
+
+func TestTable_EnsureKeySchemaConflict(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&MinimalDoc{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &tableAdminStub{table: &types.TableDescription{
+		TableStatus: types.TableStatusActive,
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+		},
+	}}
+	_, err = gonetable.NewTable("test", s, stub).Ensure(context.Background())
+	var ensureErr *gonetable.EnsureError
+	if !errors.As(err, &ensureErr) {
+		t.Fatalf("error = %v, want *EnsureError", err)
+	}
+	want := []string{"key schema is [id HASH], want [PK HASH, SK RANGE]"}
+	if !reflect.DeepEqual(ensureErr.Conflicts, want) {
+		t.Errorf("conflicts = %v, want %v", ensureErr.Conflicts, want)
+	}
+}
+
+func TestTable_EnsureWaitsActive(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&WithIndex{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &tableAdminStub{table: &types.TableDescription{
+		TableStatus: types.TableStatusUpdating,
+		KeySchema:   s.KeySchema(),
+	}}
+	_, err = gonetable.NewTable("test", s, stub).Ensure(context.Background(), gonetable.EnsurePollInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.updates) != 1 {
+		t.Errorf("got %d updates, want 1", len(stub.updates))
+	}
+}
+
+func TestTable_EnsureIndexThroughput(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&WithIndex{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name            string
+		billingMode     types.BillingMode
+		tableThroughput *types.ProvisionedThroughputDescription
+		opts            []gonetable.CreateTableOption
+		want            *types.ProvisionedThroughput
+	}{
+		{
+			name:        "on-demand",
+			billingMode: types.BillingModePayPerRequest,
+			opts:        []gonetable.CreateTableOption{gonetable.WithProvisionedThroughput(2, 1)},
+		},
+		{
+			name:        "provisioned",
+			billingMode: types.BillingModeProvisioned,
+			opts:        []gonetable.CreateTableOption{gonetable.WithProvisionedThroughput(2, 1)},
+			want: &types.ProvisionedThroughput{
+				ReadCapacityUnits:  aws.Int64(2),
+				WriteCapacityUnits: aws.Int64(1),
+			},
+		},
+		{
+			name:        "throughput of the table",
+			billingMode: types.BillingModeProvisioned,
+			tableThroughput: &types.ProvisionedThroughputDescription{
+				ReadCapacityUnits:  aws.Int64(5),
+				WriteCapacityUnits: aws.Int64(3),
+			},
+			want: &types.ProvisionedThroughput{
+				ReadCapacityUnits:  aws.Int64(5),
+				WriteCapacityUnits: aws.Int64(3),
+			},
+		},
+		{
+			// DescribeTable may omit throughput
+			name:        "no throughput known",
+			billingMode: types.BillingModeProvisioned,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &tableAdminStub{table: &types.TableDescription{
+				TableStatus:           types.TableStatusActive,
+				KeySchema:             s.KeySchema(),
+				BillingModeSummary:    &types.BillingModeSummary{BillingMode: tt.billingMode},
+				ProvisionedThroughput: tt.tableThroughput,
+			}}
+			_, err := gonetable.NewTable("test", s, stub).Ensure(context.Background(),
+				gonetable.EnsurePollInterval(0),
+				gonetable.EnsureCreateTableOptions(tt.opts...),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stub.updates) != 1 {
+				t.Fatalf("got %d updates, want 1", len(stub.updates))
+			}
+			got := stub.updates[0].GlobalSecondaryIndexUpdates[0].Create.ProvisionedThroughput
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("index throughput = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
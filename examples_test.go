@@ -3,12 +3,13 @@ package gonetable_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/memddb"
 )
 
 const TABLENAME = "SchemaExample"
@@ -30,9 +31,7 @@ func (ed *ExampleDocument) Gonetable_Key() gonetable.CompositeKey {
 }
 
 func ExampleSchema() {
-	cfg := MustLoadLocalDDBConfig()
-	client := dynamodb.NewFromConfig(cfg)
-	DeleteTableIfExists(context.Background(), client, TABLENAME)
+	client := memddb.New()
 
 	schema, err := gonetable.NewSchema([]gonetable.Document{
 		&ExampleDocument{},
@@ -41,16 +40,11 @@ func ExampleSchema() {
 		panic(err)
 	}
 
-	_, err = client.CreateTable(
-		context.Background(),
-		&dynamodb.CreateTableInput{
-			TableName:              aws.String(TABLENAME),
-			BillingMode:            types.BillingModePayPerRequest,
-			AttributeDefinitions:   schema.AttributeDefinitions(),
-			KeySchema:              schema.KeySchema(),
-			GlobalSecondaryIndexes: schema.GlobalSecondaryIndexes(),
-		},
-	)
+	createTableInput, err := schema.CreateTableInput(TABLENAME)
+	if err != nil {
+		panic(err)
+	}
+	_, err = client.CreateTable(context.Background(), createTableInput)
 	if err != nil {
 		panic(err)
 	}
@@ -76,3 +70,17 @@ func ExampleSchema() {
 	// Output:
 	// {"ID":{"Value":"123456"},"Name":{"Value":"Example"},"PK":{"Value":"ed#123456"},"SK":{"Value":"ed"},"_Type":{"Value":"ed"}}
 }
+
+func ExampleSchema_DescribeKeys() {
+	schema, err := gonetable.NewSchema([]gonetable.Document{
+		&ExampleDocument{},
+	})
+	if err != nil {
+		panic(err)
+	}
+	for _, kd := range schema.DescribeKeys() {
+		fmt.Println(kd)
+	}
+	// Output:
+	// ed: ed#{ID} / ed
+}
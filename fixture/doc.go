@@ -0,0 +1,15 @@
+// Package fixture seeds gonetable tables from YAML or JSON files and
+// dumps them back to the same format for golden comparisons.
+//
+// A fixture file is a list of documents. Each entry has the type id of
+// the document in _Type, and the fields of the document as they are
+// named in DynamoDB:
+//
+//	# editors.yaml
+//	- _Type: ed
+//	  ID: "1"
+//	  Name: x
+//
+// Key and index attributes are not part of the fixture, they are
+// computed by the schema when the documents are written.
+package fixture
@@ -0,0 +1,184 @@
+package fixture_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/fixture"
+	"github.com/juranki/gonetable/memddb"
+)
+
+type Editor struct {
+	ID    string
+	Name  string
+	Tags  []string `dynamodbav:",stringset,omitempty"`
+	Score float64  `dynamodbav:"score"`
+}
+
+func (e *Editor) Gonetable_TypeID() string { return "ed" }
+func (e *Editor) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"ed", e.ID},
+		RangeSegments: []string{"ed"},
+	}
+}
+
+type Post struct {
+	EditorID string
+	Slug     string
+	Views    int
+}
+
+func (p *Post) Gonetable_TypeID() string { return "post" }
+func (p *Post) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"ed", p.EditorID},
+		RangeSegments: []string{"post", p.Slug},
+	}
+}
+
+func newTable(t *testing.T) *gonetable.Table {
+	s, err := gonetable.NewSchema([]gonetable.Document{&Editor{}, &Post{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := gonetable.NewTable("test", s, memddb.New())
+	if _, err := table.Ensure(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+const fixtureYAML = `
+- _Type: post
+  EditorID: "2"
+  Slug: hello
+  Views: 3
+- _Type: ed
+  ID: "2"
+  Name: y
+  score: 1.5
+- _Type: ed
+  ID: "1"
+  Name: x
+  Tags: [b, a]
+`
+
+const snapshotYAML = `- _Type: ed
+  ID: "1"
+  Name: x
+  Tags:
+    - b
+    - a
+  score: 0
+- _Type: ed
+  ID: "2"
+  Name: "y"
+  score: 1.5
+- _Type: post
+  EditorID: "2"
+  Slug: hello
+  Views: 3
+`
+
+func TestLoadAndSnapshot(t *testing.T) {
+	ctx := context.Background()
+	table := newTable(t)
+	docs, err := fixture.Load(ctx, table, strings.NewReader(fixtureYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 3 || docs[0].(*Post).Views != 3 {
+		t.Errorf("docs = %v", docs)
+	}
+	doc, err := table.Get(ctx, (&Editor{ID: "1"}).Gonetable_Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Editor{ID: "1", Name: "x", Tags: []string{"b", "a"}}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("editor = %+v, want %+v", doc, want)
+	}
+
+	got, err := fixture.SnapshotString(ctx, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != snapshotYAML {
+		t.Errorf("snapshot:\n%s\nwant:\n%s", got, snapshotYAML)
+	}
+
+	// snapshot loads back to the same table contents
+	reloaded := newTable(t)
+	if _, err := fixture.Load(ctx, reloaded, strings.NewReader(got)); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := fixture.SnapshotString(ctx, reloaded); again != got {
+		t.Errorf("snapshot after reload:\n%s", again)
+	}
+}
+
+func TestDecode_JSON(t *testing.T) {
+	docs, err := fixture.Decode(newTable(t).Schema(), strings.NewReader(
+		`[{"_Type": "ed", "ID": "1", "Name": "x"}, {"_Type": "post", "EditorID": "1", "Slug": "s"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gonetable.Document{&Editor{ID: "1", Name: "x"}, &Post{EditorID: "1", Slug: "s"}}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("docs = %v, want %v", docs, want)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+		want    error
+		msg     string
+	}{
+		{
+			name:    "unknown type",
+			fixture: "- _Type: foo\n  ID: x",
+			want:    gonetable.ErrUnknownType,
+		},
+		{
+			name:    "missing type",
+			fixture: "- ID: x",
+			want:    gonetable.ErrNoTypeAttribute,
+		},
+		{
+			name:    "unknown field",
+			fixture: "- _Type: ed\n  ID: \"1\"\n  Nmae: x",
+			want:    fixture.ErrUnknownField,
+			msg:     "fixture entry 0 (ed): field is not part of the document type: Nmae",
+		},
+		{
+			name:    "duplicate key",
+			fixture: "- _Type: ed\n  ID: \"1\"\n- _Type: ed\n  ID: \"1\"\n  Name: x",
+			want:    fixture.ErrDuplicateKey,
+			msg:     "fixture entry 1 (ed): document key is already used by another entry: entry 0",
+		},
+		{
+			name:    "delimiter in key",
+			fixture: "- _Type: ed\n  ID: a#b",
+			want:    gonetable.ErrKeyDelimiter,
+		},
+	}
+	schema := newTable(t).Schema()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fixture.Decode(schema, strings.NewReader(tt.fixture))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			if tt.msg != "" && (err == nil || err.Error() != tt.msg) {
+				t.Errorf("error = %v, want %s", err, tt.msg)
+			}
+		})
+	}
+}
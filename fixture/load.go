@@ -0,0 +1,138 @@
+package fixture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/internal/batch"
+	"github.com/juranki/gonetable/internal/fields"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnknownField = errors.New("field is not part of the document type")
+	ErrDuplicateKey = errors.New("document key is already used by another entry")
+)
+
+// DynamoDB allows at most 25 writes in one BatchWriteItem call
+const maxBatchWrite = 25
+
+// EntryError tells which fixture entry couldn't be loaded.
+type EntryError struct {
+	// Zero based position of the entry in the fixture
+	Index  int
+	TypeID string
+	Reason error
+}
+
+func (e *EntryError) Error() string {
+	if e.TypeID == "" {
+		return fmt.Sprintf("fixture entry %d: %s", e.Index, e.Reason)
+	}
+	return fmt.Sprintf("fixture entry %d (%s): %s", e.Index, e.TypeID, e.Reason)
+}
+
+func (e *EntryError) Unwrap() error { return e.Reason }
+
+// Decode reads fixture in YAML or JSON format and decodes the entries
+// to documents of the schema. Entries must have a registered _Type and
+// only fields of that document type.
+func Decode(schema *gonetable.Schema, r io.Reader) ([]gonetable.Document, error) {
+	entries := []map[string]interface{}{}
+	if err := yaml.NewDecoder(r).Decode(&entries); err != nil && err != io.EOF {
+		return nil, err
+	}
+	docs := make([]gonetable.Document, 0, len(entries))
+	keys := map[string]int{}
+	for i, entry := range entries {
+		typeID, _ := entry["_Type"].(string)
+		doc, err := decodeEntry(schema, entry)
+		if err != nil {
+			return nil, &EntryError{Index: i, TypeID: typeID, Reason: err}
+		}
+		key := itemKey(doc.Gonetable_Key())
+		if first, ok := keys[key]; ok {
+			return nil, &EntryError{
+				Index:  i,
+				TypeID: typeID,
+				Reason: fmt.Errorf("%w: entry %d", ErrDuplicateKey, first),
+			}
+		}
+		keys[key] = i
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func decodeEntry(schema *gonetable.Schema, entry map[string]interface{}) (gonetable.Document, error) {
+	av, err := attributevalue.MarshalMap(entry)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := schema.Unmarshal(av)
+	if err != nil {
+		return nil, err
+	}
+	known := fields.AttributeNames(reflect.TypeOf(doc))
+	for name := range entry {
+		if name != "_Type" && !known[name] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, name)
+		}
+	}
+	// marshal to catch key errors before anything is written
+	if _, err := schema.Marshal(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func itemKey(key gonetable.CompositeKey) string {
+	return strings.Join(key.HashSegments, gonetable.KeyDelimiter) + "\x00" +
+		strings.Join(key.RangeSegments, gonetable.KeyDelimiter)
+}
+
+// Load decodes fixture and writes the documents to the table with
+// batch writes. Nothing is written if any entry is invalid. Returns the
+// written documents in fixture order.
+func Load(ctx context.Context, table *gonetable.Table, r io.Reader) ([]gonetable.Document, error) {
+	docs, err := Decode(table.Schema(), r)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]types.WriteRequest, 0, len(docs))
+	for _, doc := range docs {
+		item, err := table.Schema().Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+	}
+	for start := 0; start < len(requests); start += maxBatchWrite {
+		end := start + maxBatchWrite
+		if end > len(requests) {
+			end = len(requests)
+		}
+		if err := batch.Write(ctx, table.Client(), table.Name(), requests[start:end]); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+// LoadFile loads fixture from file.
+func LoadFile(ctx context.Context, table *gonetable.Table, path string) ([]gonetable.Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(ctx, table, f)
+}
@@ -0,0 +1,172 @@
+package fixture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"gopkg.in/yaml.v3"
+)
+
+// Snapshot scans the whole table and writes its documents in fixture
+// format, sorted by PK and SK. Entries start with _Type and the other
+// fields are sorted by name, so the output is stable and can be
+// compared with a golden file.
+func Snapshot(ctx context.Context, table *gonetable.Table, w io.Writer) error {
+	items, err := scanAll(ctx, table)
+	if err != nil {
+		return err
+	}
+	sort.Slice(items, func(i, j int) bool {
+		pi, pj := stringAttribute(items[i], "PK"), stringAttribute(items[j], "PK")
+		if pi != pj {
+			return pi < pj
+		}
+		return stringAttribute(items[i], "SK") < stringAttribute(items[j], "SK")
+	})
+	root := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, item := range items {
+		doc, err := table.Schema().Unmarshal(item)
+		if err != nil {
+			return fmt.Errorf("item %s / %s: %w",
+				stringAttribute(item, "PK"), stringAttribute(item, "SK"), err)
+		}
+		node, err := entryNode(doc)
+		if err != nil {
+			return err
+		}
+		root.Content = append(root.Content, node)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// SnapshotString returns Snapshot of the table as string.
+func SnapshotString(ctx context.Context, table *gonetable.Table) (string, error) {
+	buf := &bytes.Buffer{}
+	err := Snapshot(ctx, table, buf)
+	return buf.String(), err
+}
+
+func scanAll(ctx context.Context, table *gonetable.Table) ([]map[string]types.AttributeValue, error) {
+	items := []map[string]types.AttributeValue{}
+	input := &dynamodb.ScanInput{
+		TableName:      aws.String(table.Name()),
+		ConsistentRead: aws.Bool(true),
+	}
+	for {
+		out, err := table.Client().Scan(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, out.Items...)
+		if out.LastEvaluatedKey == nil {
+			return items, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// entryNode returns fixture entry of the document as mapping node
+func entryNode(doc gonetable.Document) (*yaml.Node, error) {
+	av, err := attributevalue.MarshalMap(doc)
+	if err != nil {
+		return nil, err
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	if err := appendField(node, "_Type", doc.Gonetable_TypeID()); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(av))
+	for name := range av {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := appendField(node, name, plainValue(av[name])); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func appendField(node *yaml.Node, name string, value interface{}) error {
+	valueNode := &yaml.Node{}
+	if err := valueNode.Encode(value); err != nil {
+		return err
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: name},
+		valueNode)
+	return nil
+}
+
+// plainValue converts attribute value to a value that encodes to YAML
+// naturally. Numbers become integers or floats when they fit, and sets
+// become lists.
+func plainValue(av types.AttributeValue) interface{} {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case *types.AttributeValueMemberN:
+		return plainNumber(v.Value)
+	case *types.AttributeValueMemberB:
+		return v.Value
+	case *types.AttributeValueMemberBOOL:
+		return v.Value
+	case *types.AttributeValueMemberNULL:
+		return nil
+	case *types.AttributeValueMemberSS:
+		return v.Value
+	case *types.AttributeValueMemberNS:
+		rv := make([]interface{}, len(v.Value))
+		for i, n := range v.Value {
+			rv[i] = plainNumber(n)
+		}
+		return rv
+	case *types.AttributeValueMemberBS:
+		return v.Value
+	case *types.AttributeValueMemberL:
+		rv := make([]interface{}, len(v.Value))
+		for i, elem := range v.Value {
+			rv[i] = plainValue(elem)
+		}
+		return rv
+	case *types.AttributeValueMemberM:
+		rv := make(map[string]interface{}, len(v.Value))
+		for k, elem := range v.Value {
+			rv[k] = plainValue(elem)
+		}
+		return rv
+	}
+	return nil
+}
+
+func plainNumber(n string) interface{} {
+	if i, err := strconv.ParseInt(n, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(n, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == n {
+		return f
+	}
+	return n
+}
+
+func stringAttribute(item map[string]types.AttributeValue, name string) string {
+	if s, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
@@ -0,0 +1,253 @@
+package gonetabletest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/internal/fields"
+)
+
+// Number of random documents CheckDocument marshals
+const checkIterations = 50
+
+// Nesting depth of generated values, to stop recursive types
+const maxRandomDepth = 3
+
+// CheckDocument verifies that document type works with gonetable.
+// Sample is the value that is registered to the schema, &Doc{} or
+// Doc{}. CheckDocument reports a test error for each problem:
+//
+//   - Gonetable methods with pointer receivers when the sample is not
+//     a pointer, because such methods are not found from the value and
+//     their indexes are silently left out
+//   - problems that NewSchema finds, e.g. fields whose attribute names
+//     collide with PK, SK, _Type, _V or the index key attributes
+//   - key methods that fail or panic when fields have random values.
+//     Random strings often contain gonetable.KeyDelimiter, so string
+//     fields that are used as key segments must be escaped by the key
+//     methods.
+//   - fields that don't survive a round trip through Schema.Marshal
+//     and Schema.Unmarshal
+//
+// Random values are generated from a fixed seed, so the results are
+// repeatable.
+func CheckDocument(t testing.TB, sample gonetable.Document) {
+	t.Helper()
+	for _, problem := range checkDocument(sample, rand.New(rand.NewSource(1))) {
+		t.Error(problem)
+	}
+}
+
+func checkDocument(sample gonetable.Document, rnd *rand.Rand) []string {
+	goType := reflect.TypeOf(sample)
+	problems := checkReceivers(goType)
+	schema, err := gonetable.NewSchema([]gonetable.Document{sample}, gonetable.CollectErrors())
+	var errs gonetable.SchemaErrors
+	if errors.As(err, &errs) {
+		for _, err := range errs {
+			problems = append(problems, err.Error())
+		}
+		return problems
+	}
+	if err != nil {
+		return append(problems, err.Error())
+	}
+	for i := 0; i < checkIterations; i++ {
+		doc := randomDocument(goType, rnd)
+		if problem := checkRoundTrip(schema, doc); problem != "" {
+			return append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// checkReceivers reports Gonetable methods that only the pointer type
+// has, when the document type is not a pointer
+func checkReceivers(goType reflect.Type) []string {
+	if goType.Kind() == reflect.Pointer {
+		return nil
+	}
+	problems := []string{}
+	ptrType := reflect.PointerTo(goType)
+	for i := 0; i < ptrType.NumMethod(); i++ {
+		name := ptrType.Method(i).Name
+		if !strings.HasPrefix(name, "Gonetable_") {
+			continue
+		}
+		if _, ok := goType.MethodByName(name); !ok {
+			problems = append(problems, fmt.Sprintf(
+				"%s has pointer receiver, but the document is registered as value %s; register &%s{} or use value receivers",
+				name, goType, goType.Name()))
+		}
+	}
+	return problems
+}
+
+// checkRoundTrip marshals and unmarshals the document, and reports
+// the first failure or difference
+func checkRoundTrip(schema *gonetable.Schema, doc gonetable.Document) (problem string) {
+	defer func() {
+		if r := recover(); r != nil {
+			problem = fmt.Sprintf("%T: panic with random field values: %v\n\tdocument: %+v", doc, r, doc)
+		}
+	}()
+	av, err := schema.Marshal(doc)
+	if err != nil {
+		return fmt.Sprintf("%T: marshal with random field values: %v\n\tdocument: %+v", doc, err, doc)
+	}
+	got, err := schema.Unmarshal(av)
+	if err != nil {
+		return fmt.Sprintf("%T: unmarshal: %v\n\tdocument: %+v", doc, err, doc)
+	}
+	if diff := diffFields(reflect.ValueOf(doc), reflect.ValueOf(got)); len(diff) > 0 {
+		return fmt.Sprintf("%T: fields changed in Marshal/Unmarshal round trip: %s\n\tdocument: %+v\n\tunmarshaled: %+v",
+			doc, strings.Join(diff, ", "), doc, got)
+	}
+	return ""
+}
+
+// diffFields returns names of top level fields that differ
+func diffFields(want, got reflect.Value) []string {
+	want, got = reflect.Indirect(want), reflect.Indirect(got)
+	if want.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(want.Interface(), got.Interface()) {
+			return []string{"value"}
+		}
+		return nil
+	}
+	rv := []string{}
+	for i := 0; i < want.NumField(); i++ {
+		if !want.Type().Field(i).IsExported() {
+			continue
+		}
+		if !reflect.DeepEqual(want.Field(i).Interface(), got.Field(i).Interface()) {
+			rv = append(rv, want.Type().Field(i).Name)
+		}
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// randomDocument returns new document of the type with random values
+// in the attribute fields
+func randomDocument(goType reflect.Type, rnd *rand.Rand) gonetable.Document {
+	ptr := reflect.New(goType)
+	if goType.Kind() == reflect.Pointer {
+		ptr = reflect.New(goType.Elem())
+	}
+	fillStruct(ptr.Elem(), rnd, 0)
+	if goType.Kind() == reflect.Pointer {
+		return ptr.Interface().(gonetable.Document)
+	}
+	return ptr.Elem().Interface().(gonetable.Document)
+}
+
+func fillStruct(v reflect.Value, rnd *rand.Rand, depth int) {
+	for _, f := range fields.Attributes(v.Type()) {
+		fv, ok := fieldByIndex(v, f.Index)
+		if ok {
+			fillValue(fv, rnd, depth)
+		}
+	}
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but allocates nil
+// embedded struct pointers
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, fi := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(fi)
+	}
+	return v, v.CanSet()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func fillValue(v reflect.Value, rnd *rand.Rand, depth int) {
+	if depth > maxRandomDepth {
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(randomString(rnd))
+	case reflect.Bool:
+		v.SetBool(rnd.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(int64(rnd.Intn(100) + 1))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(uint64(rnd.Intn(100) + 1))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(rnd.Intn(400)+1) / 4)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		fillValue(elem.Elem(), rnd, depth+1)
+		v.Set(elem)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, 8)
+			rnd.Read(b)
+			v.SetBytes(b)
+			return
+		}
+		s := reflect.MakeSlice(v.Type(), 2, 2)
+		for i := 0; i < s.Len(); i++ {
+			fillValue(s.Index(i), rnd, depth+1)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fillValue(v.Index(i), rnd, depth+1)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		m := reflect.MakeMap(v.Type())
+		for i := 0; i < 2; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			key.SetString(randomString(rnd))
+			elem := reflect.New(v.Type().Elem()).Elem()
+			fillValue(elem, rnd, depth+1)
+			m.SetMapIndex(key, elem)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			v.Set(reflect.ValueOf(time.Unix(rnd.Int63n(1<<32), 0).UTC()))
+			return
+		}
+		fillStruct(v, rnd, depth+1)
+	}
+}
+
+const randomLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// randomString returns 8 random letters. Every fourth string on
+// average has gonetable.KeyDelimiter in it, so that fields that end up
+// in key segments unescaped are found.
+func randomString(rnd *rand.Rand) string {
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = randomLetters[rnd.Intn(len(randomLetters))]
+	}
+	if rnd.Intn(4) == 0 {
+		i := rnd.Intn(len(b) + 1)
+		return string(b[:i]) + gonetable.KeyDelimiter + string(b[i:])
+	}
+	return string(b)
+}
@@ -0,0 +1,153 @@
+package gonetabletest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/gonetabletest"
+)
+
+// recorder collects errors reported by CheckDocument
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+func (r *recorder) Error(args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+type Good struct {
+	ID      string
+	Owner   string
+	Score   float64
+	Tags    []string `dynamodbav:",stringset,omitempty"`
+	Address *struct{ City string }
+	Counts  map[string]int
+}
+
+// escape replaces key delimiters in segments that come from fields
+func escape(s string) string {
+	return strings.ReplaceAll(s, gonetable.KeyDelimiter, "%23")
+}
+
+func (g *Good) Gonetable_TypeID() string { return "good" }
+func (g *Good) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"good", escape(g.ID)}, RangeSegments: []string{"good"}}
+}
+func (g *Good) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"owner", escape(g.Owner)}, RangeSegments: []string{"good", escape(g.ID)}}
+}
+
+type ValueDoc struct {
+	ID string
+}
+
+func (v ValueDoc) Gonetable_TypeID() string { return "value" }
+func (v ValueDoc) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"value", escape(v.ID)}, RangeSegments: []string{"value"}}
+}
+func (v *ValueDoc) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"value"}, RangeSegments: []string{escape(v.ID)}}
+}
+
+type Reserved struct {
+	ID     string
+	Kind   string `dynamodbav:"_Type"`
+	GSI1SK string
+}
+
+func (r *Reserved) Gonetable_TypeID() string { return "reserved" }
+func (r *Reserved) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"reserved", r.ID}, RangeSegments: []string{"reserved"}}
+}
+func (r *Reserved) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"reserved"}, RangeSegments: []string{r.ID}}
+}
+
+type Unescaped struct {
+	ID string
+}
+
+func (u *Unescaped) Gonetable_TypeID() string { return "unescaped" }
+func (u *Unescaped) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"unescaped", u.ID}, RangeSegments: []string{"unescaped"}}
+}
+
+type Joined struct {
+	A, B string
+}
+
+func (j *Joined) Gonetable_TypeID() string { return "joined" }
+func (j *Joined) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{j.A + "#" + j.B}, RangeSegments: []string{"joined"}}
+}
+
+type Lossy struct {
+	ID    string
+	Title string `dynamodbav:"Name"`
+	Name  string
+}
+
+func (l *Lossy) Gonetable_TypeID() string { return "lossy" }
+func (l *Lossy) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{HashSegments: []string{"lossy", escape(l.ID)}, RangeSegments: []string{"lossy"}}
+}
+
+func TestCheckDocument(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample gonetable.Document
+		want   []string
+	}{
+		{
+			name:   "good",
+			sample: &Good{},
+		},
+		{
+			name:   "pointer receiver on value document",
+			sample: ValueDoc{},
+			want:   []string{"Gonetable_GSI1Key has pointer receiver, but the document is registered as value gonetabletest_test.ValueDoc"},
+		},
+		{
+			name:   "reserved names",
+			sample: &Reserved{},
+			want: []string{
+				"field Kind uses the type id attribute _Type",
+				"field GSI1SK uses the GSI1 sort key attribute GSI1SK",
+			},
+		},
+		{
+			name:   "unescaped field in key",
+			sample: &Unescaped{},
+			want:   []string{"key delimiter used in key segment"},
+		},
+		{
+			name:   "delimiter in key",
+			sample: &Joined{},
+			want:   []string{"key delimiter used in key segment"},
+		},
+		{
+			name:   "lossy round trip",
+			sample: &Lossy{},
+			want:   []string{"fields changed in Marshal/Unmarshal round trip: Name\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			gonetabletest.CheckDocument(r, tt.sample)
+			if len(r.errors) != len(tt.want) {
+				t.Fatalf("errors = %q, want %d", r.errors, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(r.errors[i], want) {
+					t.Errorf("error %q doesn't contain %q", r.errors[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Package gonetabletest provisions gonetable tables for tests.
+//
+// NewTestTable creates a uniquely named table for each test in
+// DynamoDB Local, so tests can run in parallel against a shared
+// container, and deletes the table when the test ends. Start DynamoDB
+// Local for example with the docker-compose.yaml of this repository.
+package gonetabletest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/memddb"
+)
+
+const (
+	// EndpointEnv overrides the default endpoint of DynamoDB Local
+	EndpointEnv     = "GONETABLE_TEST_ENDPOINT"
+	DefaultEndpoint = "http://localhost:8000"
+)
+
+// DynamoDB table names are 3-255 characters
+const maxTableName = 255
+
+var invalidTableNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+type options struct {
+	endpoint     string
+	fakeFallback bool
+	dialTimeout  time.Duration
+	tableOpts    []gonetable.TableOption
+	createOpts   []gonetable.CreateTableOption
+}
+
+// Option modifies how NewTestTable provisions the table.
+type Option func(*options)
+
+// WithEndpoint sets the URL of DynamoDB Local. Default is the value
+// of GONETABLE_TEST_ENDPOINT environment variable, or
+// http://localhost:8000.
+func WithEndpoint(endpoint string) Option {
+	return func(o *options) {
+		o.endpoint = endpoint
+	}
+}
+
+// WithFakeFallback makes NewTestTable use the in-memory fake from
+// memddb package when the endpoint isn't reachable, instead of
+// skipping the test.
+func WithFakeFallback() Option {
+	return func(o *options) {
+		o.fakeFallback = true
+	}
+}
+
+// WithDialTimeout sets how long NewTestTable waits when checking
+// whether the endpoint is reachable. Default is 500ms.
+func WithDialTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = d
+	}
+}
+
+// WithTableOptions are passed to gonetable.NewTable.
+func WithTableOptions(opts ...gonetable.TableOption) Option {
+	return func(o *options) {
+		o.tableOpts = append(o.tableOpts, opts...)
+	}
+}
+
+// WithCreateTableOptions are used when the table is created.
+func WithCreateTableOptions(opts ...gonetable.CreateTableOption) Option {
+	return func(o *options) {
+		o.createOpts = append(o.createOpts, opts...)
+	}
+}
+
+// NewTestTable creates a table for the schema and deletes it when the
+// test and its subtests have completed. The table name is derived from
+// the test name and a random suffix.
+//
+// If the endpoint isn't reachable, the test is skipped, or it gets a
+// table in memddb fake if WithFakeFallback is given.
+func NewTestTable(t testing.TB, schema *gonetable.Schema, opts ...Option) *gonetable.Table {
+	t.Helper()
+	o := &options{
+		endpoint:    os.Getenv(EndpointEnv),
+		dialTimeout: 500 * time.Millisecond,
+	}
+	if o.endpoint == "" {
+		o.endpoint = DefaultEndpoint
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	name := TableName(t)
+	ctx := context.Background()
+	var client gonetable.Client
+	if err := checkReachable(o.endpoint, o.dialTimeout); err != nil {
+		if !o.fakeFallback {
+			t.Skipf("DynamoDB Local not reachable: %v", err)
+		}
+		client = memddb.New()
+	} else {
+		cfg, err := LocalConfig(o.endpoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ddb := dynamodb.NewFromConfig(cfg)
+		t.Cleanup(func() {
+			if err := DeleteTableIfExists(context.Background(), ddb, name); err != nil {
+				t.Errorf("delete test table %s: %v", name, err)
+			}
+		})
+		client = ddb
+	}
+	table := gonetable.NewTable(name, schema, client, o.tableOpts...)
+	_, err := table.Ensure(ctx,
+		gonetable.EnsurePollInterval(100*time.Millisecond),
+		gonetable.EnsureCreateTableOptions(o.createOpts...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+// TableName returns unique table name for the test.
+func TableName(t testing.TB) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	name := invalidTableNameChars.ReplaceAllString(t.Name(), "_")
+	if max := maxTableName - 1 - 2*len(suffix); len(name) > max {
+		name = name[:max]
+	}
+	return name + "-" + hex.EncodeToString(suffix)
+}
+
+// LocalConfig returns AWS config for DynamoDB Local at endpoint, with
+// dummy credentials.
+func LocalConfig(endpoint string) (aws.Config, error) {
+	return config.LoadDefaultConfig(
+		context.Background(),
+		config.WithRegion("local"),
+		config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(
+			func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+				return aws.Endpoint{URL: endpoint}, nil
+			})),
+		config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
+			Value: aws.Credentials{
+				AccessKeyID:     "dummy",
+				SecretAccessKey: "dummy",
+				SessionToken:    "dummy",
+				Source:          "dummy",
+			},
+		}),
+	)
+}
+
+// DeleteTableIfExists deletes the table, ignoring
+// ResourceNotFoundException.
+func DeleteTableIfExists(ctx context.Context, ddb *dynamodb.Client, name string) error {
+	_, err := ddb.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: aws.String(name)})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
+
+func checkReachable(endpoint string, timeout time.Duration) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	conn, err := net.DialTimeout("tcp", host, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
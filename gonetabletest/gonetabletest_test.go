@@ -0,0 +1,76 @@
+package gonetabletest_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/gonetabletest"
+	"github.com/juranki/gonetable/memddb"
+)
+
+type Item struct {
+	ID string
+}
+
+func (i *Item) Gonetable_TypeID() string { return "item" }
+func (i *Item) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"item", i.ID},
+		RangeSegments: []string{"item"},
+	}
+}
+
+func testSchema(t *testing.T) *gonetable.Schema {
+	s, err := gonetable.NewSchema([]gonetable.Document{&Item{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func roundTrip(t *testing.T, table *gonetable.Table) {
+	ctx := context.Background()
+	if err := table.Put(ctx, &Item{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := table.Get(ctx, (&Item{ID: "1"}).Gonetable_Key())
+	if err != nil || doc.(*Item).ID != "1" {
+		t.Errorf("Get() = %v, %v", doc, err)
+	}
+}
+
+func TestNewTestTable_FakeFallback(t *testing.T) {
+	table := gonetabletest.NewTestTable(t, testSchema(t),
+		gonetabletest.WithEndpoint("http://127.0.0.1:1"),
+		gonetabletest.WithFakeFallback())
+	if _, ok := table.Client().(*memddb.Client); !ok {
+		t.Errorf("client = %T, want *memddb.Client", table.Client())
+	}
+	roundTrip(t, table)
+}
+
+func TestNewTestTable_Local(t *testing.T) {
+	t.Parallel()
+	table := gonetabletest.NewTestTable(t, testSchema(t))
+	roundTrip(t, table)
+}
+
+func TestTableName(t *testing.T) {
+	t.Run("sub test/with spaces", func(t *testing.T) {
+		a, b := gonetabletest.TableName(t), gonetabletest.TableName(t)
+		if a == b {
+			t.Errorf("names are not unique: %s", a)
+		}
+		if !regexp.MustCompile(`^TestTableName_sub_test_with_spaces-[0-9a-f]{8}$`).MatchString(a) {
+			t.Errorf("name = %s", a)
+		}
+	})
+	t.Run(strings.Repeat("x", 300), func(t *testing.T) {
+		if name := gonetabletest.TableName(t); len(name) != 255 {
+			t.Errorf("len(name) = %d", len(name))
+		}
+	})
+}
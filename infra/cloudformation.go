@@ -0,0 +1,161 @@
+package infra
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrResourceName = errors.New("invalid resource name")
+
+var logicalIDRE = regexp.MustCompile(`^[a-zA-Z0-9]{1,255}$`)
+
+type cfnResource struct {
+	Type       string   `yaml:"Type"`
+	Properties cfnTable `yaml:"Properties"`
+}
+
+type cfnTable struct {
+	TableName              string            `yaml:"TableName"`
+	BillingMode            types.BillingMode `yaml:"BillingMode"`
+	AttributeDefinitions   []cfnAttribute    `yaml:"AttributeDefinitions"`
+	KeySchema              []cfnKeyElement   `yaml:"KeySchema"`
+	GlobalSecondaryIndexes []cfnIndex        `yaml:"GlobalSecondaryIndexes,omitempty"`
+	LocalSecondaryIndexes  []cfnIndex        `yaml:"LocalSecondaryIndexes,omitempty"`
+	ProvisionedThroughput  *cfnThroughput    `yaml:"ProvisionedThroughput,omitempty"`
+	StreamSpecification    *cfnStream        `yaml:"StreamSpecification,omitempty"`
+	SSESpecification       *cfnSSE           `yaml:"SSESpecification,omitempty"`
+	TableClass             types.TableClass  `yaml:"TableClass,omitempty"`
+	Tags                   []cfnTag          `yaml:"Tags,omitempty"`
+}
+
+type cfnAttribute struct {
+	AttributeName string                    `yaml:"AttributeName"`
+	AttributeType types.ScalarAttributeType `yaml:"AttributeType"`
+}
+
+type cfnKeyElement struct {
+	AttributeName string        `yaml:"AttributeName"`
+	KeyType       types.KeyType `yaml:"KeyType"`
+}
+
+type cfnIndex struct {
+	IndexName             string          `yaml:"IndexName"`
+	KeySchema             []cfnKeyElement `yaml:"KeySchema"`
+	Projection            cfnProjection   `yaml:"Projection"`
+	ProvisionedThroughput *cfnThroughput  `yaml:"ProvisionedThroughput,omitempty"`
+}
+
+type cfnProjection struct {
+	ProjectionType   types.ProjectionType `yaml:"ProjectionType"`
+	NonKeyAttributes []string             `yaml:"NonKeyAttributes,omitempty"`
+}
+
+type cfnThroughput struct {
+	ReadCapacityUnits  int64 `yaml:"ReadCapacityUnits"`
+	WriteCapacityUnits int64 `yaml:"WriteCapacityUnits"`
+}
+
+type cfnStream struct {
+	StreamViewType types.StreamViewType `yaml:"StreamViewType"`
+}
+
+type cfnSSE struct {
+	SSEEnabled     bool          `yaml:"SSEEnabled"`
+	SSEType        types.SSEType `yaml:"SSEType,omitempty"`
+	KMSMasterKeyId string        `yaml:"KMSMasterKeyId,omitempty"`
+}
+
+type cfnTag struct {
+	Key   string `yaml:"Key"`
+	Value string `yaml:"Value"`
+}
+
+// CloudFormation writes the table as a CloudFormation resource in
+// YAML, to be placed under Resources of a template. Logical id must be
+// alphanumeric.
+func CloudFormation(w io.Writer, logicalID string, input *dynamodb.CreateTableInput) error {
+	if !logicalIDRE.MatchString(logicalID) {
+		return fmt.Errorf("%w: %q, CloudFormation logical id must be alphanumeric", ErrResourceName, logicalID)
+	}
+	table := cfnTable{
+		TableName:             aws.ToString(input.TableName),
+		BillingMode:           input.BillingMode,
+		KeySchema:             cfnKeySchema(input.KeySchema),
+		ProvisionedThroughput: cfnProvisioned(input.ProvisionedThroughput),
+		TableClass:            input.TableClass,
+	}
+	for _, ad := range input.AttributeDefinitions {
+		table.AttributeDefinitions = append(table.AttributeDefinitions, cfnAttribute{
+			AttributeName: aws.ToString(ad.AttributeName),
+			AttributeType: ad.AttributeType,
+		})
+	}
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		table.GlobalSecondaryIndexes = append(table.GlobalSecondaryIndexes, cfnIndex{
+			IndexName:             aws.ToString(gsi.IndexName),
+			KeySchema:             cfnKeySchema(gsi.KeySchema),
+			Projection:            cfnProjectionOf(gsi.Projection),
+			ProvisionedThroughput: cfnProvisioned(gsi.ProvisionedThroughput),
+		})
+	}
+	for _, lsi := range input.LocalSecondaryIndexes {
+		table.LocalSecondaryIndexes = append(table.LocalSecondaryIndexes, cfnIndex{
+			IndexName:  aws.ToString(lsi.IndexName),
+			KeySchema:  cfnKeySchema(lsi.KeySchema),
+			Projection: cfnProjectionOf(lsi.Projection),
+		})
+	}
+	if s := input.StreamSpecification; s != nil && aws.ToBool(s.StreamEnabled) {
+		table.StreamSpecification = &cfnStream{StreamViewType: s.StreamViewType}
+	}
+	if s := input.SSESpecification; s != nil && aws.ToBool(s.Enabled) {
+		table.SSESpecification = &cfnSSE{
+			SSEEnabled:     true,
+			SSEType:        s.SSEType,
+			KMSMasterKeyId: aws.ToString(s.KMSMasterKeyId),
+		}
+	}
+	for _, tag := range input.Tags {
+		table.Tags = append(table.Tags, cfnTag{Key: aws.ToString(tag.Key), Value: aws.ToString(tag.Value)})
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]cfnResource{
+		logicalID: {Type: "AWS::DynamoDB::Table", Properties: table},
+	}); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func cfnKeySchema(ks []types.KeySchemaElement) []cfnKeyElement {
+	rv := make([]cfnKeyElement, len(ks))
+	for i, k := range ks {
+		rv[i] = cfnKeyElement{AttributeName: aws.ToString(k.AttributeName), KeyType: k.KeyType}
+	}
+	return rv
+}
+
+func cfnProjectionOf(p *types.Projection) cfnProjection {
+	if p == nil {
+		return cfnProjection{ProjectionType: types.ProjectionTypeAll}
+	}
+	return cfnProjection{ProjectionType: p.ProjectionType, NonKeyAttributes: p.NonKeyAttributes}
+}
+
+func cfnProvisioned(t *types.ProvisionedThroughput) *cfnThroughput {
+	if t == nil {
+		return nil
+	}
+	return &cfnThroughput{
+		ReadCapacityUnits:  aws.ToInt64(t.ReadCapacityUnits),
+		WriteCapacityUnits: aws.ToInt64(t.WriteCapacityUnits),
+	}
+}
@@ -0,0 +1,18 @@
+// Package infra renders table definitions of a gonetable schema for
+// infrastructure tools, so that tables declared outside Go match the
+// schema of the code.
+//
+// Renderers take the CreateTableInput of Schema or SchemaSnapshot, so
+// billing mode, streams, encryption, table class and tags are set with
+// the same CreateTableOptions that are used when creating the table
+// from Go:
+//
+//	input, err := schema.CreateTableInput("app", gonetable.WithStream(types.StreamViewTypeNewImage))
+//	if err != nil {
+//		return err
+//	}
+//	return infra.Terraform(os.Stdout, "app", input)
+//
+// CloudFormation output is an AWS::DynamoDB::Table resource, that is
+// also used for tables with indexes in SAM templates.
+package infra
@@ -0,0 +1,260 @@
+package infra_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/infra"
+)
+
+type Post struct {
+	Editor  string
+	Slug    string
+	Title   string
+	Created string
+}
+
+func (p *Post) Gonetable_TypeID() string { return "post" }
+func (p *Post) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"ed", p.Editor},
+		RangeSegments: []string{"post", p.Slug},
+	}
+}
+func (p *Post) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"post"},
+		RangeSegments: []string{p.Slug},
+	}
+}
+func (p *Post) Gonetable_LSI1SortKey() []string { return []string{"created", p.Created} }
+
+type PostTitle struct {
+	Title string
+}
+
+func input(t *testing.T, opts ...gonetable.CreateTableOption) *dynamodb.CreateTableInput {
+	s, err := gonetable.NewSchema(
+		[]gonetable.Document{&Post{}},
+		gonetable.WithProjection("GSI1", gonetable.IncludeProjection(PostTitle{})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := s.CreateTableInput("app-${env}", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+const kmsKeyARN = "arn:aws:kms:eu-west-1:111122223333:key/app"
+
+var fullOptions = []gonetable.CreateTableOption{
+	gonetable.WithProvisionedThroughput(5, 2),
+	gonetable.WithStream(types.StreamViewTypeNewImage),
+	gonetable.WithSSE(kmsKeyARN),
+	gonetable.WithTableClass(types.TableClassStandardInfrequentAccess),
+	gonetable.WithTags(map[string]string{"team": "blog", "cost center": "42"}),
+}
+
+const wantCloudFormation = `AppTable:
+  Type: AWS::DynamoDB::Table
+  Properties:
+    TableName: app-${env}
+    BillingMode: PROVISIONED
+    AttributeDefinitions:
+      - AttributeName: PK
+        AttributeType: S
+      - AttributeName: SK
+        AttributeType: S
+      - AttributeName: GSI1PK
+        AttributeType: S
+      - AttributeName: GSI1SK
+        AttributeType: S
+      - AttributeName: LSI1SK
+        AttributeType: S
+    KeySchema:
+      - AttributeName: PK
+        KeyType: HASH
+      - AttributeName: SK
+        KeyType: RANGE
+    GlobalSecondaryIndexes:
+      - IndexName: GSI1
+        KeySchema:
+          - AttributeName: GSI1PK
+            KeyType: HASH
+          - AttributeName: GSI1SK
+            KeyType: RANGE
+        Projection:
+          ProjectionType: INCLUDE
+          NonKeyAttributes:
+            - Title
+            - _Type
+        ProvisionedThroughput:
+          ReadCapacityUnits: 5
+          WriteCapacityUnits: 2
+    LocalSecondaryIndexes:
+      - IndexName: LSI1
+        KeySchema:
+          - AttributeName: PK
+            KeyType: HASH
+          - AttributeName: LSI1SK
+            KeyType: RANGE
+        Projection:
+          ProjectionType: ALL
+    ProvisionedThroughput:
+      ReadCapacityUnits: 5
+      WriteCapacityUnits: 2
+    StreamSpecification:
+      StreamViewType: NEW_IMAGE
+    SSESpecification:
+      SSEEnabled: true
+      SSEType: KMS
+      KMSMasterKeyId: arn:aws:kms:eu-west-1:111122223333:key/app
+    TableClass: STANDARD_INFREQUENT_ACCESS
+    Tags:
+      - Key: cost center
+        Value: "42"
+      - Key: team
+        Value: blog
+`
+
+const wantTerraform = `resource "aws_dynamodb_table" "app" {
+  name             = "app-$${env}"
+  billing_mode     = "PROVISIONED"
+  hash_key         = "PK"
+  range_key        = "SK"
+  read_capacity    = 5
+  write_capacity   = 2
+  table_class      = "STANDARD_INFREQUENT_ACCESS"
+  stream_enabled   = true
+  stream_view_type = "NEW_IMAGE"
+
+  attribute {
+    name = "PK"
+    type = "S"
+  }
+
+  attribute {
+    name = "SK"
+    type = "S"
+  }
+
+  attribute {
+    name = "GSI1PK"
+    type = "S"
+  }
+
+  attribute {
+    name = "GSI1SK"
+    type = "S"
+  }
+
+  attribute {
+    name = "LSI1SK"
+    type = "S"
+  }
+
+  global_secondary_index {
+    name               = "GSI1"
+    hash_key           = "GSI1PK"
+    range_key          = "GSI1SK"
+    projection_type    = "INCLUDE"
+    non_key_attributes = ["Title", "_Type"]
+    read_capacity      = 5
+    write_capacity     = 2
+  }
+
+  local_secondary_index {
+    name            = "LSI1"
+    range_key       = "LSI1SK"
+    projection_type = "ALL"
+  }
+
+  server_side_encryption {
+    enabled     = true
+    kms_key_arn = "arn:aws:kms:eu-west-1:111122223333:key/app"
+  }
+
+  tags = {
+    "cost center" = "42"
+    team          = "blog"
+  }
+}
+`
+
+func TestCloudFormation(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := infra.CloudFormation(buf, "AppTable", input(t, fullOptions...)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != wantCloudFormation {
+		t.Errorf("CloudFormation:\n%s\nwant:\n%s", buf, wantCloudFormation)
+	}
+}
+
+func TestTerraform(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := infra.Terraform(buf, "app", input(t, fullOptions...)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != wantTerraform {
+		t.Errorf("Terraform:\n%s\nwant:\n%s", buf, wantTerraform)
+	}
+}
+
+func TestTerraform_OnDemand(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := infra.Terraform(buf, "app", input(t)); err != nil {
+		t.Fatal(err)
+	}
+	want := `resource "aws_dynamodb_table" "app" {
+  name         = "app-$${env}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "PK"
+  range_key    = "SK"
+`
+	if !bytes.HasPrefix(buf.Bytes(), []byte(want)) {
+		t.Errorf("Terraform:\n%s\nwant prefix:\n%s", buf, want)
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	in := input(t)
+	if err := infra.CloudFormation(&bytes.Buffer{}, "app-table", in); !errors.Is(err, infra.ErrResourceName) {
+		t.Errorf("CloudFormation error = %v, want %v", err, infra.ErrResourceName)
+	}
+	if err := infra.Terraform(&bytes.Buffer{}, "1app", in); !errors.Is(err, infra.ErrResourceName) {
+		t.Errorf("Terraform error = %v, want %v", err, infra.ErrResourceName)
+	}
+}
+
+func TestTerraform_Escapes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := infra.Terraform(buf, "app", input(t, gonetable.WithTags(map[string]string{
+		"note": "a \"b\"\\\n\t\a\x7f\u200bé %{x}",
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `note = "a \"b\"\\\n\t\u0007\u007f\u200bé %%{x}"`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Terraform:\n%s\nwant to contain:\n%s", buf, want)
+	}
+}
+
+func TestTerraform_KMSKeyARN(t *testing.T) {
+	err := infra.Terraform(&bytes.Buffer{}, "app", input(t, gonetable.WithSSE("alias/app")))
+	if !errors.Is(err, infra.ErrKMSKeyARN) {
+		t.Errorf("error = %v, want %v", err, infra.ErrKMSKeyARN)
+	}
+	if err := infra.CloudFormation(&bytes.Buffer{}, "App", input(t, gonetable.WithSSE("alias/app"))); err != nil {
+		t.Errorf("CloudFormation error = %v, want alias accepted", err)
+	}
+}
@@ -0,0 +1,225 @@
+package infra
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrKMSKeyARN = errors.New("Terraform needs KMS key ARN, not key id or alias")
+
+// Terraform resource names and map keys that need no quotes
+var identifierRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// Terraform writes the table as an aws_dynamodb_table resource block
+// in HCL, formatted like terraform fmt does. Terraform sets the KMS key
+// of server side encryption by ARN, so the key given to
+// gonetable.WithSSE must be an ARN.
+func Terraform(w io.Writer, resourceName string, input *dynamodb.CreateTableInput) error {
+	if !identifierRE.MatchString(resourceName) {
+		return fmt.Errorf("%w: %q, Terraform resource name must start with letter or underscore and contain only letters, digits, underscores and dashes",
+			ErrResourceName, resourceName)
+	}
+	if s := input.SSESpecification; s != nil && s.KMSMasterKeyId != nil && !strings.HasPrefix(*s.KMSMasterKeyId, "arn:") {
+		return fmt.Errorf("%w: %q", ErrKMSKeyARN, *s.KMSMasterKeyId)
+	}
+	body := &hclBody{}
+	body.attr("name", hclString(aws.ToString(input.TableName)))
+	body.attr("billing_mode", hclString(string(input.BillingMode)))
+	for _, k := range input.KeySchema {
+		body.attr(keyAttr(k.KeyType), hclString(aws.ToString(k.AttributeName)))
+	}
+	if t := input.ProvisionedThroughput; t != nil {
+		body.attr("read_capacity", strconv.FormatInt(aws.ToInt64(t.ReadCapacityUnits), 10))
+		body.attr("write_capacity", strconv.FormatInt(aws.ToInt64(t.WriteCapacityUnits), 10))
+	}
+	if input.TableClass != "" {
+		body.attr("table_class", hclString(string(input.TableClass)))
+	}
+	if s := input.StreamSpecification; s != nil && aws.ToBool(s.StreamEnabled) {
+		body.attr("stream_enabled", "true")
+		body.attr("stream_view_type", hclString(string(s.StreamViewType)))
+	}
+	for _, ad := range input.AttributeDefinitions {
+		attribute := body.block("attribute")
+		attribute.attr("name", hclString(aws.ToString(ad.AttributeName)))
+		attribute.attr("type", hclString(string(ad.AttributeType)))
+	}
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		index := body.block("global_secondary_index")
+		index.attr("name", hclString(aws.ToString(gsi.IndexName)))
+		for _, k := range gsi.KeySchema {
+			index.attr(keyAttr(k.KeyType), hclString(aws.ToString(k.AttributeName)))
+		}
+		projectionAttrs(index, gsi.Projection)
+		if t := gsi.ProvisionedThroughput; t != nil {
+			index.attr("read_capacity", strconv.FormatInt(aws.ToInt64(t.ReadCapacityUnits), 10))
+			index.attr("write_capacity", strconv.FormatInt(aws.ToInt64(t.WriteCapacityUnits), 10))
+		}
+	}
+	for _, lsi := range input.LocalSecondaryIndexes {
+		index := body.block("local_secondary_index")
+		index.attr("name", hclString(aws.ToString(lsi.IndexName)))
+		for _, k := range lsi.KeySchema {
+			// hash key of LSI is always the hash key of the table
+			if k.KeyType == types.KeyTypeRange {
+				index.attr("range_key", hclString(aws.ToString(k.AttributeName)))
+			}
+		}
+		projectionAttrs(index, lsi.Projection)
+	}
+	if s := input.SSESpecification; s != nil && aws.ToBool(s.Enabled) {
+		sse := body.block("server_side_encryption")
+		sse.attr("enabled", "true")
+		if s.KMSMasterKeyId != nil {
+			sse.attr("kms_key_arn", hclString(*s.KMSMasterKeyId))
+		}
+	}
+	if len(input.Tags) > 0 {
+		tags := &hclBody{}
+		for _, tag := range input.Tags {
+			tags.attr(hclKey(aws.ToString(tag.Key)), hclString(aws.ToString(tag.Value)))
+		}
+		body.mapAttr("tags", tags)
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "resource \"aws_dynamodb_table\" %s {\n", hclString(resourceName))
+	body.write(b, 1)
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func keyAttr(kt types.KeyType) string {
+	if kt == types.KeyTypeHash {
+		return "hash_key"
+	}
+	return "range_key"
+}
+
+func projectionAttrs(index *hclBody, p *types.Projection) {
+	if p == nil {
+		index.attr("projection_type", hclString(string(types.ProjectionTypeAll)))
+		return
+	}
+	index.attr("projection_type", hclString(string(p.ProjectionType)))
+	if len(p.NonKeyAttributes) > 0 {
+		names := make([]string, len(p.NonKeyAttributes))
+		for i, name := range p.NonKeyAttributes {
+			names[i] = hclString(name)
+		}
+		index.attr("non_key_attributes", "["+strings.Join(names, ", ")+"]")
+	}
+}
+
+// hclBody is the body of a block or map, with attributes and nested
+// blocks in the order they are written
+type hclBody struct {
+	items []hclItem
+}
+
+type hclItem struct {
+	name  string
+	value string
+	// nested block, or map value if isMap
+	body  *hclBody
+	isMap bool
+}
+
+func (b *hclBody) attr(name, value string) {
+	b.items = append(b.items, hclItem{name: name, value: value})
+}
+
+func (b *hclBody) mapAttr(name string, m *hclBody) {
+	b.items = append(b.items, hclItem{name: name, body: m, isMap: true})
+}
+
+func (b *hclBody) block(name string) *hclBody {
+	body := &hclBody{}
+	b.items = append(b.items, hclItem{name: name, body: body})
+	return body
+}
+
+// write writes the items with consecutive attributes aligned at the
+// equals sign, and blocks separated by empty lines
+func (b *hclBody) write(sb *strings.Builder, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for i := 0; i < len(b.items); i++ {
+		item := b.items[i]
+		if i > 0 && (item.isBlock() || b.items[i-1].isBlock()) {
+			sb.WriteString("\n")
+		}
+		if item.isBlock() {
+			fmt.Fprintf(sb, "%s%s {\n", indent, item.name)
+			item.body.write(sb, depth+1)
+			fmt.Fprintf(sb, "%s}\n", indent)
+			continue
+		}
+		width := 0
+		for j := i; j < len(b.items) && !b.items[j].isBlock(); j++ {
+			if len(b.items[j].name) > width {
+				width = len(b.items[j].name)
+			}
+		}
+		for ; i < len(b.items) && !b.items[i].isBlock(); i++ {
+			item := b.items[i]
+			fmt.Fprintf(sb, "%s%-*s = ", indent, width, item.name)
+			if item.isMap {
+				sb.WriteString("{\n")
+				item.body.write(sb, depth+1)
+				fmt.Fprintf(sb, "%s}\n", indent)
+				continue
+			}
+			sb.WriteString(item.value + "\n")
+		}
+		i--
+	}
+}
+
+func (item hclItem) isBlock() bool { return item.body != nil && !item.isMap }
+
+// hclString quotes string with the escapes that HCL supports, and
+// escapes template sequences too
+func hclString(s string) string {
+	b := &strings.Builder{}
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case !unicode.IsPrint(r) && r <= 0xffff:
+			fmt.Fprintf(b, `\u%04x`, r)
+		case !unicode.IsPrint(r):
+			fmt.Fprintf(b, `\U%08x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	q := strings.ReplaceAll(b.String(), "${", "$${")
+	return strings.ReplaceAll(q, "%{", "%%{")
+}
+
+// hclKey returns map key as identifier if possible, otherwise quoted
+func hclKey(s string) string {
+	if identifierRE.MatchString(s) {
+		return s
+	}
+	return hclString(s)
+}
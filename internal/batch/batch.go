@@ -0,0 +1,86 @@
+// Package batch runs DynamoDB batch operations to completion. Keys and
+// items that DynamoDB leaves unprocessed are retried with capped
+// exponential backoff, as the DynamoDB documentation recommends.
+package batch
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Delay before the first retry, doubled on each following retry up to
+// MaxDelay. Variables so that tests can shorten them.
+var (
+	BaseDelay = 50 * time.Millisecond
+	MaxDelay  = 5 * time.Second
+)
+
+type Getter interface {
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+}
+
+type Writer interface {
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+}
+
+// Get reads the requested keys and returns the items by table name.
+func Get(ctx context.Context, client Getter, requests map[string]types.KeysAndAttributes) (map[string][]map[string]types.AttributeValue, error) {
+	rv := map[string][]map[string]types.AttributeValue{}
+	for retry := 0; len(requests) > 0; retry++ {
+		if retry > 0 {
+			if err := Backoff(ctx, retry); err != nil {
+				return nil, err
+			}
+		}
+		out, err := client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: requests,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for table, items := range out.Responses {
+			rv[table] = append(rv[table], items...)
+		}
+		requests = out.UnprocessedKeys
+	}
+	return rv, nil
+}
+
+// Write writes the requests to the table.
+func Write(ctx context.Context, client Writer, table string, requests []types.WriteRequest) error {
+	for retry := 0; len(requests) > 0; retry++ {
+		if retry > 0 {
+			if err := Backoff(ctx, retry); err != nil {
+				return err
+			}
+		}
+		out, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{table: requests},
+		})
+		if err != nil {
+			return err
+		}
+		requests = out.UnprocessedItems[table]
+	}
+	return nil
+}
+
+// Backoff sleeps before retry, or returns the error of ctx if it is
+// done first. Retries are numbered from 1.
+func Backoff(ctx context.Context, retry int) error {
+	delay := MaxDelay
+	if retry < 32 && BaseDelay<<(retry-1) < MaxDelay {
+		delay = BaseDelay << (retry - 1)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
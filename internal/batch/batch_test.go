@@ -0,0 +1,102 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/internal/batch"
+)
+
+// throttled processes one request per call
+type throttled struct {
+	calls []time.Time
+}
+
+func (th *throttled) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	th.calls = append(th.calls, time.Now())
+	out := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]types.AttributeValue{},
+		UnprocessedKeys: map[string]types.KeysAndAttributes{},
+	}
+	for table, req := range params.RequestItems {
+		out.Responses[table] = req.Keys[:1]
+		if len(req.Keys) > 1 {
+			out.UnprocessedKeys[table] = types.KeysAndAttributes{Keys: req.Keys[1:]}
+		}
+	}
+	return out, nil
+}
+
+func (th *throttled) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	th.calls = append(th.calls, time.Now())
+	out := &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{}}
+	for table, reqs := range params.RequestItems {
+		if len(reqs) > 1 {
+			out.UnprocessedItems[table] = reqs[1:]
+		}
+	}
+	return out, nil
+}
+
+func keys(n int) []map[string]types.AttributeValue {
+	rv := make([]map[string]types.AttributeValue, n)
+	for i := range rv {
+		rv[i] = map[string]types.AttributeValue{"PK": &types.AttributeValueMemberN{Value: string(rune('0' + i))}}
+	}
+	return rv
+}
+
+func TestGet(t *testing.T) {
+	client := &throttled{}
+	got, err := batch.Get(context.Background(), client, map[string]types.KeysAndAttributes{
+		"test": {Keys: keys(3)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got["test"]) != 3 {
+		t.Errorf("got %d items, want 3", len(got["test"]))
+	}
+	if len(client.calls) != 3 {
+		t.Fatalf("got %d calls, want 3", len(client.calls))
+	}
+	first, second := client.calls[1].Sub(client.calls[0]), client.calls[2].Sub(client.calls[1])
+	if first < batch.BaseDelay || second < 2*batch.BaseDelay {
+		t.Errorf("delays %v and %v, want at least %v and %v", first, second, batch.BaseDelay, 2*batch.BaseDelay)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	client := &throttled{}
+	requests := make([]types.WriteRequest, 3)
+	for i, key := range keys(3) {
+		requests[i] = types.WriteRequest{PutRequest: &types.PutRequest{Item: key}}
+	}
+	if err := batch.Write(context.Background(), client, "test", requests); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.calls) != 3 {
+		t.Errorf("got %d calls, want 3", len(client.calls))
+	}
+}
+
+func TestWrite_Canceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), batch.BaseDelay/2)
+	defer cancel()
+	client := &throttled{}
+	requests := make([]types.WriteRequest, 3)
+	for i, key := range keys(3) {
+		requests[i] = types.WriteRequest{PutRequest: &types.PutRequest{Item: key}}
+	}
+	err := batch.Write(ctx, client, "test", requests)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if len(client.calls) != 1 {
+		t.Errorf("got %d calls, want 1", len(client.calls))
+	}
+}
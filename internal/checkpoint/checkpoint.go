@@ -0,0 +1,81 @@
+// Package checkpoint implements the checkpoint stores of migrate and
+// stream packages. Checkpoints are kept by key, that is scan segment
+// or shard id, and must be encodable as JSON object key.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// Memory keeps checkpoints in memory.
+type Memory[K comparable, V any] struct {
+	mu          sync.Mutex
+	checkpoints map[K]V
+}
+
+func (m *Memory[K, V]) Load(ctx context.Context) (map[K]V, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rv := map[K]V{}
+	for k, v := range m.checkpoints {
+		rv[k] = v
+	}
+	return rv, nil
+}
+
+func (m *Memory[K, V]) Save(ctx context.Context, key K, checkpoint V) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.checkpoints == nil {
+		m.checkpoints = map[K]V{}
+	}
+	m.checkpoints[key] = checkpoint
+	return nil
+}
+
+// File keeps checkpoints in a JSON file. The file is rewritten on
+// every save.
+type File[K comparable, V any] struct {
+	Path string
+	mu   sync.Mutex
+	mem  Memory[K, V]
+}
+
+func (f *File[K, V]) Load(ctx context.Context) (map[K]V, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[K]V{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := map[K]V{}
+	if err := json.Unmarshal(data, &checkpoints); err != nil {
+		return nil, err
+	}
+	for key, checkpoint := range checkpoints {
+		f.mem.Save(ctx, key, checkpoint)
+	}
+	return checkpoints, nil
+}
+
+func (f *File[K, V]) Save(ctx context.Context, key K, checkpoint V) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mem.Save(ctx, key, checkpoint)
+	checkpoints, _ := f.mem.Load(ctx)
+	data, err := json.Marshal(checkpoints)
+	if err != nil {
+		return err
+	}
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Path)
+}
@@ -0,0 +1,59 @@
+package checkpoint_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/juranki/gonetable/internal/checkpoint"
+)
+
+type segment struct {
+	PK   string `json:"pk,omitempty"`
+	Done bool   `json:"done,omitempty"`
+}
+
+func TestFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	f := &checkpoint.File[int, segment]{Path: path}
+	got, err := f.Load(ctx)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("Load() = %v, %v", got, err)
+	}
+	if err := f.Save(ctx, 0, segment{PK: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Save(ctx, 1, segment{Done: true}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = (&checkpoint.File[int, segment]{Path: path}).Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]segment{
+		0: {PK: "a"},
+		1: {Done: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	m := &checkpoint.Memory[string, string]{}
+	if err := m.Save(ctx, "shard", "1"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got["shard"] = "changed"
+	got, _ = m.Load(ctx)
+	if !reflect.DeepEqual(got, map[string]string{"shard": "1"}) {
+		t.Errorf("Load() = %v", got)
+	}
+}
@@ -0,0 +1,147 @@
+// Package ddbjson converts attribute values to and from the DynamoDB
+// JSON format, e.g. {"S": "abc"} and {"M": {"n": {"N": "1"}}}, that is
+// used in Lambda stream events and table exports.
+package ddbjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var ErrAttributeValue = errors.New("invalid DynamoDB JSON attribute value")
+
+// Map is attribute value map that marshals to and from DynamoDB JSON.
+type Map map[string]types.AttributeValue
+
+func (m Map) MarshalJSON() ([]byte, error) {
+	out := make(map[string]json.RawMessage, len(m))
+	for k, v := range m {
+		raw, err := Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		out[k] = raw
+	}
+	return json.Marshal(out)
+}
+
+func (m *Map) UnmarshalJSON(data []byte) error {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = make(Map, len(raw))
+	for k, v := range raw {
+		av, err := Unmarshal(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		(*m)[k] = av
+	}
+	return nil
+}
+
+// Marshal returns DynamoDB JSON of attribute value
+func Marshal(av types.AttributeValue) ([]byte, error) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return json.Marshal(map[string]string{"S": v.Value})
+	case *types.AttributeValueMemberN:
+		return json.Marshal(map[string]string{"N": v.Value})
+	case *types.AttributeValueMemberB:
+		return json.Marshal(map[string][]byte{"B": v.Value})
+	case *types.AttributeValueMemberBOOL:
+		return json.Marshal(map[string]bool{"BOOL": v.Value})
+	case *types.AttributeValueMemberNULL:
+		return json.Marshal(map[string]bool{"NULL": v.Value})
+	case *types.AttributeValueMemberSS:
+		return json.Marshal(map[string][]string{"SS": v.Value})
+	case *types.AttributeValueMemberNS:
+		return json.Marshal(map[string][]string{"NS": v.Value})
+	case *types.AttributeValueMemberBS:
+		return json.Marshal(map[string][][]byte{"BS": v.Value})
+	case *types.AttributeValueMemberL:
+		list := make([]json.RawMessage, len(v.Value))
+		for i, item := range v.Value {
+			raw, err := Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = raw
+		}
+		return json.Marshal(map[string][]json.RawMessage{"L": list})
+	case *types.AttributeValueMemberM:
+		raw, err := Map(v.Value).MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]json.RawMessage{"M": raw})
+	}
+	return nil, fmt.Errorf("%w: %T", ErrAttributeValue, av)
+}
+
+// Unmarshal parses DynamoDB JSON of single attribute value
+func Unmarshal(data []byte) (types.AttributeValue, error) {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw) != 1 {
+		return nil, fmt.Errorf("%w: %s", ErrAttributeValue, data)
+	}
+	var tag string
+	var value json.RawMessage
+	// raw has exactly one entry
+	for tag, value = range raw {
+	}
+	switch tag {
+	case "S":
+		av := &types.AttributeValueMemberS{}
+		return av, json.Unmarshal(value, &av.Value)
+	case "N":
+		av := &types.AttributeValueMemberN{}
+		return av, json.Unmarshal(value, &av.Value)
+	case "B":
+		av := &types.AttributeValueMemberB{}
+		return av, json.Unmarshal(value, &av.Value)
+	case "BOOL":
+		av := &types.AttributeValueMemberBOOL{}
+		return av, json.Unmarshal(value, &av.Value)
+	case "NULL":
+		av := &types.AttributeValueMemberNULL{}
+		return av, json.Unmarshal(value, &av.Value)
+	case "SS":
+		av := &types.AttributeValueMemberSS{}
+		return av, json.Unmarshal(value, &av.Value)
+	case "NS":
+		av := &types.AttributeValueMemberNS{}
+		return av, json.Unmarshal(value, &av.Value)
+	case "BS":
+		av := &types.AttributeValueMemberBS{}
+		return av, json.Unmarshal(value, &av.Value)
+	case "L":
+		items := []json.RawMessage{}
+		if err := json.Unmarshal(value, &items); err != nil {
+			return nil, err
+		}
+		av := &types.AttributeValueMemberL{Value: make([]types.AttributeValue, len(items))}
+		for i, item := range items {
+			v, err := Unmarshal(item)
+			if err != nil {
+				return nil, err
+			}
+			av.Value[i] = v
+		}
+		return av, nil
+	case "M":
+		m := Map{}
+		if err := m.UnmarshalJSON(value); err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: m}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown type %q", ErrAttributeValue, tag)
+}
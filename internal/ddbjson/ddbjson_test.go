@@ -0,0 +1,69 @@
+package ddbjson_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/internal/ddbjson"
+)
+
+func TestMap_RoundTrip(t *testing.T) {
+	in := `{
+		"s": {"S": "abc"},
+		"n": {"N": "1.5"},
+		"b": {"B": "AQI="},
+		"t": {"BOOL": true},
+		"z": {"NULL": true},
+		"ss": {"SS": ["a", "b"]},
+		"ns": {"NS": ["1"]},
+		"bs": {"BS": ["AQ=="]},
+		"l": {"L": [{"S": "x"}, {"N": "2"}]},
+		"m": {"M": {"k": {"S": "v"}}}
+	}`
+	m := ddbjson.Map{}
+	if err := json.Unmarshal([]byte(in), &m); err != nil {
+		t.Fatal(err)
+	}
+	want := ddbjson.Map{
+		"s":  &types.AttributeValueMemberS{Value: "abc"},
+		"n":  &types.AttributeValueMemberN{Value: "1.5"},
+		"b":  &types.AttributeValueMemberB{Value: []byte{1, 2}},
+		"t":  &types.AttributeValueMemberBOOL{Value: true},
+		"z":  &types.AttributeValueMemberNULL{Value: true},
+		"ss": &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		"ns": &types.AttributeValueMemberNS{Value: []string{"1"}},
+		"bs": &types.AttributeValueMemberBS{Value: [][]byte{{1}}},
+		"l": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "x"},
+			&types.AttributeValueMemberN{Value: "2"},
+		}},
+		"m": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"k": &types.AttributeValueMemberS{Value: "v"},
+		}},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("Unmarshal() = %v, want %v", m, want)
+	}
+	out, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again := ddbjson.Map{}
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, want) {
+		t.Errorf("round trip = %v, want %v", again, want)
+	}
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	for _, in := range []string{`{}`, `{"S": "a", "N": "1"}`, `{"X": 1}`} {
+		if _, err := ddbjson.Unmarshal([]byte(in)); !errors.Is(err, ddbjson.ErrAttributeValue) {
+			t.Errorf("Unmarshal(%s) error = %v, want %v", in, err, ddbjson.ErrAttributeValue)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package fields lists the DynamoDB attributes of struct fields the
+// way attributevalue marshals them.
+package fields
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Field is an exported struct field and its attribute name.
+type Field struct {
+	// Attribute name, from dynamodbav tag or the field name
+	Name string
+	// Index path of the field for reflect.Value.FieldByIndex
+	Index []int
+	// Go field names along Index, joined with dots
+	Path string
+	Type reflect.Type
+}
+
+// Attributes returns the fields of struct type t, or of the struct t
+// points to. Fields of embedded structs without a tag name are
+// included, like attributevalue flattens them, and fields tagged "-"
+// are left out.
+func Attributes(t reflect.Type) []Field {
+	rv := []Field{}
+	collect(t, nil, "", &rv)
+	return rv
+}
+
+// AttributeNames returns names of Attributes as a set.
+func AttributeNames(t reflect.Type) map[string]bool {
+	rv := map[string]bool{}
+	for _, f := range Attributes(t) {
+		rv[f.Name] = true
+	}
+	return rv
+}
+
+func collect(t reflect.Type, index []int, path string, rv *[]Field) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("dynamodbav"), ",")
+		if name == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		fieldPath := f.Name
+		if path != "" {
+			fieldPath = path + "." + f.Name
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collect(ft, fieldIndex, fieldPath, rv)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		*rv = append(*rv, Field{Name: name, Index: fieldIndex, Path: fieldPath, Type: f.Type})
+	}
+}
+
+// Reserved returns the attributes that gonetable.Schema.Marshal may
+// add to items of a schema with the indexes, with their descriptions.
+func Reserved(indexes, localIndexes []string) map[string]string {
+	rv := map[string]string{
+		"PK":    "table partition key",
+		"SK":    "table sort key",
+		"_Type": "type id",
+		"_V":    "document version",
+	}
+	for _, idx := range indexes {
+		rv[idx+"PK"] = idx + " partition key"
+		rv[idx+"SK"] = idx + " sort key"
+	}
+	for _, idx := range localIndexes {
+		rv[idx+"SK"] = idx + " sort key"
+	}
+	return rv
+}
@@ -2,6 +2,7 @@ package gonetable
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -19,12 +20,45 @@ type CompositeKey struct {
 	RangeSegments []string
 }
 
+// KeyError tells which part of a composite key couldn't be marshaled.
+// Err is ErrKeyDelimiter or ErrKeyNoSegments, so KeyError can be
+// matched against them with errors.Is.
+//
+// TypeID and Index are filled in by Schema.Marshal, Index is empty
+// for the primary key of the table.
+type KeyError struct {
+	TypeID    string
+	Index     string
+	Attribute string
+	Segment   int
+	Value     string
+	Err       error
+}
+
+func (e *KeyError) Error() string {
+	b := strings.Builder{}
+	if e.TypeID != "" {
+		fmt.Fprintf(&b, "document %q, ", e.TypeID)
+	}
+	if e.Index != "" {
+		fmt.Fprintf(&b, "index %s, ", e.Index)
+	}
+	fmt.Fprintf(&b, "attribute %s", e.Attribute)
+	if e.Segment >= 0 {
+		fmt.Fprintf(&b, ", segment %d %q", e.Segment, e.Value)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *KeyError) Unwrap() error { return e.Err }
+
 func (k CompositeKey) Marshal() (map[string]types.AttributeValue, error) {
-	spk, err := joinKeySegments(k.HashSegments)
+	spk, err := joinKeySegments("PK", k.HashSegments)
 	if err != nil {
 		return nil, err
 	}
-	ssk, err := joinKeySegments(k.RangeSegments)
+	ssk, err := joinKeySegments("SK", k.RangeSegments)
 	if err != nil {
 		return nil, err
 	}
@@ -42,13 +76,13 @@ func (k CompositeKey) Marshal() (map[string]types.AttributeValue, error) {
 	}, nil
 }
 
-func joinKeySegments(segments []string) (string, error) {
+func joinKeySegments(attribute string, segments []string) (string, error) {
 	if len(segments) == 0 {
-		return "", ErrKeyNoSegments
+		return "", &KeyError{Attribute: attribute, Segment: -1, Err: ErrKeyNoSegments}
 	}
-	for _, s := range segments {
+	for i, s := range segments {
 		if strings.Contains(s, KeyDelimiter) {
-			return "", ErrKeyDelimiter
+			return "", &KeyError{Attribute: attribute, Segment: i, Value: s, Err: ErrKeyDelimiter}
 		}
 	}
 	return strings.Join(segments, KeyDelimiter), nil
@@ -0,0 +1,238 @@
+package gonetable
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Placeholders of string fields in evaluated key segments
+var placeholderRE = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// KeyTemplate shows how key segments of a document type are formed.
+// Segments that come from document fields are shown as field names in
+// braces, e.g. {"ed", "{ID}"}. Segments that depend on more than one
+// field list all of them, e.g. "{Year+Month}", and segments that
+// couldn't be evaluated are shown as "{?}".
+//
+// Templates of LSIs have only range segments.
+type KeyTemplate struct {
+	Hash  []string `json:"hash,omitempty"`
+	Range []string `json:"range"`
+}
+
+func (kt KeyTemplate) String() string {
+	if kt.Hash == nil {
+		return strings.Join(kt.Range, KeyDelimiter)
+	}
+	return fmt.Sprintf("%s / %s",
+		strings.Join(kt.Hash, KeyDelimiter),
+		strings.Join(kt.Range, KeyDelimiter))
+}
+
+func (kt KeyTemplate) equal(other KeyTemplate) bool {
+	return reflect.DeepEqual(kt.Hash, other.Hash) && reflect.DeepEqual(kt.Range, other.Range)
+}
+
+// keyTemplates evaluates key methods of document type with placeholder
+// field values. Returns templates by index name, the table key has
+// empty index name.
+//
+// String fields are set to their names in braces. Other fields are
+// detected by changing one field at a time and comparing the results.
+// Segments that changed list also the string fields whose placeholders
+// they contain.
+func (dt *docType) keyTemplates() map[string]KeyTemplate {
+	base := dt.placeholderValue("")
+	baseKeys := dt.evalKeys(base)
+	fields := dt.perturbableFields()
+	changedBy := map[string][]string{}
+	for _, field := range fields {
+		keys := dt.evalKeys(dt.placeholderValue(field))
+		for k, segs := range keys {
+			for i, seg := range segs {
+				if i >= len(baseKeys[k]) || baseKeys[k][i] != seg {
+					id := fmt.Sprintf("%s/%d", k, i)
+					changedBy[id] = append(changedBy[id], field)
+				}
+			}
+		}
+	}
+	templateSegments := func(k string) []string {
+		segs, ok := baseKeys[k]
+		if !ok {
+			return []string{"{?}"}
+		}
+		rv := make([]string, len(segs))
+		for i, seg := range segs {
+			if fields, ok := changedBy[fmt.Sprintf("%s/%d", k, i)]; ok {
+				seg = "{" + strings.Join(dt.segmentFields(seg, fields), "+") + "}"
+			}
+			rv[i] = seg
+		}
+		return rv
+	}
+	rv := map[string]KeyTemplate{}
+	for _, idx := range dt.indeces {
+		rv[idx] = KeyTemplate{
+			Hash:  templateSegments(idx + "/hash"),
+			Range: templateSegments(idx + "/range"),
+		}
+	}
+	for _, idx := range dt.localIndeces {
+		rv[idx] = KeyTemplate{
+			Range: templateSegments(idx + "/range"),
+		}
+	}
+	return rv
+}
+
+// segmentFields returns the string fields whose placeholders are in
+// the segment and the changed fields, in the order of the struct fields
+func (dt *docType) segmentFields(segment string, changed []string) []string {
+	used := map[string]bool{}
+	for _, field := range changed {
+		used[field] = true
+	}
+	strs := map[string]bool{}
+	for _, m := range placeholderRE.FindAllStringSubmatch(segment, -1) {
+		strs[m[1]] = true
+	}
+	rv := []string{}
+	eachField(reflect.ValueOf(newDocument(dt.goType)).Elem(), func(name string, fv reflect.Value) {
+		if used[name] || fv.Kind() == reflect.String && strs[name] {
+			rv = append(rv, name)
+		}
+	})
+	return rv
+}
+
+// placeholderValue returns new value of document type with string
+// fields set to "{FieldName}". If perturb names a non-string field,
+// that field is set to a non-zero value.
+func (dt *docType) placeholderValue(perturb string) reflect.Value {
+	ptr := reflect.ValueOf(newDocument(dt.goType))
+	eachField(ptr.Elem(), func(name string, fv reflect.Value) {
+		switch fv.Kind() {
+		case reflect.String:
+			fv.SetString("{" + name + "}")
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if name == perturb {
+				fv.SetInt(7)
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if name == perturb {
+				fv.SetUint(7)
+			}
+		case reflect.Float32, reflect.Float64:
+			if name == perturb {
+				fv.SetFloat(7.5)
+			}
+		case reflect.Bool:
+			if name == perturb {
+				fv.SetBool(true)
+			}
+		}
+	})
+	if dt.goType.Kind() == reflect.Pointer {
+		return ptr
+	}
+	return ptr.Elem()
+}
+
+// perturbableFields lists exported non-string scalar fields
+func (dt *docType) perturbableFields() []string {
+	rv := []string{}
+	eachField(reflect.ValueOf(newDocument(dt.goType)).Elem(), func(name string, fv reflect.Value) {
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Bool:
+			rv = append(rv, name)
+		}
+	})
+	return rv
+}
+
+// eachField calls fn for exported fields of struct v, including the
+// fields of embedded structs.
+func eachField(v reflect.Value, fn func(name string, fv reflect.Value)) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			eachField(v.Field(i), fn)
+			continue
+		}
+		if f.IsExported() {
+			fn(f.Name, v.Field(i))
+		}
+	}
+}
+
+// evalKeys calls key methods of the document and returns the segments
+// by "<index>/hash" and "<index>/range". Methods that panic are left
+// out.
+func (dt *docType) evalKeys(doc reflect.Value) map[string][]string {
+	rv := map[string][]string{}
+	for _, idx := range dt.indeces {
+		if key, ok := callKeyMethod[CompositeKey](doc, fmt.Sprintf("Gonetable_%sKey", idx)); ok {
+			rv[idx+"/hash"] = key.HashSegments
+			rv[idx+"/range"] = key.RangeSegments
+		}
+	}
+	for _, idx := range dt.localIndeces {
+		if segments, ok := callKeyMethod[[]string](doc, fmt.Sprintf("Gonetable_%sSortKey", idx)); ok {
+			rv[idx+"/range"] = segments
+		}
+	}
+	return rv
+}
+
+func callKeyMethod[T any](doc reflect.Value, name string) (rv T, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	out := doc.MethodByName(name).Call([]reflect.Value{})
+	return out[0].Interface().(T), true
+}
+
+// KeyDescription is the key template of a document type in the table
+// or in a secondary index.
+type KeyDescription struct {
+	TypeID string
+	// Index name, empty for the table key
+	Index    string
+	Template KeyTemplate
+}
+
+func (kd KeyDescription) String() string {
+	if kd.Index == "" {
+		return fmt.Sprintf("%s: %s", kd.TypeID, kd.Template)
+	}
+	return fmt.Sprintf("%s %s: %s", kd.TypeID, kd.Index, kd.Template)
+}
+
+// DescribeKeys evaluates key methods of the sample documents with
+// placeholder field values and returns the inferred key templates.
+// Descriptions are sorted by type id, table key first and then the
+// indeces by name.
+func (s *Schema) DescribeKeys() []KeyDescription {
+	rv := []KeyDescription{}
+	for _, typeID := range sortedKeys(s.docTypes) {
+		templates := s.docTypes[typeID].keyTemplates()
+		for _, idx := range sortedKeys(templates) {
+			rv = append(rv, KeyDescription{
+				TypeID:   typeID,
+				Index:    idx,
+				Template: templates[idx],
+			})
+		}
+	}
+	return rv
+}
@@ -0,0 +1,315 @@
+package memddb
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/ddbexpr"
+)
+
+const (
+	maxBatchGetKeys   = 100
+	maxBatchWrites    = 25
+	maxTransactItems  = 100
+	duplicateItemsMsg = "Transaction request cannot include multiple operations on one item"
+)
+
+func (c *Client) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := 0
+	for _, ka := range params.RequestItems {
+		count += len(ka.Keys)
+	}
+	if count > maxBatchGetKeys {
+		return nil, validationError("Too many items requested for the BatchGetItem call")
+	}
+	out := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]types.AttributeValue{},
+		UnprocessedKeys: map[string]types.KeysAndAttributes{},
+	}
+	for name, ka := range params.RequestItems {
+		t, err := c.table(aws.String(name))
+		if err != nil {
+			return nil, err
+		}
+		paths, err := parseProjection(ka.ProjectionExpression)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPlaceholders(placeholders(ka.ExpressionAttributeNames, nil), paths); err != nil {
+			return nil, err
+		}
+		seen := map[string]bool{}
+		items := []map[string]types.AttributeValue{}
+		for _, key := range ka.Keys {
+			if err := t.validateKey(key); err != nil {
+				return nil, err
+			}
+			encoded := encodeKey(key, t.key.attributes())
+			if seen[encoded] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			seen[encoded] = true
+			item := t.get(key)
+			if item == nil {
+				continue
+			}
+			projected, err := projectItem(item, paths, ka.ExpressionAttributeNames)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, projected)
+		}
+		out.Responses[name] = items
+	}
+	return out, nil
+}
+
+func (c *Client) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := 0
+	for _, requests := range params.RequestItems {
+		count += len(requests)
+	}
+	if count > maxBatchWrites {
+		return nil, validationError("1 validation error detected: Value at 'requestItems' failed to satisfy constraint: Map value must satisfy constraint: [Member must have length less than or equal to 25, Member must have length greater than or equal to 1]")
+	}
+	// validate everything before writing, like DynamoDB does
+	type write struct {
+		t    *table
+		key  map[string]types.AttributeValue
+		item map[string]types.AttributeValue
+	}
+	writes := []write{}
+	seen := map[string]bool{}
+	for name, requests := range params.RequestItems {
+		t, err := c.table(aws.String(name))
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range requests {
+			w := write{t: t}
+			switch {
+			case r.PutRequest != nil:
+				if err := t.validateItem(r.PutRequest.Item); err != nil {
+					return nil, err
+				}
+				w.key, w.item = t.itemKey(r.PutRequest.Item), r.PutRequest.Item
+			case r.DeleteRequest != nil:
+				if err := t.validateKey(r.DeleteRequest.Key); err != nil {
+					return nil, err
+				}
+				w.key = r.DeleteRequest.Key
+			default:
+				return nil, validationError("Supplied AttributeValue has more than one datatypes set, must contain exactly one of the supported datatypes")
+			}
+			id := name + "\x00" + encodeKey(w.key, t.key.attributes())
+			if seen[id] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			seen[id] = true
+			writes = append(writes, w)
+		}
+	}
+	for _, w := range writes {
+		if w.item != nil {
+			w.t.put(ddbexpr.CopyItem(w.item))
+		} else {
+			w.t.delete(w.key)
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{}}, nil
+}
+
+func (c *Client) TransactGetItems(ctx context.Context, params *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(params.TransactItems) > maxTransactItems {
+		return nil, validationError("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to 100")
+	}
+	out := &dynamodb.TransactGetItemsOutput{}
+	for _, ti := range params.TransactItems {
+		get := ti.Get
+		t, err := c.table(get.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.validateKey(get.Key); err != nil {
+			return nil, err
+		}
+		paths, err := parseProjection(get.ProjectionExpression)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPlaceholders(placeholders(get.ExpressionAttributeNames, nil), paths); err != nil {
+			return nil, err
+		}
+		response := types.ItemResponse{}
+		if item := t.get(get.Key); item != nil {
+			if response.Item, err = projectItem(item, paths, get.ExpressionAttributeNames); err != nil {
+				return nil, err
+			}
+		}
+		out.Responses = append(out.Responses, response)
+	}
+	return out, nil
+}
+
+// transactWrite is a prepared write of a transaction. item is nil for
+// deletes and condition checks.
+type transactWrite struct {
+	t      *table
+	key    map[string]types.AttributeValue
+	item   map[string]types.AttributeValue
+	delete bool
+}
+
+// TransactWriteItems checks conditions of all items before writing
+// any of them. If a condition fails, it returns
+// TransactionCanceledException with a cancellation reason for each
+// item.
+func (c *Client) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(params.TransactItems) > maxTransactItems {
+		return nil, validationError("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to 100")
+	}
+	writes := []transactWrite{}
+	reasons := []types.CancellationReason{}
+	failed := false
+	seen := map[string]bool{}
+	for _, ti := range params.TransactItems {
+		w, err := c.prepareTransactWrite(ti)
+		reason := types.CancellationReason{Code: aws.String("None")}
+		var conditionErr *types.ConditionalCheckFailedException
+		switch {
+		case errors.As(err, &conditionErr):
+			failed = true
+			reason = types.CancellationReason{
+				Code:    aws.String("ConditionalCheckFailed"),
+				Message: aws.String("The conditional request failed"),
+			}
+			if returnOld(ti) {
+				reason.Item = ddbexpr.CopyItem(w.t.get(w.key))
+			}
+		case err != nil:
+			return nil, err
+		}
+		id := aws.ToString(w.t.desc.TableName) + "\x00" + encodeKey(w.key, w.t.key.attributes())
+		if seen[id] {
+			return nil, validationError(duplicateItemsMsg)
+		}
+		seen[id] = true
+		reasons = append(reasons, reason)
+		writes = append(writes, w)
+	}
+	if failed {
+		codes := make([]string, len(reasons))
+		for i, r := range reasons {
+			codes[i] = *r.Code
+		}
+		return nil, &types.TransactionCanceledException{
+			Message:             aws.String("Transaction cancelled, please refer cancellation reasons for specific reasons [" + strings.Join(codes, ", ") + "]"),
+			CancellationReasons: reasons,
+		}
+	}
+	for _, w := range writes {
+		switch {
+		case w.delete:
+			w.t.delete(w.key)
+		case w.item != nil:
+			w.t.put(w.item)
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// prepareTransactWrite validates the item and checks its condition. The
+// returned write has table and key also when the condition fails.
+func (c *Client) prepareTransactWrite(ti types.TransactWriteItem) (transactWrite, error) {
+	var (
+		tableName *string
+		key       map[string]types.AttributeValue
+		item      map[string]types.AttributeValue
+		condition *string
+		ph        ddbexpr.Placeholders
+	)
+	switch {
+	case ti.ConditionCheck != nil:
+		cc := ti.ConditionCheck
+		tableName, key, condition = cc.TableName, cc.Key, cc.ConditionExpression
+		ph = placeholders(cc.ExpressionAttributeNames, cc.ExpressionAttributeValues)
+	case ti.Put != nil:
+		put := ti.Put
+		tableName, item, condition = put.TableName, put.Item, put.ConditionExpression
+		ph = placeholders(put.ExpressionAttributeNames, put.ExpressionAttributeValues)
+	case ti.Delete != nil:
+		del := ti.Delete
+		tableName, key, condition = del.TableName, del.Key, del.ConditionExpression
+		ph = placeholders(del.ExpressionAttributeNames, del.ExpressionAttributeValues)
+	case ti.Update != nil:
+		update := ti.Update
+		tableName, key, condition = update.TableName, update.Key, update.ConditionExpression
+		ph = placeholders(update.ExpressionAttributeNames, update.ExpressionAttributeValues)
+	default:
+		return transactWrite{}, validationError("TransactItems can only contain one of Check, Put, Update or Delete")
+	}
+	t, err := c.table(tableName)
+	if err != nil {
+		return transactWrite{}, err
+	}
+	w := transactWrite{t: t, key: key, delete: ti.Delete != nil}
+	if item != nil {
+		if err := t.validateItem(item); err != nil {
+			return w, err
+		}
+		w.key = t.itemKey(item)
+		w.item = ddbexpr.CopyItem(item)
+	} else if err := t.validateKey(key); err != nil {
+		return w, err
+	}
+	if ti.ConditionCheck != nil && condition == nil {
+		return w, validationError("The ConditionExpression parameter must be specified for ConditionCheck")
+	}
+	cond, err := parseCondition("ConditionExpression", condition)
+	if err != nil {
+		return w, err
+	}
+	var update *ddbexpr.Update
+	if ti.Update != nil {
+		if update, err = parseUpdate(ti.Update.UpdateExpression); err != nil {
+			return w, err
+		}
+	}
+	if err := checkPlaceholders(ph, update, cond); err != nil {
+		return w, err
+	}
+	old := t.get(w.key)
+	if ti.Update != nil {
+		if w.item, err = t.updated(w.key, old, update, ph); err != nil {
+			return w, err
+		}
+	}
+	return w, checkCondition(cond, old, ph)
+}
+
+func returnOld(ti types.TransactWriteItem) bool {
+	var rv types.ReturnValuesOnConditionCheckFailure
+	switch {
+	case ti.ConditionCheck != nil:
+		rv = ti.ConditionCheck.ReturnValuesOnConditionCheckFailure
+	case ti.Put != nil:
+		rv = ti.Put.ReturnValuesOnConditionCheckFailure
+	case ti.Delete != nil:
+		rv = ti.Delete.ReturnValuesOnConditionCheckFailure
+	case ti.Update != nil:
+		rv = ti.Update.ReturnValuesOnConditionCheckFailure
+	}
+	return rv == types.ReturnValuesOnConditionCheckFailureAllOld
+}
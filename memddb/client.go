@@ -0,0 +1,319 @@
+// Package memddb is an in-memory fake of the DynamoDB API for tests.
+//
+// Client implements the operations that gonetable uses: table
+// management, item reads and writes, queries and scans of tables and
+// secondary indexes, and batch and transaction operations. Condition,
+// filter, key condition, update and projection expressions are
+// evaluated with ddbexpr. Errors have the same types and messages as
+// DynamoDB errors where practical, so code that checks them, e.g. with
+// errors.As(err, &types.ConditionalCheckFailedException{}), works the
+// same.
+//
+// Capacity, billing, streams and TTL are not simulated, tables and
+// indexes are ACTIVE immediately, and reads are always consistent.
+package memddb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// Client is an in-memory DynamoDB. It is safe for concurrent use.
+type Client struct {
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+// New returns client without tables.
+func New() *Client {
+	return &Client{tables: map[string]*table{}}
+}
+
+func validationError(format string, args ...interface{}) error {
+	return &smithy.GenericAPIError{
+		Code:    "ValidationException",
+		Message: fmt.Sprintf(format, args...),
+		Fault:   smithy.FaultClient,
+	}
+}
+
+func notFound() error {
+	return &types.ResourceNotFoundException{Message: aws.String("Requested resource not found")}
+}
+
+func conditionFailed() error {
+	return &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+}
+
+func (c *Client) table(name *string) (*table, error) {
+	t, ok := c.tables[aws.ToString(name)]
+	if !ok {
+		return nil, notFound()
+	}
+	return t, nil
+}
+
+func (t *table) index(name *string) (*index, error) {
+	if name == nil {
+		return nil, nil
+	}
+	idx, ok := t.indexes[*name]
+	if !ok {
+		return nil, validationError("The table does not have the specified index: %s", *name)
+	}
+	return idx, nil
+}
+
+func (c *Client) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := aws.ToString(params.TableName)
+	if name == "" {
+		return nil, validationError("1 validation error detected: Value null at 'tableName' failed to satisfy constraint: Member must not be null")
+	}
+	if _, exists := c.tables[name]; exists {
+		return nil, &types.ResourceInUseException{Message: aws.String("Table already exists: " + name)}
+	}
+	t := &table{
+		key:     newKeySchema(params.KeySchema),
+		indexes: map[string]*index{},
+		items:   map[string]map[string]types.AttributeValue{},
+		desc: types.TableDescription{
+			TableName:            aws.String(name),
+			TableArn:             aws.String("arn:aws:dynamodb:local:000000000000:table/" + name),
+			TableStatus:          types.TableStatusActive,
+			CreationDateTime:     aws.Time(time.Now()),
+			KeySchema:            params.KeySchema,
+			AttributeDefinitions: params.AttributeDefinitions,
+			StreamSpecification:  params.StreamSpecification,
+		},
+	}
+	if t.key.hash == "" {
+		return nil, validationError("1 validation error detected: Value null at 'keySchema' failed to satisfy constraint: Member must not be null")
+	}
+	billingMode := params.BillingMode
+	if billingMode == "" {
+		billingMode = types.BillingModeProvisioned
+	}
+	t.desc.BillingModeSummary = &types.BillingModeSummary{BillingMode: billingMode}
+	if params.ProvisionedThroughput != nil {
+		t.desc.ProvisionedThroughput = throughputDescription(params.ProvisionedThroughput)
+	}
+	for _, gsi := range params.GlobalSecondaryIndexes {
+		if err := t.addGlobalIndex(gsi); err != nil {
+			return nil, err
+		}
+	}
+	for _, lsi := range params.LocalSecondaryIndexes {
+		if lsi.Projection == nil {
+			return nil, validationError("1 validation error detected: Value null at 'localSecondaryIndexes.%s.member.projection' failed to satisfy constraint: Member must not be null", aws.ToString(lsi.IndexName))
+		}
+		idx := &index{
+			name:       aws.ToString(lsi.IndexName),
+			key:        newKeySchema(lsi.KeySchema),
+			projection: *lsi.Projection,
+			local:      true,
+		}
+		t.indexes[idx.name] = idx
+		t.desc.LocalSecondaryIndexes = append(t.desc.LocalSecondaryIndexes, types.LocalSecondaryIndexDescription{
+			IndexName:  lsi.IndexName,
+			KeySchema:  lsi.KeySchema,
+			Projection: lsi.Projection,
+		})
+	}
+	if err := t.validateAttributeDefinitions(); err != nil {
+		return nil, err
+	}
+	c.tables[name] = t
+	return &dynamodb.CreateTableOutput{TableDescription: t.description()}, nil
+}
+
+func (t *table) addGlobalIndex(gsi types.GlobalSecondaryIndex) error {
+	name := aws.ToString(gsi.IndexName)
+	if _, exists := t.indexes[name]; exists {
+		return validationError("One or more parameter values were invalid: Duplicate index name: %s", name)
+	}
+	if gsi.Projection == nil {
+		return validationError("1 validation error detected: Value null at 'globalSecondaryIndexes.%s.member.projection' failed to satisfy constraint: Member must not be null", name)
+	}
+	t.indexes[name] = &index{
+		name:       name,
+		key:        newKeySchema(gsi.KeySchema),
+		projection: *gsi.Projection,
+	}
+	desc := types.GlobalSecondaryIndexDescription{
+		IndexName:   gsi.IndexName,
+		IndexArn:    aws.String(aws.ToString(t.desc.TableArn) + "/index/" + name),
+		IndexStatus: types.IndexStatusActive,
+		KeySchema:   gsi.KeySchema,
+		Projection:  gsi.Projection,
+	}
+	if gsi.ProvisionedThroughput != nil {
+		desc.ProvisionedThroughput = throughputDescription(gsi.ProvisionedThroughput)
+	}
+	t.desc.GlobalSecondaryIndexes = append(t.desc.GlobalSecondaryIndexes, desc)
+	return nil
+}
+
+// validateAttributeDefinitions checks that key attributes of the table
+// and indexes are defined
+func (t *table) validateAttributeDefinitions() error {
+	attrs := t.key.attributes()
+	for _, name := range sortedIndexNames(t.indexes) {
+		attrs = append(attrs, t.indexes[name].key.attributes()...)
+	}
+	for _, attr := range attrs {
+		if t.attributeType(attr) == "" {
+			return validationError("One or more parameter values were invalid: Some index key attributes are not defined in AttributeDefinitions. Keys: [%s], AttributeDefinitions: %s", attr, definedAttributes(t.desc.AttributeDefinitions))
+		}
+	}
+	return nil
+}
+
+func definedAttributes(ads []types.AttributeDefinition) string {
+	names := []string{}
+	for _, ad := range ads {
+		names = append(names, aws.ToString(ad.AttributeName))
+	}
+	return fmt.Sprint(names)
+}
+
+func throughputDescription(pt *types.ProvisionedThroughput) *types.ProvisionedThroughputDescription {
+	return &types.ProvisionedThroughputDescription{
+		ReadCapacityUnits:  pt.ReadCapacityUnits,
+		WriteCapacityUnits: pt.WriteCapacityUnits,
+	}
+}
+
+// description returns copy of table description with current item
+// count
+func (t *table) description() *types.TableDescription {
+	desc := t.desc
+	desc.ItemCount = int64(len(t.items))
+	desc.GlobalSecondaryIndexes = append([]types.GlobalSecondaryIndexDescription{}, t.desc.GlobalSecondaryIndexes...)
+	for i, gsi := range desc.GlobalSecondaryIndexes {
+		count := int64(0)
+		for _, item := range t.items {
+			if _, ok := t.project(t.indexes[aws.ToString(gsi.IndexName)], item); ok {
+				count++
+			}
+		}
+		desc.GlobalSecondaryIndexes[i].ItemCount = count
+	}
+	if len(desc.GlobalSecondaryIndexes) == 0 {
+		desc.GlobalSecondaryIndexes = nil
+	}
+	return &desc
+}
+
+func (c *Client) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.DescribeTableOutput{Table: t.description()}, nil
+}
+
+func (c *Client) DeleteTable(ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	delete(c.tables, aws.ToString(params.TableName))
+	desc := t.description()
+	desc.TableStatus = types.TableStatusDeleting
+	return &dynamodb.DeleteTableOutput{TableDescription: desc}, nil
+}
+
+func (c *Client) ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := []string{}
+	for name := range c.tables {
+		if params.ExclusiveStartTableName == nil || name > *params.ExclusiveStartTableName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	out := &dynamodb.ListTablesOutput{}
+	if params.Limit != nil && int(*params.Limit) < len(names) {
+		names = names[:*params.Limit]
+		out.LastEvaluatedTableName = aws.String(names[len(names)-1])
+	}
+	out.TableNames = names
+	return out, nil
+}
+
+// UpdateTable creates and deletes global secondary indexes. New indexes
+// are ACTIVE immediately. Other changes are accepted and ignored,
+// except that the billing mode is updated.
+func (c *Client) UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	// work on a copy so that failed update doesn't change the table
+	updated := *t
+	updated.indexes = map[string]*index{}
+	for name, idx := range t.indexes {
+		updated.indexes[name] = idx
+	}
+	for _, ad := range params.AttributeDefinitions {
+		if updated.attributeType(aws.ToString(ad.AttributeName)) == "" {
+			updated.desc.AttributeDefinitions = append(updated.desc.AttributeDefinitions, ad)
+		}
+	}
+	if params.BillingMode != "" {
+		updated.desc.BillingModeSummary = &types.BillingModeSummary{BillingMode: params.BillingMode}
+	}
+	for _, u := range params.GlobalSecondaryIndexUpdates {
+		switch {
+		case u.Create != nil:
+			name := aws.ToString(u.Create.IndexName)
+			if _, exists := updated.indexes[name]; exists {
+				return nil, validationError("One or more parameter values were invalid: Attempting to create an index which already exists")
+			}
+			err := updated.addGlobalIndex(types.GlobalSecondaryIndex{
+				IndexName:             u.Create.IndexName,
+				KeySchema:             u.Create.KeySchema,
+				Projection:            u.Create.Projection,
+				ProvisionedThroughput: u.Create.ProvisionedThroughput,
+			})
+			if err != nil {
+				return nil, err
+			}
+		case u.Delete != nil:
+			name := aws.ToString(u.Delete.IndexName)
+			if idx, exists := updated.indexes[name]; !exists || idx.local {
+				return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found: Index: " + name)}
+			}
+			delete(updated.indexes, name)
+			gsis := []types.GlobalSecondaryIndexDescription{}
+			for _, gsi := range updated.desc.GlobalSecondaryIndexes {
+				if aws.ToString(gsi.IndexName) != name {
+					gsis = append(gsis, gsi)
+				}
+			}
+			updated.desc.GlobalSecondaryIndexes = gsis
+		}
+	}
+	if err := updated.validateAttributeDefinitions(); err != nil {
+		return nil, err
+	}
+	*t = updated
+	return &dynamodb.UpdateTableOutput{TableDescription: t.description()}, nil
+}
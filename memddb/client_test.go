@@ -0,0 +1,277 @@
+package memddb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/memddb"
+)
+
+var _ gonetable.Client = (*memddb.Client)(nil)
+
+type Order struct {
+	ID       string
+	Customer string
+	Day      string
+	Total    int
+}
+
+func (o *Order) Gonetable_TypeID() string { return "order" }
+func (o *Order) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"order", o.ID},
+		RangeSegments: []string{"order"},
+	}
+}
+func (o *Order) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"customer", o.Customer},
+		RangeSegments: []string{"day", o.Day, o.ID},
+	}
+}
+
+func mustMarshal(in interface{}) types.AttributeValue {
+	v, err := attributevalue.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func newTable(t *testing.T, opts ...gonetable.SchemaOption) *gonetable.Table {
+	s, err := gonetable.NewSchema([]gonetable.Document{&Order{}}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := gonetable.NewTable("test", s, memddb.New())
+	if _, err := table.Ensure(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestClient_Table(t *testing.T) {
+	ctx := context.Background()
+	table := newTable(t, gonetable.WithProjection("GSI1", gonetable.KeysOnlyProjection()))
+	orders := []*Order{
+		{ID: "1", Customer: "a", Day: "2022-01-01", Total: 10},
+		{ID: "2", Customer: "a", Day: "2022-01-02", Total: 20},
+		{ID: "3", Customer: "a", Day: "2022-02-01", Total: 30},
+		{ID: "4", Customer: "b", Day: "2022-01-01", Total: 40},
+	}
+	for _, o := range orders {
+		if err := table.Put(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := table.Get(ctx, orders[1].Gonetable_Key())
+	if err != nil || !reflect.DeepEqual(got, orders[1]) {
+		t.Errorf("Get() = %v, %v", got, err)
+	}
+
+	docs, err := table.Query(ctx, gonetable.Query{
+		Index:        "GSI1",
+		HashSegments: []string{"customer", "a"},
+		RangePrefix:  []string{"day", "2022-01"},
+		Descending:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []gonetable.Document{orders[1], orders[0]}; !reflect.DeepEqual(docs, want) {
+		t.Errorf("Query() = %v, want %v", docs, want)
+	}
+
+	if err := table.Delete(ctx, orders[0].Gonetable_Key()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Get(ctx, orders[0].Gonetable_Key()); !errors.Is(err, gonetable.ErrNotFound) {
+		t.Errorf("error = %v, want %v", err, gonetable.ErrNotFound)
+	}
+}
+
+func TestClient_Query(t *testing.T) {
+	ctx := context.Background()
+	table := newTable(t)
+	for _, o := range []*Order{
+		{ID: "1", Customer: "a", Day: "1", Total: 10},
+		{ID: "2", Customer: "a", Day: "2", Total: 20},
+		{ID: "3", Customer: "a", Day: "3", Total: 30},
+		{ID: "4", Customer: "a", Day: "4", Total: 40},
+	} {
+		if err := table.Put(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input := &dynamodb.QueryInput{
+		TableName:                aws.String("test"),
+		IndexName:                aws.String("GSI1"),
+		KeyConditionExpression:   aws.String("GSI1PK = :pk AND GSI1SK BETWEEN :low AND :high"),
+		FilterExpression:         aws.String("#t <> :skip"),
+		ProjectionExpression:     aws.String("ID"),
+		ExpressionAttributeNames: map[string]string{"#t": "Total"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":   mustMarshal("customer#a"),
+			":low":  mustMarshal("day#2"),
+			":high": mustMarshal("day#9"),
+			":skip": mustMarshal(30),
+		},
+		Limit: aws.Int32(2),
+	}
+	ids := []string{}
+	pages := 0
+	for {
+		out, err := table.Client().Query(ctx, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+		for _, item := range out.Items {
+			if len(item) != 1 {
+				t.Errorf("projected item = %v", item)
+			}
+			ids = append(ids, item["ID"].(*types.AttributeValueMemberS).Value)
+		}
+		if out.LastEvaluatedKey == nil {
+			break
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+	if pages != 2 || !reflect.DeepEqual(ids, []string{"2", "4"}) {
+		t.Errorf("got %v in %d pages", ids, pages)
+	}
+
+	invalid := []struct {
+		keyCondition string
+		filter       string
+		want         string
+	}{
+		{"GSI1SK = :low", "#t <> :skip", "Query condition missed key schema element: GSI1PK"},
+		{"GSI1PK = :pk AND GSI1SK BETWEEN :low AND :high", "#t <> :skip OR GSI1SK <> :skip", "Filter Expression can only contain non-primary key attributes: Primary key attribute: GSI1SK"},
+		{"GSI1PK = :pk AND GSI1SK BETWEEN :low AND :high", "Total <> :skip", "Invalid FilterExpression: Attribute name is a reserved keyword; reserved keyword: Total"},
+		{"GSI1PK = :pk AND GSI1SK > :low", "#t <> :skip", "Value provided in ExpressionAttributeValues unused in expressions: keys: {:high}"},
+	}
+	for _, tt := range invalid {
+		input.KeyConditionExpression = aws.String(tt.keyCondition)
+		input.FilterExpression = aws.String(tt.filter)
+		var apiErr smithy.APIError
+		if _, err := table.Client().Query(ctx, input); !errors.As(err, &apiErr) || apiErr.ErrorMessage() != tt.want {
+			t.Errorf("Query(%q, %q) error = %v, want %v", tt.keyCondition, tt.filter, err, tt.want)
+		}
+	}
+}
+
+func TestClient_ConditionsAndUpdates(t *testing.T) {
+	ctx := context.Background()
+	client := newTable(t).Client()
+	key := map[string]types.AttributeValue{"PK": mustMarshal("x"), "SK": mustMarshal("y")}
+	item := map[string]types.AttributeValue{"PK": mustMarshal("x"), "SK": mustMarshal("y"), "N": mustMarshal(1)}
+	put := &dynamodb.PutItemInput{
+		TableName:           aws.String("test"),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	}
+	if _, err := client.PutItem(ctx, put); err != nil {
+		t.Fatal(err)
+	}
+	var conditionErr *types.ConditionalCheckFailedException
+	if _, err := client.PutItem(ctx, put); !errors.As(err, &conditionErr) {
+		t.Errorf("error = %v, want ConditionalCheckFailedException", err)
+	}
+
+	out, err := client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String("test"),
+		Key:                       key,
+		UpdateExpression:          aws.String("SET #m = if_not_exists(#m, :m) REMOVE N"),
+		ConditionExpression:       aws.String("N = :one"),
+		ExpressionAttributeNames:  map[string]string{"#m": "M"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":one": mustMarshal(1), ":m": mustMarshal("m")},
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]types.AttributeValue{"PK": mustMarshal("x"), "SK": mustMarshal("y"), "M": mustMarshal("m")}
+	if !reflect.DeepEqual(out.Attributes, want) {
+		t.Errorf("updated = %v, want %v", out.Attributes, want)
+	}
+
+	_, err = client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName: aws.String("test"),
+				Item:      map[string]types.AttributeValue{"PK": mustMarshal("new"), "SK": mustMarshal("y")},
+			}},
+			{Delete: &types.Delete{
+				TableName:           aws.String("test"),
+				Key:                 key,
+				ConditionExpression: aws.String("attribute_exists(N)"),
+			}},
+		},
+	})
+	var cancelErr *types.TransactionCanceledException
+	if !errors.As(err, &cancelErr) || *cancelErr.CancellationReasons[1].Code != "ConditionalCheckFailed" {
+		t.Fatalf("error = %v, want TransactionCanceledException", err)
+	}
+	scan, err := client.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("test"), Select: types.SelectCount})
+	if err != nil || scan.Count != 1 {
+		t.Errorf("after cancelled transaction count = %d, %v", scan.Count, err)
+	}
+}
+
+func TestClient_Validation(t *testing.T) {
+	ctx := context.Background()
+	client := newTable(t).Client()
+	isValidationError := func(err error) bool {
+		var apiErr smithy.APIError
+		return errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationException"
+	}
+
+	_, err := client.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("test"), Limit: aws.Int32(0)})
+	if !isValidationError(err) {
+		t.Errorf("Scan with zero limit error = %v, want ValidationException", err)
+	}
+	_, err = client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String("test"),
+		KeyConditionExpression:    aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{":pk": mustMarshal("x")},
+		Limit:                     aws.Int32(0),
+	})
+	if !isValidationError(err) {
+		t.Errorf("Query with zero limit error = %v, want ValidationException", err)
+	}
+
+	keySchema := []types.KeySchemaElement{{AttributeName: aws.String("PK"), KeyType: types.KeyTypeHash}}
+	_, err = client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:            aws.String("noprojection"),
+		BillingMode:          types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("PK"), AttributeType: types.ScalarAttributeTypeS}},
+		KeySchema:            keySchema,
+		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+			{IndexName: aws.String("GSI1"), KeySchema: keySchema},
+		},
+	})
+	if !isValidationError(err) {
+		t.Errorf("CreateTable with GSI without projection error = %v, want ValidationException", err)
+	}
+	_, err = client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:            aws.String("noprojection"),
+		BillingMode:          types.BillingModePayPerRequest,
+		AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("PK"), AttributeType: types.ScalarAttributeTypeS}},
+		KeySchema:            keySchema,
+		LocalSecondaryIndexes: []types.LocalSecondaryIndex{
+			{IndexName: aws.String("LSI1"), KeySchema: keySchema},
+		},
+	})
+	if !isValidationError(err) {
+		t.Errorf("CreateTable with LSI without projection error = %v, want ValidationException", err)
+	}
+}
@@ -0,0 +1,32 @@
+package memddb_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/memddb"
+)
+
+func ExampleNew() {
+	schema, err := gonetable.NewSchema([]gonetable.Document{&Order{}})
+	if err != nil {
+		panic(err)
+	}
+	table := gonetable.NewTable("orders", schema, memddb.New())
+	if _, err := table.Ensure(context.Background()); err != nil {
+		panic(err)
+	}
+	if err := table.Put(context.Background(), &Order{ID: "1", Customer: "a", Day: "2022-01-01"}); err != nil {
+		panic(err)
+	}
+	docs, err := table.Query(context.Background(), gonetable.Query{
+		Index:        "GSI1",
+		HashSegments: []string{"customer", "a"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(docs[0].(*Order).ID)
+	// Output: 1
+}
@@ -0,0 +1,305 @@
+package memddb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/ddbexpr"
+)
+
+// expressionError returns ValidationException for invalid expression
+// parameter
+func expressionError(param string, err error) error {
+	return validationError("Invalid %s: %s", param, err)
+}
+
+func parseCondition(param string, expr *string) (ddbexpr.Condition, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	c, err := ddbexpr.ParseCondition(*expr)
+	if err != nil {
+		return nil, expressionError(param, err)
+	}
+	return c, nil
+}
+
+func parseUpdate(expr *string) (*ddbexpr.Update, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	u, err := ddbexpr.ParseUpdate(*expr)
+	if err != nil {
+		return nil, expressionError("UpdateExpression", err)
+	}
+	return u, nil
+}
+
+func parseProjection(expr *string) (ddbexpr.Projection, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	paths, err := ddbexpr.ParseProjection(*expr)
+	if err != nil {
+		return nil, expressionError("ProjectionExpression", err)
+	}
+	return paths, nil
+}
+
+// checkPlaceholders returns ValidationException if placeholders are
+// undefined or unused in the expressions of a request
+func checkPlaceholders(ph ddbexpr.Placeholders, exprs ...ddbexpr.Expression) error {
+	if err := ddbexpr.CheckPlaceholders(ph, exprs...); err != nil {
+		return validationError("%s", err)
+	}
+	return nil
+}
+
+// checkCondition returns ConditionalCheckFailedException if item
+// doesn't satisfy the condition
+func checkCondition(c ddbexpr.Condition, item map[string]types.AttributeValue, ph ddbexpr.Placeholders) error {
+	if c == nil {
+		return nil
+	}
+	if item == nil {
+		item = map[string]types.AttributeValue{}
+	}
+	ok, err := ddbexpr.Evaluate(c, item, ph)
+	if err != nil {
+		return expressionError("ConditionExpression", err)
+	}
+	if !ok {
+		return conditionFailed()
+	}
+	return nil
+}
+
+// projectItem applies projection expression to copy of item
+func projectItem(item map[string]types.AttributeValue, paths ddbexpr.Projection, names map[string]string) (map[string]types.AttributeValue, error) {
+	if paths == nil {
+		return ddbexpr.CopyItem(item), nil
+	}
+	projected, err := ddbexpr.Project(item, paths, names)
+	if err != nil {
+		return nil, expressionError("ProjectionExpression", err)
+	}
+	return projected, nil
+}
+
+func (c *Client) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.validateKey(params.Key); err != nil {
+		return nil, err
+	}
+	paths, err := parseProjection(params.ProjectionExpression)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPlaceholders(placeholders(params.ExpressionAttributeNames, nil), paths); err != nil {
+		return nil, err
+	}
+	item := t.get(params.Key)
+	if item == nil {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	projected, err := projectItem(item, paths, params.ExpressionAttributeNames)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.GetItemOutput{Item: projected}, nil
+}
+
+func (c *Client) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := parseCondition("ConditionExpression", params.ConditionExpression)
+	if err != nil {
+		return nil, err
+	}
+	ph := placeholders(params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err := checkPlaceholders(ph, cond); err != nil {
+		return nil, err
+	}
+	old, err := t.putItem(params.Item, cond, ph)
+	if err != nil {
+		return nil, err
+	}
+	out := &dynamodb.PutItemOutput{}
+	switch params.ReturnValues {
+	case types.ReturnValueNone, "":
+	case types.ReturnValueAllOld:
+		out.Attributes = ddbexpr.CopyItem(old)
+	default:
+		return nil, validationError("ReturnValues can only be ALL_OLD or NONE")
+	}
+	return out, nil
+}
+
+// putItem validates and stores copy of the item, and returns the item
+// it replaced
+func (t *table) putItem(item map[string]types.AttributeValue, cond ddbexpr.Condition, ph ddbexpr.Placeholders) (map[string]types.AttributeValue, error) {
+	if err := t.validateItem(item); err != nil {
+		return nil, err
+	}
+	old := t.get(t.itemKey(item))
+	if err := checkCondition(cond, old, ph); err != nil {
+		return nil, err
+	}
+	t.put(ddbexpr.CopyItem(item))
+	return old, nil
+}
+
+func (c *Client) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := parseCondition("ConditionExpression", params.ConditionExpression)
+	if err != nil {
+		return nil, err
+	}
+	ph := placeholders(params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err := checkPlaceholders(ph, cond); err != nil {
+		return nil, err
+	}
+	old, err := t.deleteItem(params.Key, cond, ph)
+	if err != nil {
+		return nil, err
+	}
+	out := &dynamodb.DeleteItemOutput{}
+	switch params.ReturnValues {
+	case types.ReturnValueNone, "":
+	case types.ReturnValueAllOld:
+		out.Attributes = ddbexpr.CopyItem(old)
+	default:
+		return nil, validationError("ReturnValues can only be ALL_OLD or NONE")
+	}
+	return out, nil
+}
+
+func (t *table) deleteItem(key map[string]types.AttributeValue, cond ddbexpr.Condition, ph ddbexpr.Placeholders) (map[string]types.AttributeValue, error) {
+	if err := t.validateKey(key); err != nil {
+		return nil, err
+	}
+	old := t.get(key)
+	if err := checkCondition(cond, old, ph); err != nil {
+		return nil, err
+	}
+	t.delete(key)
+	return old, nil
+}
+
+func (c *Client) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if params.AttributeUpdates != nil || params.Expected != nil {
+		return nil, validationError("memddb supports only expression parameters, not AttributeUpdates or Expected")
+	}
+	update, err := parseUpdate(params.UpdateExpression)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := parseCondition("ConditionExpression", params.ConditionExpression)
+	if err != nil {
+		return nil, err
+	}
+	ph := placeholders(params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err := checkPlaceholders(ph, update, cond); err != nil {
+		return nil, err
+	}
+	old, updated, err := t.updateItem(params.Key, update, cond, ph)
+	if err != nil {
+		return nil, err
+	}
+	out := &dynamodb.UpdateItemOutput{}
+	switch params.ReturnValues {
+	case types.ReturnValueNone, "":
+	case types.ReturnValueAllOld:
+		out.Attributes = ddbexpr.CopyItem(old)
+	case types.ReturnValueAllNew:
+		out.Attributes = ddbexpr.CopyItem(updated)
+	case types.ReturnValueUpdatedOld:
+		out.Attributes = changedAttributes(old, updated, old)
+	case types.ReturnValueUpdatedNew:
+		out.Attributes = changedAttributes(old, updated, updated)
+	}
+	return out, nil
+}
+
+// updateItem applies update expression to the item, or to a new item
+// with the key, and returns the old and the updated item
+func (t *table) updateItem(key map[string]types.AttributeValue, update *ddbexpr.Update, cond ddbexpr.Condition, ph ddbexpr.Placeholders) (old, updated map[string]types.AttributeValue, err error) {
+	if err := t.validateKey(key); err != nil {
+		return nil, nil, err
+	}
+	old = t.get(key)
+	if err := checkCondition(cond, old, ph); err != nil {
+		return nil, nil, err
+	}
+	if updated, err = t.updated(key, old, update, ph); err != nil {
+		return nil, nil, err
+	}
+	t.put(updated)
+	return old, updated, nil
+}
+
+// updated returns copy of old item, or new item with the key, with
+// update expression applied
+func (t *table) updated(key, old map[string]types.AttributeValue, update *ddbexpr.Update, ph ddbexpr.Placeholders) (map[string]types.AttributeValue, error) {
+	updated := ddbexpr.CopyItem(old)
+	if updated == nil {
+		updated = ddbexpr.CopyItem(key)
+	}
+	if update != nil {
+		var err error
+		if updated, err = ddbexpr.Apply(update, updated, ph); err != nil {
+			return nil, expressionError("UpdateExpression", err)
+		}
+	}
+	for _, attr := range t.key.attributes() {
+		if v, ok := updated[attr]; !ok || !ddbexpr.Equal(v, key[attr]) {
+			return nil, validationError("One or more parameter values were invalid: Cannot update attribute %s. This attribute is part of the key", attr)
+		}
+	}
+	if err := t.validateItem(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// changedAttributes returns attributes of from that are different in
+// old and updated
+func changedAttributes(old, updated, from map[string]types.AttributeValue) map[string]types.AttributeValue {
+	rv := map[string]types.AttributeValue{}
+	for k, v := range from {
+		o, inOld := old[k]
+		u, inUpdated := updated[k]
+		if inOld != inUpdated || !ddbexpr.Equal(o, u) {
+			rv[k] = ddbexpr.CopyValue(v)
+		}
+	}
+	if len(rv) == 0 {
+		return nil
+	}
+	return rv
+}
+
+func placeholders(names map[string]string, values map[string]types.AttributeValue) ddbexpr.Placeholders {
+	return ddbexpr.Placeholders{Names: names, Values: values}
+}
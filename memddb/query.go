@@ -0,0 +1,313 @@
+package memddb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/ddbexpr"
+)
+
+func (c *Client) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := t.index(params.IndexName)
+	if err != nil {
+		return nil, err
+	}
+	if idx != nil && !idx.local && params.ConsistentRead != nil && *params.ConsistentRead {
+		return nil, validationError("Consistent reads are not supported on global secondary indexes")
+	}
+	if params.KeyConditionExpression == nil {
+		return nil, validationError("Either the KeyConditions or KeyConditionExpression parameter must be specified in the request.")
+	}
+	ph := placeholders(params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	keyCondition, err := parseCondition("KeyConditionExpression", params.KeyConditionExpression)
+	if err != nil {
+		return nil, err
+	}
+	key := t.key
+	if idx != nil {
+		key = idx.key
+	}
+	if err := validateKeyCondition(keyCondition, key, ph.Names); err != nil {
+		return nil, err
+	}
+	filter, paths, err := parseFilterAndProjection(params.FilterExpression, params.ProjectionExpression)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPlaceholders(ph, keyCondition, filter, paths); err != nil {
+		return nil, err
+	}
+	if err := validateQueryFilter(filter, key, ph.Names); err != nil {
+		return nil, err
+	}
+	items := []map[string]types.AttributeValue{}
+	for _, item := range t.indexItems(idx) {
+		ok, err := ddbexpr.Evaluate(keyCondition, item, ph)
+		if err != nil {
+			return nil, expressionError("KeyConditionExpression", err)
+		}
+		if ok {
+			items = append(items, item)
+		}
+	}
+	if params.ScanIndexForward != nil && !*params.ScanIndexForward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	page, err := t.page(idx, items, pageParams{
+		exclusiveStartKey: params.ExclusiveStartKey,
+		descending:        params.ScanIndexForward != nil && !*params.ScanIndexForward,
+		limit:             params.Limit,
+		filter:            filter,
+		projection:        paths,
+		selectCount:       params.Select == types.SelectCount,
+		ph:                ph,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.QueryOutput{
+		Items:            page.items,
+		Count:            page.count,
+		ScannedCount:     page.scannedCount,
+		LastEvaluatedKey: page.lastEvaluatedKey,
+	}, nil
+}
+
+// validateKeyCondition checks that key condition has equality
+// condition on hash key and at most one condition on range key
+func validateKeyCondition(c ddbexpr.Condition, key keySchema, names map[string]string) error {
+	conditions := []ddbexpr.Condition{}
+	var flatten func(c ddbexpr.Condition)
+	flatten = func(c ddbexpr.Condition) {
+		if and, ok := c.(ddbexpr.And); ok {
+			flatten(and.Left)
+			flatten(and.Right)
+			return
+		}
+		conditions = append(conditions, c)
+	}
+	flatten(c)
+	if len(conditions) > 2 {
+		return validationError("Conditions can be of length 1 or 2 only")
+	}
+	hasHash := false
+	for _, c := range conditions {
+		attr, err := keyConditionAttribute(c, names)
+		if err != nil {
+			return err
+		}
+		switch {
+		case attr == key.hash:
+			if cmp, ok := c.(ddbexpr.Comparison); !ok || cmp.Op != "=" {
+				return validationError("Query key condition not supported")
+			}
+			hasHash = true
+		case attr == key.rng && key.rng != "":
+		default:
+			return validationError("Query condition missed key schema element: %s", key.hash)
+		}
+	}
+	if !hasHash {
+		return validationError("Query condition missed key schema element: %s", key.hash)
+	}
+	return nil
+}
+
+// keyConditionAttribute returns the key attribute that the condition
+// is on
+func keyConditionAttribute(c ddbexpr.Condition, names map[string]string) (string, error) {
+	var operand ddbexpr.Operand
+	switch c := c.(type) {
+	case ddbexpr.Comparison:
+		if c.Op == "<>" {
+			return "", validationError("Unsupported operator in KeyConditionExpression: <>")
+		}
+		operand = c.Left
+	case ddbexpr.Between:
+		operand = c.Operand
+	case ddbexpr.FunctionCondition:
+		if c.Name != "begins_with" {
+			return "", validationError("Invalid KeyConditionExpression: Invalid operator used in KeyConditionExpression: %s", c.Name)
+		}
+		operand = c.Args[0]
+	default:
+		return "", validationError("Invalid KeyConditionExpression: Invalid operator used in KeyConditionExpression")
+	}
+	p, ok := operand.(ddbexpr.PathOperand)
+	if !ok || len(p.Path) != 1 {
+		return "", validationError("Query key condition not supported")
+	}
+	path, err := p.Path.Resolve(names)
+	if err != nil {
+		return "", expressionError("KeyConditionExpression", err)
+	}
+	return path[0].Name, nil
+}
+
+func (c *Client) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, err := c.table(params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := t.index(params.IndexName)
+	if err != nil {
+		return nil, err
+	}
+	if (params.Segment == nil) != (params.TotalSegments == nil) {
+		return nil, validationError("The TotalSegments parameter is required but was not present in the request when Segment parameter is present")
+	}
+	ph := placeholders(params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	filter, paths, err := parseFilterAndProjection(params.FilterExpression, params.ProjectionExpression)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPlaceholders(ph, filter, paths); err != nil {
+		return nil, err
+	}
+	items := t.indexItems(idx)
+	if params.TotalSegments != nil {
+		if *params.Segment < 0 || *params.Segment >= *params.TotalSegments {
+			return nil, validationError("The Segment parameter is zero-based and must be less than parameter TotalSegments: Segment: %d is not less than TotalSegments: %d", *params.Segment, *params.TotalSegments)
+		}
+		segmentItems := []map[string]types.AttributeValue{}
+		for _, item := range items {
+			if t.segment(item, *params.TotalSegments) == *params.Segment {
+				segmentItems = append(segmentItems, item)
+			}
+		}
+		items = segmentItems
+	}
+	page, err := t.page(idx, items, pageParams{
+		exclusiveStartKey: params.ExclusiveStartKey,
+		limit:             params.Limit,
+		filter:            filter,
+		projection:        paths,
+		selectCount:       params.Select == types.SelectCount,
+		ph:                ph,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.ScanOutput{
+		Items:            page.items,
+		Count:            page.count,
+		ScannedCount:     page.scannedCount,
+		LastEvaluatedKey: page.lastEvaluatedKey,
+	}, nil
+}
+
+// parseFilterAndProjection parses filter and projection expressions of
+// Query or Scan
+func parseFilterAndProjection(filterExpr, projectionExpr *string) (ddbexpr.Condition, ddbexpr.Projection, error) {
+	filter, err := parseCondition("FilterExpression", filterExpr)
+	if err != nil {
+		return nil, nil, err
+	}
+	paths, err := parseProjection(projectionExpr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return filter, paths, nil
+}
+
+// validateQueryFilter checks that filter of a query doesn't refer to
+// the key attributes being queried
+func validateQueryFilter(filter ddbexpr.Condition, key keySchema, names map[string]string) error {
+	for _, p := range ddbexpr.Paths(filter) {
+		path, err := p.Resolve(names)
+		if err != nil {
+			// undefined names are reported by checkPlaceholders
+			continue
+		}
+		for _, attr := range key.attributes() {
+			if path[0].Name == attr {
+				return validationError("Filter Expression can only contain non-primary key attributes: Primary key attribute: %s", attr)
+			}
+		}
+	}
+	return nil
+}
+
+type pageParams struct {
+	exclusiveStartKey map[string]types.AttributeValue
+	descending        bool
+	limit             *int32
+	filter            ddbexpr.Condition
+	projection        ddbexpr.Projection
+	selectCount       bool
+	ph                ddbexpr.Placeholders
+}
+
+type page struct {
+	items            []map[string]types.AttributeValue
+	count            int32
+	scannedCount     int32
+	lastEvaluatedKey map[string]types.AttributeValue
+}
+
+// page returns the items after exclusive start key, up to the limit,
+// filtered and projected
+func (t *table) page(idx *index, items []map[string]types.AttributeValue, p pageParams) (page, error) {
+	if p.limit != nil && *p.limit < 1 {
+		return page{}, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value greater than or equal to 1", *p.limit)
+	}
+	if p.exclusiveStartKey != nil {
+		order := t.key.attributes()
+		if idx != nil {
+			order = append(idx.key.attributes(), order...)
+		}
+		start := len(items)
+		for i, item := range items {
+			c := compareKeys(item, p.exclusiveStartKey, order)
+			if p.descending {
+				c = -c
+			}
+			if c > 0 {
+				start = i
+				break
+			}
+		}
+		items = items[start:]
+	}
+	rv := page{items: []map[string]types.AttributeValue{}}
+	for i, item := range items {
+		if p.limit != nil && i == int(*p.limit) {
+			rv.lastEvaluatedKey = t.lastEvaluatedKey(idx, items[i-1])
+			break
+		}
+		rv.scannedCount++
+		if p.filter != nil {
+			ok, err := ddbexpr.Evaluate(p.filter, item, p.ph)
+			if err != nil {
+				return page{}, expressionError("FilterExpression", err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		rv.count++
+		if p.selectCount {
+			continue
+		}
+		projected, err := projectItem(item, p.projection, p.ph.Names)
+		if err != nil {
+			return page{}, err
+		}
+		rv.items = append(rv.items, projected)
+	}
+	if p.selectCount {
+		rv.items = nil
+	}
+	return rv, nil
+}
@@ -0,0 +1,288 @@
+package memddb
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/ddbexpr"
+)
+
+// keySchema has the names of hash and range key attributes, range is
+// empty if the key has only hash
+type keySchema struct {
+	hash  string
+	rng   string
+	local bool
+}
+
+func newKeySchema(elements []types.KeySchemaElement) keySchema {
+	ks := keySchema{}
+	for _, e := range elements {
+		switch e.KeyType {
+		case types.KeyTypeHash:
+			ks.hash = aws.ToString(e.AttributeName)
+		case types.KeyTypeRange:
+			ks.rng = aws.ToString(e.AttributeName)
+		}
+	}
+	return ks
+}
+
+func (ks keySchema) attributes() []string {
+	if ks.rng == "" {
+		return []string{ks.hash}
+	}
+	return []string{ks.hash, ks.rng}
+}
+
+type index struct {
+	name       string
+	key        keySchema
+	projection types.Projection
+	local      bool
+}
+
+type table struct {
+	desc    types.TableDescription
+	key     keySchema
+	indexes map[string]*index
+	items   map[string]map[string]types.AttributeValue
+}
+
+func (t *table) attributeType(name string) types.ScalarAttributeType {
+	for _, ad := range t.desc.AttributeDefinitions {
+		if aws.ToString(ad.AttributeName) == name {
+			return ad.AttributeType
+		}
+	}
+	return ""
+}
+
+// itemKey returns the primary key attributes of item
+func (t *table) itemKey(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	key := map[string]types.AttributeValue{}
+	for _, attr := range t.key.attributes() {
+		key[attr] = item[attr]
+	}
+	return key
+}
+
+// validateKey checks that key has exactly the key attributes of the
+// table with correct types.
+func (t *table) validateKey(key map[string]types.AttributeValue) error {
+	if len(key) != len(t.key.attributes()) {
+		return validationError("The provided key element does not match the schema")
+	}
+	for _, attr := range t.key.attributes() {
+		v, ok := key[attr]
+		if !ok || typeName(v) != string(t.attributeType(attr)) {
+			return validationError("The provided key element does not match the schema")
+		}
+		if isEmpty(v) {
+			return validationError("One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty string value. Key: %s", attr)
+		}
+	}
+	return nil
+}
+
+// validateItem checks key and index key attributes of item
+func (t *table) validateItem(item map[string]types.AttributeValue) error {
+	for _, attr := range t.key.attributes() {
+		v, ok := item[attr]
+		if !ok {
+			return validationError("One or more parameter values were invalid: Missing the key %s in the item", attr)
+		}
+		if want := string(t.attributeType(attr)); typeName(v) != want {
+			return validationError("One or more parameter values were invalid: Type mismatch for key %s expected: %s actual: %s", attr, want, typeName(v))
+		}
+		if isEmpty(v) {
+			return validationError("One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty string value. Key: %s", attr)
+		}
+	}
+	for _, name := range sortedIndexNames(t.indexes) {
+		idx := t.indexes[name]
+		for _, attr := range idx.key.attributes() {
+			v, ok := item[attr]
+			if !ok {
+				continue
+			}
+			if want := string(t.attributeType(attr)); typeName(v) != want {
+				return validationError("One or more parameter values were invalid: Type mismatch for Index Key %s Expected: %s Actual: %s IndexName: %s", attr, want, typeName(v), name)
+			}
+			if isEmpty(v) {
+				return validationError("One or more parameter values are not valid. A value specified for a secondary index key is not supported. The AttributeValue for a key attribute cannot contain an empty string value. IndexName: %s, IndexKey: %s", name, attr)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *table) get(key map[string]types.AttributeValue) map[string]types.AttributeValue {
+	return t.items[encodeKey(key, t.key.attributes())]
+}
+
+func (t *table) put(item map[string]types.AttributeValue) {
+	t.items[encodeKey(item, t.key.attributes())] = item
+}
+
+func (t *table) delete(key map[string]types.AttributeValue) {
+	delete(t.items, encodeKey(key, t.key.attributes()))
+}
+
+// indexItems returns items of the table or index, projected to the
+// index, in key order
+func (t *table) indexItems(idx *index) []map[string]types.AttributeValue {
+	rv := []map[string]types.AttributeValue{}
+	order := t.key.attributes()
+	if idx != nil {
+		order = append(idx.key.attributes(), order...)
+	}
+	for _, item := range t.items {
+		if idx == nil {
+			rv = append(rv, item)
+			continue
+		}
+		if projected, ok := t.project(idx, item); ok {
+			rv = append(rv, projected)
+		}
+	}
+	sort.Slice(rv, func(i, j int) bool { return compareKeys(rv[i], rv[j], order) < 0 })
+	return rv
+}
+
+// project returns item as it is stored in index, false if the item
+// doesn't have the index key
+func (t *table) project(idx *index, item map[string]types.AttributeValue) (map[string]types.AttributeValue, bool) {
+	for _, attr := range idx.key.attributes() {
+		if _, ok := item[attr]; !ok {
+			return nil, false
+		}
+	}
+	if idx.projection.ProjectionType == types.ProjectionTypeAll {
+		return item, true
+	}
+	rv := map[string]types.AttributeValue{}
+	for _, attr := range append(t.key.attributes(), idx.key.attributes()...) {
+		rv[attr] = item[attr]
+	}
+	if idx.projection.ProjectionType == types.ProjectionTypeInclude {
+		for _, attr := range idx.projection.NonKeyAttributes {
+			if v, ok := item[attr]; ok {
+				rv[attr] = v
+			}
+		}
+	}
+	return rv, true
+}
+
+// lastEvaluatedKey returns table and index key attributes of item
+func (t *table) lastEvaluatedKey(idx *index, item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	key := t.itemKey(item)
+	if idx != nil {
+		for _, attr := range idx.key.attributes() {
+			key[attr] = item[attr]
+		}
+	}
+	return ddbexpr.CopyItem(key)
+}
+
+// segment returns scan segment of item
+func (t *table) segment(item map[string]types.AttributeValue, totalSegments int32) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(encodeKey(item, []string{t.key.hash})))
+	return int32(h.Sum32() % uint32(totalSegments))
+}
+
+// compareKeys orders items by the attributes, missing values first
+func compareKeys(a, b map[string]types.AttributeValue, attrs []string) int {
+	for _, attr := range attrs {
+		va, okA := a[attr]
+		vb, okB := b[attr]
+		switch {
+		case !okA && !okB:
+			continue
+		case !okA:
+			return -1
+		case !okB:
+			return 1
+		}
+		if c, _ := ddbexpr.Compare(va, vb); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// encodeKey returns string that identifies the key values, numbers
+// are normalized so that e.g. 1 and 1.0 are the same key
+func encodeKey(item map[string]types.AttributeValue, attrs []string) string {
+	parts := make([]string, len(attrs))
+	for i, attr := range attrs {
+		switch v := item[attr].(type) {
+		case *types.AttributeValueMemberS:
+			parts[i] = "S:" + v.Value
+		case *types.AttributeValueMemberN:
+			if r, ok := new(big.Rat).SetString(v.Value); ok {
+				parts[i] = "N:" + r.RatString()
+			} else {
+				parts[i] = "N:" + v.Value
+			}
+		case *types.AttributeValueMemberB:
+			parts[i] = "B:" + base64.StdEncoding.EncodeToString(v.Value)
+		default:
+			parts[i] = fmt.Sprintf("%T", v)
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func typeName(av types.AttributeValue) string {
+	switch av.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	}
+	return ""
+}
+
+func isEmpty(av types.AttributeValue) bool {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value == ""
+	case *types.AttributeValueMemberB:
+		return len(v.Value) == 0
+	}
+	return false
+}
+
+func sortedIndexNames(indexes map[string]*index) []string {
+	rv := make([]string, 0, len(indexes))
+	for name := range indexes {
+		rv = append(rv, name)
+	}
+	sort.Strings(rv)
+	return rv
+}
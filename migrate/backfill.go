@@ -0,0 +1,168 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+)
+
+// Backfill recomputes index attributes of existing items. It is needed
+// after a Gonetable_[Index]Key or Gonetable_[Index]SortKey method is
+// added or changed, because the change only affects new writes.
+//
+// Backfill scans the table in parallel segments, decodes each item
+// using its _Type, marshals it again with the schema and updates the
+// index attributes of the items where they differ. Items are updated
+// only if their index attributes, version and document attributes
+// still have the values that were scanned, so index keys computed
+// from a stale copy don't replace the keys of a concurrent write.
+type Backfill struct {
+	Table *gonetable.Table
+	// Number of parallel scan segments, default 4
+	Segments int
+	// Maximum number of updates per second, 0 for no limit
+	RateLimit float64
+	// Progress of the scan, so that interrupted backfill can be
+	// resumed. Default keeps checkpoints in memory.
+	Checkpoints CheckpointStore
+	// Called after each scanned page with totals so far
+	Progress func(BackfillProgress)
+}
+
+// BackfillProgress counts items processed by Backfill.
+type BackfillProgress struct {
+	Scanned int64
+	Updated int64
+	// Items that are not gonetable documents, or whose type isn't
+	// registered in the schema
+	Skipped int64
+	// Items that were changed between scan and update
+	Conflicts int64
+}
+
+// Run runs the backfill until all segments are done, or until the
+// first error.
+func (b *Backfill) Run(ctx context.Context) (BackfillProgress, error) {
+	indexAttributes := []string{}
+	for _, ad := range b.Table.Schema().AttributeDefinitions() {
+		if name := *ad.AttributeName; name != "PK" && name != "SK" {
+			indexAttributes = append(indexAttributes, name)
+		}
+	}
+	sort.Strings(indexAttributes)
+	lim := newLimiter(b.RateLimit)
+	defer lim.stop()
+
+	mu := sync.Mutex{}
+	progress := BackfillProgress{}
+	err := parallelScan(ctx, b.Table, b.Segments, b.Checkpoints,
+		func(ctx context.Context, segment int, items []map[string]types.AttributeValue) error {
+			page := BackfillProgress{Scanned: int64(len(items))}
+			for _, item := range items {
+				doc, err := b.Table.Schema().Unmarshal(item)
+				if err != nil {
+					page.Skipped++
+					continue
+				}
+				input, err := b.updateInput(item, doc, indexAttributes)
+				if err != nil {
+					return err
+				}
+				if input == nil {
+					continue
+				}
+				if err := lim.wait(ctx); err != nil {
+					return err
+				}
+				_, err = b.Table.Client().UpdateItem(ctx, input)
+				var conditionErr *types.ConditionalCheckFailedException
+				switch {
+				case errors.As(err, &conditionErr):
+					page.Conflicts++
+				case err != nil:
+					return err
+				default:
+					page.Updated++
+				}
+			}
+			mu.Lock()
+			progress.Scanned += page.Scanned
+			progress.Updated += page.Updated
+			progress.Skipped += page.Skipped
+			progress.Conflicts += page.Conflicts
+			current := progress
+			mu.Unlock()
+			if b.Progress != nil {
+				b.Progress(current)
+			}
+			return nil
+		})
+	return progress, err
+}
+
+// updateInput returns update for index attributes of the item, or nil
+// if the item doesn't need an update.
+func (b *Backfill) updateInput(item map[string]types.AttributeValue, doc gonetable.Document, indexAttributes []string) (*dynamodb.UpdateItemInput, error) {
+	want, err := b.Table.Schema().Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("item %v: %w", item["PK"], err)
+	}
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+	set, remove, conditions := []string{}, []string{}, []string{}
+	for i, attr := range indexAttributes {
+		current, hasCurrent := item[attr]
+		wanted, hasWanted := want[attr]
+		if hasCurrent == hasWanted && reflect.DeepEqual(current, wanted) {
+			continue
+		}
+		name := fmt.Sprintf("#a%d", i)
+		names[name] = attr
+		if hasWanted {
+			values[fmt.Sprintf(":v%d", i)] = wanted
+			set = append(set, fmt.Sprintf("%s = :v%d", name, i))
+		} else {
+			remove = append(remove, name)
+		}
+		if hasCurrent {
+			values[fmt.Sprintf(":o%d", i)] = current
+			conditions = append(conditions, fmt.Sprintf("%s = :o%d", name, i))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("attribute_not_exists(%s)", name))
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	conditions = append(unchangedConditions(item, doc, names, values), conditions...)
+	update := ""
+	if len(set) > 0 {
+		update = "SET " + strings.Join(set, ", ")
+	}
+	if len(remove) > 0 {
+		if update != "" {
+			update += " "
+		}
+		update += "REMOVE " + strings.Join(remove, ", ")
+	}
+	input := &dynamodb.UpdateItemInput{
+		TableName:                aws.String(b.Table.Name()),
+		Key:                      map[string]types.AttributeValue{"PK": item["PK"], "SK": item["SK"]},
+		UpdateExpression:         aws.String(update),
+		ConditionExpression:      aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeNames: names,
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values
+	}
+	return input, nil
+}
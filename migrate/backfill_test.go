@@ -0,0 +1,244 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/memddb"
+	"github.com/juranki/gonetable/migrate"
+)
+
+type User struct {
+	ID    string
+	Email string
+}
+
+func (u *User) Gonetable_TypeID() string { return "user" }
+func (u *User) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"user", u.ID},
+		RangeSegments: []string{"user"},
+	}
+}
+func (u *User) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"email", u.Email},
+		RangeSegments: []string{"user"},
+	}
+}
+
+// scanStub serves items one per page from segment 0, and records updates
+type scanStub struct {
+	gonetable.Client
+	mu           sync.Mutex
+	items        []map[string]types.AttributeValue
+	failAfter    int
+	scans        int
+	updates      []*dynamodb.UpdateItemInput
+	transactions []*dynamodb.TransactWriteItemsInput
+	conflict     string
+}
+
+func (ss *scanStub) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if *params.Segment != 0 {
+		return &dynamodb.ScanOutput{}, nil
+	}
+	ss.scans++
+	if ss.failAfter > 0 && ss.scans > ss.failAfter {
+		return nil, errors.New("scan failed")
+	}
+	start := 0
+	if params.ExclusiveStartKey != nil {
+		for i, item := range ss.items {
+			if reflect.DeepEqual(item["PK"], params.ExclusiveStartKey["PK"]) {
+				start = i + 1
+			}
+		}
+	}
+	out := &dynamodb.ScanOutput{Items: ss.items[start : start+1]}
+	if start+1 < len(ss.items) {
+		out.LastEvaluatedKey = map[string]types.AttributeValue{
+			"PK": ss.items[start]["PK"],
+			"SK": ss.items[start]["SK"],
+		}
+	}
+	return out, nil
+}
+
+func (ss *scanStub) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if reflect.DeepEqual(params.Key["PK"], mustMarshal(ss.conflict)) {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("conflict")}
+	}
+	ss.updates = append(ss.updates, params)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func mustMarshal(in interface{}) types.AttributeValue {
+	v, err := attributevalue.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func userItem(id, email string, gsi1pk string) map[string]types.AttributeValue {
+	item := map[string]types.AttributeValue{
+		"PK":    mustMarshal("user#" + id),
+		"SK":    mustMarshal("user"),
+		"ID":    mustMarshal(id),
+		"Email": mustMarshal(email),
+		"_Type": mustMarshal("user"),
+	}
+	if gsi1pk != "" {
+		item["GSI1PK"] = mustMarshal(gsi1pk)
+		item["GSI1SK"] = mustMarshal("user")
+	}
+	return item
+}
+
+func TestBackfill_Run(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&User{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &scanStub{
+		items: []map[string]types.AttributeValue{
+			userItem("1", "a@example.com", ""),
+			userItem("2", "b@example.com", "email#b@example.com"),
+			userItem("3", "c@example.com", "email#old"),
+			{"PK": mustMarshal("other"), "SK": mustMarshal("other")},
+			userItem("5", "e@example.com", ""),
+		},
+		failAfter: 2,
+		conflict:  "user#5",
+	}
+	checkpoints := &migrate.MemoryCheckpoints{}
+	backfill := &migrate.Backfill{
+		Table:       gonetable.NewTable("test", s, stub),
+		Segments:    2,
+		Checkpoints: checkpoints,
+	}
+	progress, err := backfill.Run(context.Background())
+	if err == nil {
+		t.Fatal("expected scan error")
+	}
+	if progress.Scanned != 2 || progress.Updated != 1 {
+		t.Errorf("progress = %+v", progress)
+	}
+
+	stub.failAfter = 0
+	reported := []migrate.BackfillProgress{}
+	backfill.Progress = func(p migrate.BackfillProgress) { reported = append(reported, p) }
+	progress, err = backfill.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := migrate.BackfillProgress{Scanned: 3, Updated: 1, Skipped: 1, Conflicts: 1}
+	if progress != want {
+		t.Errorf("progress = %+v, want %+v", progress, want)
+	}
+	if len(reported) != 3 || reported[2] != want {
+		t.Errorf("reported progress = %+v", reported)
+	}
+
+	updated := []string{}
+	for _, u := range stub.updates {
+		updated = append(updated, u.Key["PK"].(*types.AttributeValueMemberS).Value)
+	}
+	sort.Strings(updated)
+	if !reflect.DeepEqual(updated, []string{"user#1", "user#3"}) {
+		t.Errorf("updated = %v", updated)
+	}
+	update := stub.updates[1]
+	if *update.UpdateExpression != "SET #a0 = :v0" ||
+		*update.ConditionExpression != "attribute_exists(PK) AND #u0 = :u0 AND #u1 = :u1 AND attribute_not_exists(#u2) AND #a0 = :o0" ||
+		!reflect.DeepEqual(update.ExpressionAttributeValues[":o0"], mustMarshal("email#old")) {
+		t.Errorf("update = %s / %s / %v",
+			*update.UpdateExpression, *update.ConditionExpression, update.ExpressionAttributeValues)
+	}
+
+	// all segments are done, nothing is scanned again
+	stub.scans = 0
+	if _, err := backfill.Run(context.Background()); err != nil || stub.scans != 0 {
+		t.Errorf("rerun error = %v, scans = %d", err, stub.scans)
+	}
+}
+
+// concurrentUpdater puts an item before the first update, as if
+// another writer changed it after scanning
+type concurrentUpdater struct {
+	*memddb.Client
+	item map[string]types.AttributeValue
+}
+
+func (cu *concurrentUpdater) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if cu.item != nil {
+		if _, err := cu.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("test"), Item: cu.item}); err != nil {
+			return nil, err
+		}
+		cu.item = nil
+	}
+	return cu.Client.UpdateItem(ctx, params, optFns...)
+}
+
+func TestBackfill_Run_ChangedAfterScan(t *testing.T) {
+	ctx := context.Background()
+	s, err := gonetable.NewSchema([]gonetable.Document{&User{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// writer that doesn't know about GSI1 changes the email
+	client := &concurrentUpdater{
+		Client: memddb.New(),
+		item:   userItem("1", "new@example.com", ""),
+	}
+	input, err := s.CreateTableInput("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateTable(ctx, input); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String("test"),
+		Item:      userItem("1", "old@example.com", ""),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	backfill := &migrate.Backfill{
+		Table: gonetable.NewTable("test", s, client),
+		// too high for a ticker, doesn't limit
+		RateLimit: 2e9,
+	}
+	progress, err := backfill.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := migrate.BackfillProgress{Scanned: 1, Conflicts: 1}
+	if progress != want {
+		t.Errorf("progress = %+v, want %+v", progress, want)
+	}
+	out, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("test"),
+		Key:       map[string]types.AttributeValue{"PK": mustMarshal("user#1"), "SK": mustMarshal("user")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := out.Item["GSI1PK"]; ok {
+		t.Errorf("GSI1PK = %v, want it unset", out.Item["GSI1PK"])
+	}
+}
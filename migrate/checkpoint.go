@@ -0,0 +1,30 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/juranki/gonetable/internal/checkpoint"
+)
+
+// SegmentCheckpoint tells how far a scan segment has progressed.
+// PK and SK are the key of the last processed item.
+type SegmentCheckpoint struct {
+	PK   string `json:"pk,omitempty"`
+	SK   string `json:"sk,omitempty"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// CheckpointStore persists progress of scan segments, so that
+// interrupted run can be resumed.
+type CheckpointStore interface {
+	Load(ctx context.Context) (map[int]SegmentCheckpoint, error)
+	Save(ctx context.Context, segment int, checkpoint SegmentCheckpoint) error
+}
+
+// MemoryCheckpoints keeps checkpoints in memory. It is useful for
+// resuming a run within the same process, and in tests.
+type MemoryCheckpoints = checkpoint.Memory[int, SegmentCheckpoint]
+
+// FileCheckpoints keeps checkpoints in a JSON file. The file is
+// rewritten on every save.
+type FileCheckpoints = checkpoint.File[int, SegmentCheckpoint]
@@ -0,0 +1,37 @@
+package migrate_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/juranki/gonetable/migrate"
+)
+
+func TestFileCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	fc := &migrate.FileCheckpoints{Path: path}
+	got, err := fc.Load(ctx)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("Load() = %v, %v", got, err)
+	}
+	if err := fc.Save(ctx, 0, migrate.SegmentCheckpoint{PK: "a", SK: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fc.Save(ctx, 1, migrate.SegmentCheckpoint{Done: true}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = (&migrate.FileCheckpoints{Path: path}).Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]migrate.SegmentCheckpoint{
+		0: {PK: "a", SK: "b"},
+		1: {Done: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package migrate
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/internal/fields"
+)
+
+// unchangedConditions returns conditions that hold while the item
+// still exists and has the version and document attributes that were
+// scanned, including the absence of those that were missing.
+// Attributes that the document type doesn't define aren't compared, so
+// that the expression stays short on wide items. Placeholders #u<n>
+// and :u<n> are added to names and values.
+func unchangedConditions(item map[string]types.AttributeValue, doc gonetable.Document, names map[string]string, values map[string]types.AttributeValue) []string {
+	attributes := fields.AttributeNames(reflect.TypeOf(doc))
+	attributes["_V"] = true
+	conditions := []string{"attribute_exists(PK)"}
+	for i, attr := range sortedKeys(attributes) {
+		name := fmt.Sprintf("#u%d", i)
+		names[name] = attr
+		if v, ok := item[attr]; ok {
+			values[fmt.Sprintf(":u%d", i)] = v
+			conditions = append(conditions, fmt.Sprintf("%s = :u%d", name, i))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("attribute_not_exists(%s)", name))
+		}
+	}
+	return conditions
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	rv := make([]string, 0, len(m))
+	for k := range m {
+		rv = append(rv, k)
+	}
+	sort.Strings(rv)
+	return rv
+}
@@ -0,0 +1,3 @@
+// Package migrate updates existing items of a gonetable table when
+// the schema changes.
+package migrate
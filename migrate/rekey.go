@@ -0,0 +1,353 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/internal/batch"
+)
+
+var (
+	ErrNoOldKey    = errors.New("rekey rule has no old key function")
+	ErrItemChanged = errors.New("item changed after scanning and no longer moves to the planned key")
+)
+
+// Number of attempts to move one item
+const maxMoveAttempts = 5
+
+// KeyFunc returns primary key of a document.
+type KeyFunc func(doc gonetable.Document) gonetable.CompositeKey
+
+// RekeyRule tells how key of a document type changes.
+type RekeyRule struct {
+	OldKey KeyFunc
+	// Default is Gonetable_Key of the document
+	NewKey KeyFunc
+}
+
+// ItemKey is the primary key of an item as stored in the table.
+type ItemKey struct {
+	PK string
+	SK string
+}
+
+func (k ItemKey) String() string {
+	return fmt.Sprintf("%s / %s", k.PK, k.SK)
+}
+
+// Move is a planned or completed move of an item to a new key.
+type Move struct {
+	From ItemKey
+	To   ItemKey
+}
+
+// Collision tells that items can't be moved to the new key, because
+// more than one item maps to it, or because another item already
+// exists with that key. Colliding items are not moved.
+type Collision struct {
+	To     ItemKey
+	From   []ItemKey
+	Exists bool
+}
+
+// RekeyReport summarizes the result of Rekey.Run.
+type RekeyReport struct {
+	Scanned int64
+	// Items already at their new key
+	AlreadyMoved int64
+	// Items moved, or that would be moved in dry run
+	Moves []Move
+	// Items of a rekeyed type whose key matched neither old nor new key
+	Unmatched  []ItemKey
+	Collisions []Collision
+	// Moves that failed because the new key was taken after scanning
+	Conflicts []Move
+}
+
+// Rekey moves items to new primary keys when the key format of
+// document types changes, e.g. from ["ed", ID] to ["tenant", T, "ed", ID].
+//
+// Rekey first scans the whole table and plans the moves, keeping the
+// plan in memory. Then it moves each item in a transaction that puts
+// the new item and deletes the old one. Transactions canceled by
+// throttling or conflicting transactions are retried. If the item
+// changed after scanning, the move is retried with its current
+// version, unless that no longer moves to the planned key; then Run
+// stops with ErrItemChanged. Running Rekey again is safe, because
+// items that are already at their new key are left as is.
+type Rekey struct {
+	Table *gonetable.Table
+	// Rules by type id
+	Rules map[string]RekeyRule
+	// Number of parallel scan segments, default 4
+	Segments int
+	// Only report the plan
+	DryRun bool
+}
+
+// Run plans and executes the moves.
+func (r *Rekey) Run(ctx context.Context) (RekeyReport, error) {
+	for typeID, rule := range r.Rules {
+		if rule.OldKey == nil {
+			return RekeyReport{}, fmt.Errorf("%w: %s", ErrNoOldKey, typeID)
+		}
+	}
+	report := RekeyReport{
+		Moves:      []Move{},
+		Unmatched:  []ItemKey{},
+		Collisions: []Collision{},
+		Conflicts:  []Move{},
+	}
+	mu := sync.Mutex{}
+	existing := map[ItemKey]bool{}
+	planned := map[ItemKey][]ItemKey{}
+	items := map[ItemKey]map[string]types.AttributeValue{}
+	err := parallelScan(ctx, r.Table, r.Segments, nil,
+		func(ctx context.Context, segment int, page []map[string]types.AttributeValue) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, item := range page {
+				report.Scanned++
+				current := ItemKey{PK: stringAttribute(item, "PK"), SK: stringAttribute(item, "SK")}
+				existing[current] = true
+				doc, err := r.Table.Schema().Unmarshal(item)
+				if err != nil {
+					continue
+				}
+				rule, ok := r.Rules[doc.Gonetable_TypeID()]
+				if !ok {
+					continue
+				}
+				oldKey, newKey, err := rule.keys(doc)
+				if err != nil {
+					return err
+				}
+				switch current {
+				case newKey:
+					report.AlreadyMoved++
+				case oldKey:
+					planned[newKey] = append(planned[newKey], current)
+					items[current] = item
+				default:
+					report.Unmatched = append(report.Unmatched, current)
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return report, err
+	}
+
+	moves := []Move{}
+	for _, to := range sortedItemKeys(planned) {
+		from := planned[to]
+		if len(from) > 1 || existing[to] {
+			sortItemKeys(from)
+			report.Collisions = append(report.Collisions, Collision{To: to, From: from, Exists: existing[to]})
+			continue
+		}
+		moves = append(moves, Move{From: from[0], To: to})
+	}
+	sortItemKeys(report.Unmatched)
+	if r.DryRun {
+		report.Moves = moves
+		return report, nil
+	}
+	for _, move := range moves {
+		taken, err := r.moveItem(ctx, move, items[move.From])
+		switch {
+		case err != nil:
+			return report, err
+		case taken:
+			report.Conflicts = append(report.Conflicts, move)
+		default:
+			report.Moves = append(report.Moves, move)
+		}
+	}
+	return report, nil
+}
+
+func (rule RekeyRule) keys(doc gonetable.Document) (oldKey, newKey ItemKey, err error) {
+	newKeyFunc := rule.NewKey
+	if newKeyFunc == nil {
+		newKeyFunc = func(doc gonetable.Document) gonetable.CompositeKey { return doc.Gonetable_Key() }
+	}
+	oldKey, err = itemKey(rule.OldKey(doc))
+	if err != nil {
+		return oldKey, newKey, err
+	}
+	newKey, err = itemKey(newKeyFunc(doc))
+	return oldKey, newKey, err
+}
+
+// moveItem moves the item, retrying canceled transactions. Returns
+// true if the new key was taken.
+func (r *Rekey) moveItem(ctx context.Context, move Move, item map[string]types.AttributeValue) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		err := r.move(ctx, move, item)
+		var cancelErr *types.TransactionCanceledException
+		if !errors.As(err, &cancelErr) {
+			return false, err
+		}
+		switch {
+		case cancellationCode(cancelErr, 0) == "ConditionalCheckFailed":
+			return true, nil
+		case attempt == maxMoveAttempts:
+			return false, err
+		case cancellationCode(cancelErr, 1) == "ConditionalCheckFailed":
+			if item, err = r.reread(ctx, move); err != nil {
+				return false, err
+			}
+		case isTransient(cancelErr):
+			if err := batch.Backoff(ctx, attempt); err != nil {
+				return false, err
+			}
+		default:
+			return false, err
+		}
+	}
+}
+
+// reread returns the current version of the item, if it still moves
+// from and to the keys of the move.
+func (r *Rekey) reread(ctx context.Context, move Move) (map[string]types.AttributeValue, error) {
+	key := map[string]types.AttributeValue{}
+	key["PK"], _ = attributevalue.Marshal(move.From.PK)
+	key["SK"], _ = attributevalue.Marshal(move.From.SK)
+	out, err := r.Table.Client().GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.Table.Name()),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item != nil {
+		if doc, err := r.Table.Schema().Unmarshal(out.Item); err == nil {
+			rule, ok := r.Rules[doc.Gonetable_TypeID()]
+			if ok {
+				oldKey, newKey, err := rule.keys(doc)
+				if err == nil && oldKey == move.From && newKey == move.To {
+					return out.Item, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrItemChanged, move.From)
+}
+
+// cancellationCode returns the reason code of ith item of canceled
+// transaction
+func cancellationCode(err *types.TransactionCanceledException, i int) string {
+	if i < len(err.CancellationReasons) {
+		return aws.ToString(err.CancellationReasons[i].Code)
+	}
+	return ""
+}
+
+// isTransient tells if the transaction was canceled only for reasons
+// that may go away when retried
+func isTransient(err *types.TransactionCanceledException) bool {
+	transient := false
+	for _, reason := range err.CancellationReasons {
+		switch aws.ToString(reason.Code) {
+		case "", "None":
+		case "TransactionConflict", "ThrottlingError", "ProvisionedThroughputExceeded":
+			transient = true
+		default:
+			return false
+		}
+	}
+	return transient
+}
+
+// move puts the item with new key and deletes the old one in one
+// transaction. Old item must be unchanged since scanning and new key
+// must be free.
+func (r *Rekey) move(ctx context.Context, move Move, item map[string]types.AttributeValue) error {
+	doc, err := r.Table.Schema().Unmarshal(item)
+	if err != nil {
+		return err
+	}
+	newItem, err := r.Table.Schema().Marshal(doc)
+	if err != nil {
+		return err
+	}
+	// keep attributes that the document type doesn't know about
+	for k, v := range item {
+		if _, ok := newItem[k]; !ok {
+			newItem[k] = v
+		}
+	}
+	newItem["PK"], _ = attributevalue.Marshal(move.To.PK)
+	newItem["SK"], _ = attributevalue.Marshal(move.To.SK)
+	oldKey := map[string]types.AttributeValue{}
+	oldKey["PK"], _ = attributevalue.Marshal(move.From.PK)
+	oldKey["SK"], _ = attributevalue.Marshal(move.From.SK)
+	// old item must have the version and document attributes that
+	// were scanned, so that a newer version isn't replaced with the
+	// stale copy
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+	conditions := unchangedConditions(item, doc, names, values)
+	if len(values) == 0 {
+		values = nil
+	}
+	tableName := aws.String(r.Table.Name())
+	_, err = r.Table.Client().TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName:           tableName,
+					Item:                newItem,
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+			{
+				Delete: &types.Delete{
+					TableName:                 tableName,
+					Key:                       oldKey,
+					ConditionExpression:       aws.String(strings.Join(conditions, " AND ")),
+					ExpressionAttributeNames:  names,
+					ExpressionAttributeValues: values,
+				},
+			},
+		},
+	})
+	return err
+}
+
+func itemKey(key gonetable.CompositeKey) (ItemKey, error) {
+	av, err := key.Marshal()
+	if err != nil {
+		return ItemKey{}, err
+	}
+	return ItemKey{PK: stringAttribute(av, "PK"), SK: stringAttribute(av, "SK")}, nil
+}
+
+func sortedItemKeys[V any](m map[ItemKey]V) []ItemKey {
+	rv := make([]ItemKey, 0, len(m))
+	for k := range m {
+		rv = append(rv, k)
+	}
+	sortItemKeys(rv)
+	return rv
+}
+
+func sortItemKeys(keys []ItemKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].PK != keys[j].PK {
+			return keys[i].PK < keys[j].PK
+		}
+		return keys[i].SK < keys[j].SK
+	})
+}
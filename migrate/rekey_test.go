@@ -0,0 +1,264 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/memddb"
+	"github.com/juranki/gonetable/migrate"
+)
+
+// Member used to have key ["member", ID], and now has key
+// ["team", Team, "member", Nick]
+type Member struct {
+	ID   string
+	Team string
+	Nick string
+	Role string `dynamodbav:",omitempty"`
+}
+
+func (m *Member) Gonetable_TypeID() string { return "member" }
+func (m *Member) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"team", m.Team},
+		RangeSegments: []string{"member", m.Nick},
+	}
+}
+
+func oldMemberKey(doc gonetable.Document) gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"member", doc.(*Member).ID},
+		RangeSegments: []string{"member"},
+	}
+}
+
+func memberItem(pk, sk, id, team, nick string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK":    mustMarshal(pk),
+		"SK":    mustMarshal(sk),
+		"ID":    mustMarshal(id),
+		"Team":  mustMarshal(team),
+		"Nick":  mustMarshal(nick),
+		"Extra": mustMarshal("kept"),
+		"_Type": mustMarshal("member"),
+	}
+}
+
+func (ss *scanStub) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.transactions = append(ss.transactions, params)
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func TestRekey_Run(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&Member{}, &User{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &scanStub{
+		items: []map[string]types.AttributeValue{
+			memberItem("member#1", "member", "1", "a", "x"),
+			memberItem("member#2", "member", "2", "a", "y"),
+			memberItem("member#3", "member", "3", "a", "y"),
+			memberItem("team#b", "member#z", "4", "b", "z"),
+			memberItem("something#else", "member", "5", "c", "z"),
+			userItem("1", "u@example.com", ""),
+		},
+	}
+	rekey := &migrate.Rekey{
+		Table:  gonetable.NewTable("test", s, stub),
+		Rules:  map[string]migrate.RekeyRule{"member": {OldKey: oldMemberKey}},
+		DryRun: true,
+	}
+	report, err := rekey.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := migrate.RekeyReport{
+		Scanned:      6,
+		AlreadyMoved: 1,
+		Moves: []migrate.Move{
+			{From: migrate.ItemKey{PK: "member#1", SK: "member"}, To: migrate.ItemKey{PK: "team#a", SK: "member#x"}},
+		},
+		Unmatched: []migrate.ItemKey{{PK: "something#else", SK: "member"}},
+		Collisions: []migrate.Collision{
+			{
+				To: migrate.ItemKey{PK: "team#a", SK: "member#y"},
+				From: []migrate.ItemKey{
+					{PK: "member#2", SK: "member"},
+					{PK: "member#3", SK: "member"},
+				},
+			},
+		},
+		Conflicts: []migrate.Move{},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("dry run report = %+v\nwant %+v", report, want)
+	}
+	if len(stub.transactions) != 0 {
+		t.Fatalf("dry run wrote %d transactions", len(stub.transactions))
+	}
+
+	rekey.DryRun = false
+	report, err = rekey.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %+v\nwant %+v", report, want)
+	}
+	if len(stub.transactions) != 1 {
+		t.Fatalf("got %d transactions, want 1", len(stub.transactions))
+	}
+	put := stub.transactions[0].TransactItems[0].Put.Item
+	del := stub.transactions[0].TransactItems[1].Delete.Key
+	if !reflect.DeepEqual(put["PK"], mustMarshal("team#a")) ||
+		!reflect.DeepEqual(put["Extra"], mustMarshal("kept")) ||
+		!reflect.DeepEqual(del["PK"], mustMarshal("member#1")) {
+		t.Errorf("put = %v, delete = %v", put, del)
+	}
+}
+
+// concurrentWriter puts an item before the first transaction, as if
+// another writer changed it after scanning, and cancels the first
+// throttle transactions
+type concurrentWriter struct {
+	*memddb.Client
+	item     map[string]types.AttributeValue
+	throttle int
+}
+
+func (cw *concurrentWriter) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if cw.item != nil {
+		if _, err := cw.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("test"), Item: cw.item}); err != nil {
+			return nil, err
+		}
+		cw.item = nil
+	}
+	if cw.throttle > 0 {
+		cw.throttle--
+		return nil, &types.TransactionCanceledException{
+			Message: aws.String("throttled"),
+			CancellationReasons: []types.CancellationReason{
+				{Code: aws.String("None")},
+				{Code: aws.String("ThrottlingError")},
+			},
+		}
+	}
+	return cw.Client.TransactWriteItems(ctx, params, optFns...)
+}
+
+func TestRekey_Run_Retry(t *testing.T) {
+	move := migrate.Move{
+		From: migrate.ItemKey{PK: "member#1", SK: "member"},
+		To:   migrate.ItemKey{PK: "team#a", SK: "member#x"},
+	}
+	changed := memberItem("member#1", "member", "1", "a", "x")
+	changed["Role"] = mustMarshal("admin")
+	tests := []struct {
+		name string
+		// item written after scan
+		concurrent map[string]types.AttributeValue
+		throttle   int
+		wantErr    error
+		wantReport migrate.RekeyReport
+		// items in the table after rekey
+		wantKeys []migrate.ItemKey
+		// role of the item at the new key
+		wantRole string
+	}{
+		{
+			name:       "throttled",
+			throttle:   2,
+			wantReport: migrate.RekeyReport{Moves: []migrate.Move{move}, Conflicts: []migrate.Move{}},
+			wantKeys:   []migrate.ItemKey{move.To},
+		},
+		{
+			name:       "changed",
+			concurrent: changed,
+			wantReport: migrate.RekeyReport{Moves: []migrate.Move{move}, Conflicts: []migrate.Move{}},
+			wantKeys:   []migrate.ItemKey{move.To},
+			wantRole:   "admin",
+		},
+		{
+			name:       "changed key",
+			concurrent: memberItem("member#1", "member", "1", "a", "w"),
+			wantErr:    migrate.ErrItemChanged,
+			wantReport: migrate.RekeyReport{Moves: []migrate.Move{}, Conflicts: []migrate.Move{}},
+			wantKeys:   []migrate.ItemKey{move.From},
+		},
+		{
+			name:       "key taken",
+			concurrent: memberItem("team#a", "member#x", "2", "a", "x"),
+			wantReport: migrate.RekeyReport{Moves: []migrate.Move{}, Conflicts: []migrate.Move{move}},
+			wantKeys:   []migrate.ItemKey{move.From, move.To},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, err := gonetable.NewSchema([]gonetable.Document{&Member{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &concurrentWriter{Client: memddb.New(), item: tt.concurrent, throttle: tt.throttle}
+			input, err := s.CreateTableInput("test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.CreateTable(ctx, input); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName: aws.String("test"),
+				Item:      memberItem("member#1", "member", "1", "a", "x"),
+			}); err != nil {
+				t.Fatal(err)
+			}
+			rekey := &migrate.Rekey{
+				Table: gonetable.NewTable("test", s, client),
+				Rules: map[string]migrate.RekeyRule{"member": {OldKey: oldMemberKey}},
+			}
+			report, err := rekey.Run(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(report.Moves, tt.wantReport.Moves) ||
+				!reflect.DeepEqual(report.Conflicts, tt.wantReport.Conflicts) {
+				t.Errorf("moves = %v, conflicts = %v, want %v, %v",
+					report.Moves, report.Conflicts, tt.wantReport.Moves, tt.wantReport.Conflicts)
+			}
+			out, err := client.Scan(ctx, &dynamodb.ScanInput{TableName: aws.String("test")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys := []migrate.ItemKey{}
+			for _, item := range out.Items {
+				key := migrate.ItemKey{
+					PK: item["PK"].(*types.AttributeValueMemberS).Value,
+					SK: item["SK"].(*types.AttributeValueMemberS).Value,
+				}
+				keys = append(keys, key)
+				role := ""
+				if v, ok := item["Role"].(*types.AttributeValueMemberS); ok {
+					role = v.Value
+				}
+				if key == move.To && role != tt.wantRole {
+					t.Errorf("Role = %q, want %q", role, tt.wantRole)
+				}
+			}
+			sort.Slice(keys, func(i, j int) bool { return keys[i].PK < keys[j].PK })
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("keys after rekey = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package migrate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+)
+
+const defaultSegments = 4
+
+// parallelScan scans all segments of the table concurrently and calls
+// handle for each page. Segments that are marked done in checkpoints
+// are skipped, others continue from the checkpointed key. Checkpoint
+// is saved after each page has been handled.
+//
+// First error cancels the other segments.
+func parallelScan(
+	ctx context.Context,
+	table *gonetable.Table,
+	segments int,
+	checkpoints CheckpointStore,
+	handle func(ctx context.Context, segment int, items []map[string]types.AttributeValue) error,
+) error {
+	if segments <= 0 {
+		segments = defaultSegments
+	}
+	if checkpoints == nil {
+		checkpoints = &MemoryCheckpoints{}
+	}
+	saved, err := checkpoints.Load(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wg := sync.WaitGroup{}
+	errOnce := sync.Once{}
+	var firstErr error
+	for segment := 0; segment < segments; segment++ {
+		checkpoint := saved[segment]
+		if checkpoint.Done {
+			continue
+		}
+		wg.Add(1)
+		go func(segment int, checkpoint SegmentCheckpoint) {
+			defer wg.Done()
+			err := scanSegment(ctx, table, segment, segments, checkpoint, checkpoints, handle)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(segment, checkpoint)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func scanSegment(
+	ctx context.Context,
+	table *gonetable.Table,
+	segment, segments int,
+	checkpoint SegmentCheckpoint,
+	checkpoints CheckpointStore,
+	handle func(ctx context.Context, segment int, items []map[string]types.AttributeValue) error,
+) error {
+	input := &dynamodb.ScanInput{
+		TableName:     aws.String(table.Name()),
+		Segment:       aws.Int32(int32(segment)),
+		TotalSegments: aws.Int32(int32(segments)),
+	}
+	if checkpoint.PK != "" {
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: checkpoint.PK},
+			"SK": &types.AttributeValueMemberS{Value: checkpoint.SK},
+		}
+	}
+	for {
+		out, err := table.Client().Scan(ctx, input)
+		if err != nil {
+			return err
+		}
+		if err := handle(ctx, segment, out.Items); err != nil {
+			return err
+		}
+		checkpoint := SegmentCheckpoint{Done: out.LastEvaluatedKey == nil}
+		if !checkpoint.Done {
+			checkpoint.PK = stringAttribute(out.LastEvaluatedKey, "PK")
+			checkpoint.SK = stringAttribute(out.LastEvaluatedKey, "SK")
+		}
+		if err := checkpoints.Save(ctx, segment, checkpoint); err != nil {
+			return err
+		}
+		if checkpoint.Done {
+			return nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+func stringAttribute(item map[string]types.AttributeValue, name string) string {
+	if v, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+	return ""
+}
+
+// limiter allows at most rate operations per second. Zero rate
+// doesn't limit, and neither do rates too high for time.Ticker.
+type limiter struct {
+	ticker *time.Ticker
+}
+
+func newLimiter(rate float64) *limiter {
+	interval := time.Duration(float64(time.Second) / rate)
+	// rates above one per nanosecond can't be limited by a ticker
+	if rate <= 0 || interval <= 0 {
+		return &limiter{}
+	}
+	return &limiter{ticker: time.NewTicker(interval)}
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.ticker == nil {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.ticker.C:
+		return nil
+	}
+}
+
+func (l *limiter) stop() {
+	if l.ticker != nil {
+		l.ticker.Stop()
+	}
+}
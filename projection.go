@@ -0,0 +1,81 @@
+package gonetable
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/internal/fields"
+)
+
+var ErrUnknownIndex = errors.New("index not defined by any document type")
+
+// Projection specifies which attributes are copied to an index.
+// Indeces project all attributes by default.
+type Projection struct {
+	Type             types.ProjectionType
+	NonKeyAttributes []string
+}
+
+// AllProjection copies all attributes of the item to the index.
+func AllProjection() Projection {
+	return Projection{Type: types.ProjectionTypeAll}
+}
+
+// KeysOnlyProjection copies only table and index keys to the index.
+// Items read from the index can't be decoded without fetching them
+// from the table.
+func KeysOnlyProjection() Projection {
+	return Projection{Type: types.ProjectionTypeKeysOnly}
+}
+
+// IncludeProjection copies keys and attributes that match the fields
+// of sample struct to the index. Field names follow the dynamodbav
+// tags like attributevalue.Marshal does. _Type is always included,
+// so that the items can be identified.
+func IncludeProjection(sample interface{}) Projection {
+	return Projection{
+		Type:             types.ProjectionTypeInclude,
+		NonKeyAttributes: append(attributeNames(reflect.TypeOf(sample)), "_Type"),
+	}
+}
+
+// WithProjection sets the projection of the index. Index must be
+// defined by at least one of the document samples.
+func WithProjection(index string, projection Projection) SchemaOption {
+	return func(o *schemaOptions) {
+		if o.projections == nil {
+			o.projections = map[string]Projection{}
+		}
+		o.projections[index] = projection
+	}
+}
+
+func (p Projection) toDDB() *types.Projection {
+	rv := &types.Projection{
+		ProjectionType: p.Type,
+	}
+	if len(p.NonKeyAttributes) > 0 {
+		rv.NonKeyAttributes = append([]string{}, p.NonKeyAttributes...)
+	}
+	return rv
+}
+
+// Returns the projection of the index, AllProjection unless
+// configured otherwise.
+func (s *Schema) projection(index string) Projection {
+	if p, ok := s.projections[index]; ok {
+		return p
+	}
+	return AllProjection()
+}
+
+// attributeNames returns names of the attributes that
+// attributevalue.Marshal would produce for struct type t.
+func attributeNames(t reflect.Type) []string {
+	rv := []string{}
+	for _, f := range fields.Attributes(t) {
+		rv = append(rv, f.Name)
+	}
+	return rv
+}
@@ -1,6 +1,10 @@
 package gonetable_test
 
-import "github.com/juranki/gonetable"
+import (
+	"strconv"
+
+	"github.com/juranki/gonetable"
+)
 
 // Invalid index has one GSI with too short name
 type InvalidIndex struct {
@@ -52,3 +56,209 @@ func (sd1 *WithIndex) Gonetable_GSI1Key() gonetable.CompositeKey {
 		RangeSegments: []string{"wi"},
 	}
 }
+
+// InvalidKeyMethod has GSI key method that doesn't return composite key
+type InvalidKeyMethod struct {
+	Name string
+}
+
+func (ikm *InvalidKeyMethod) Gonetable_TypeID() string { return "ikm" }
+func (ikm *InvalidKeyMethod) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"ikm", ikm.Name},
+		RangeSegments: []string{"ikm"},
+	}
+}
+func (ikm *InvalidKeyMethod) Gonetable_GSI1Key() string { return ikm.Name }
+
+// WithTwoIndeces is valid document with two GSIs, declared in reverse
+// alphabetical order
+type WithTwoIndeces struct {
+	Name string
+}
+
+func (wti *WithTwoIndeces) Gonetable_TypeID() string { return "wti" }
+func (wti *WithTwoIndeces) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"wti", wti.Name},
+		RangeSegments: []string{"wti"},
+	}
+}
+func (wti *WithTwoIndeces) Gonetable_GSI2Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"wti"},
+		RangeSegments: []string{wti.Name},
+	}
+}
+func (wti *WithTwoIndeces) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"wti", wti.Name},
+		RangeSegments: []string{"wti"},
+	}
+}
+
+// WithLocalIndex is valid document with one LSI
+type WithLocalIndex struct {
+	Name    string
+	Created string
+}
+
+func (wli *WithLocalIndex) Gonetable_TypeID() string { return "wli" }
+func (wli *WithLocalIndex) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"wli", wli.Name},
+		RangeSegments: []string{"wli"},
+	}
+}
+func (wli *WithLocalIndex) Gonetable_LSI1SortKey() []string {
+	return []string{"created", wli.Created}
+}
+
+// TooManyLocalIndeces has six LSIs
+type TooManyLocalIndeces struct{}
+
+func (tm *TooManyLocalIndeces) Gonetable_TypeID() string { return "tm" }
+func (tm *TooManyLocalIndeces) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"tm"},
+		RangeSegments: []string{"tm"},
+	}
+}
+func (tm *TooManyLocalIndeces) Gonetable_LSI1SortKey() []string { return []string{"1"} }
+func (tm *TooManyLocalIndeces) Gonetable_LSI2SortKey() []string { return []string{"2"} }
+func (tm *TooManyLocalIndeces) Gonetable_LSI3SortKey() []string { return []string{"3"} }
+func (tm *TooManyLocalIndeces) Gonetable_LSI4SortKey() []string { return []string{"4"} }
+func (tm *TooManyLocalIndeces) Gonetable_LSI5SortKey() []string { return []string{"5"} }
+func (tm *TooManyLocalIndeces) Gonetable_LSI6SortKey() []string { return []string{"6"} }
+
+// LocalAndGlobal uses the same index name for GSI and LSI
+type LocalAndGlobal struct{}
+
+func (lg *LocalAndGlobal) Gonetable_TypeID() string { return "lg" }
+func (lg *LocalAndGlobal) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"lg"},
+		RangeSegments: []string{"lg"},
+	}
+}
+func (lg *LocalAndGlobal) Gonetable_IDX1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"lg"},
+		RangeSegments: []string{"lg"},
+	}
+}
+func (lg *LocalAndGlobal) Gonetable_IDX1SortKey() []string { return []string{"lg"} }
+
+// SortSuffixIndex has GSI whose key method looks like LSI sort key method
+type SortSuffixIndex struct{}
+
+func (ss *SortSuffixIndex) Gonetable_TypeID() string { return "ss" }
+func (ss *SortSuffixIndex) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"ss"},
+		RangeSegments: []string{"ss"},
+	}
+}
+func (ss *SortSuffixIndex) Gonetable_NameSortKey() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"ss"},
+		RangeSegments: []string{"ss"},
+	}
+}
+
+// WithIndexSummary is projection of WithIndex
+type WithIndexSummary struct {
+	Name string `dynamodbav:"name"`
+	Skip string `dynamodbav:"-"`
+}
+
+// Label is embedded non-struct field of EmbeddedSummary
+type Label string
+
+// Audit is embedded through pointer in EmbeddedSummary
+type Audit struct {
+	Created string
+}
+
+// EmbeddedSummary is projection with embedded fields
+type EmbeddedSummary struct {
+	*Audit
+	Label
+	Name string `dynamodbav:"name"`
+}
+
+// Order has key segment formed from numeric field
+type Order struct {
+	Customer string
+	Number   int
+}
+
+func (o *Order) Gonetable_TypeID() string { return "order" }
+func (o *Order) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"customer", o.Customer},
+		RangeSegments: []string{"order", strconv.Itoa(o.Number)},
+	}
+}
+
+// Invoice has key segment formed from string and numeric field
+type Invoice struct {
+	Series string
+	Number int
+}
+
+func (i *Invoice) Gonetable_TypeID() string { return "invoice" }
+func (i *Invoice) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"invoice", i.Series + "-" + strconv.Itoa(i.Number)},
+		RangeSegments: []string{"invoice"},
+	}
+}
+
+// Profile is version 3 document. Version 1 had Nick instead of Name,
+// and version 2 had Tags as comma separated string.
+type Profile struct {
+	ID   string
+	Name string
+	Tags []string
+}
+
+func (p *Profile) Gonetable_TypeID() string { return "profile" }
+func (p *Profile) Gonetable_Version() int   { return 3 }
+func (p *Profile) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"profile", p.ID},
+		RangeSegments: []string{"profile"},
+	}
+}
+
+// ReservedFields has fields that collide with the attributes that
+// Schema.Marshal adds
+type ReservedFields struct {
+	ID     string
+	PK     string
+	Cursor string `dynamodbav:"GSI1SK"`
+}
+
+func (r *ReservedFields) Gonetable_TypeID() string { return "rf" }
+func (r *ReservedFields) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"rf", r.ID},
+		RangeSegments: []string{"rf"},
+	}
+}
+
+// CaseFolded has fields whose names differ from the key attributes
+// only by case
+type CaseFolded struct {
+	Pk    string
+	Type_ string `dynamodbav:"_type"`
+}
+
+func (c *CaseFolded) Gonetable_TypeID() string { return "cf" }
+func (c *CaseFolded) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"cf", c.Pk},
+		RangeSegments: []string{"cf"},
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/internal/fields"
 )
 
 var (
@@ -17,58 +20,303 @@ var (
 	ErrIndexName       = errors.New("invalid index name, must match ^[a-zA-Z0-9_.-]{3,255}$")
 	ErrUnknownType     = errors.New("document type not registered in schema")
 	ErrKeyMethod       = errors.New("key method didn't return composite key")
+	ErrNoTypeAttribute = errors.New("item has no _Type attribute")
+	ErrSortKeyMethod   = errors.New("sort key method didn't return key segments")
+	ErrIndexKind       = errors.New("index used both as global and local secondary index")
+	ErrLocalIndexCount = errors.New("too many local secondary indeces, at most 5 allowed")
+	ErrReservedName    = errors.New("field attribute name is reserved for key, type or version attribute")
+	ErrSortKeyName     = errors.New("GSI name ends with Sort, key method is ambiguous with LSI sort key method")
 
-	keyMethodRE = regexp.MustCompile(`^Gonetable_([a-zA-Z0-9]+)Key$`)
-	indexRE     = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
+	keyMethodRE     = regexp.MustCompile(`^Gonetable_([a-zA-Z0-9]+)Key$`)
+	sortKeyMethodRE = regexp.MustCompile(`^Gonetable_([a-zA-Z0-9]+)SortKey$`)
+	indexRE         = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,255}$`)
 )
 
+// DynamoDB limits the number of LSIs per table
+const maxLocalIndeces = 5
+
 type Schema struct {
-	docTypes map[string][]string
-	indeces  []string
+	docTypes     map[string]*docType
+	indeces      []string
+	localIndeces []string
+	projections  map[string]Projection
+	// in declaration order
+	accessPatterns []AccessPattern
+	// attributes that Marshal adds, with their descriptions
+	reserved map[string]string
+}
+
+type docType struct {
+	goType reflect.Type
+	// "" for the table key, followed by GSI names
+	indeces      []string
+	localIndeces []string
+	version      int
+	upcasters    map[int]Upcaster
+}
+
+// SchemaError describes a problem with one of the document samples
+// given to NewSchema, or with a document given to Schema.Marshal.
+// Reason is one of the Err* sentinels, so SchemaError can be matched
+// with errors.Is.
+type SchemaError struct {
+	TypeID string
+	GoType string
+	Index  string
+	Reason error
+}
+
+func (e *SchemaError) Error() string {
+	b := strings.Builder{}
+	if e.TypeID != "" || e.GoType != "" {
+		fmt.Fprintf(&b, "document %q (%s)", e.TypeID, e.GoType)
+	} else {
+		b.WriteString("schema")
+	}
+	if e.Index != "" {
+		fmt.Fprintf(&b, ", index %q", e.Index)
+	}
+	fmt.Fprintf(&b, ": %v", e.Reason)
+	return b.String()
+}
+
+func (e *SchemaError) Unwrap() error { return e.Reason }
+
+// SchemaErrors is returned by NewSchema when CollectErrors option
+// is used and more than one problem was found.
+type SchemaErrors []*SchemaError
+
+func (e SchemaErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d schema errors:\n\t%s", len(e), strings.Join(msgs, "\n\t"))
+}
+
+// Is reports whether any of the errors matches target.
+func (e SchemaErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
 }
 
-func NewSchema(docSamples []Document) (*Schema, error) {
+type schemaOptions struct {
+	collectErrors  bool
+	projections    map[string]Projection
+	upcasters      map[string]map[int]Upcaster
+	accessPatterns []AccessPattern
+}
+
+// SchemaOption modifies how NewSchema builds the schema.
+type SchemaOption func(*schemaOptions)
+
+// CollectErrors makes NewSchema validate all document samples before
+// returning, instead of failing on the first problem. If there are
+// more than one problem, they are returned as SchemaErrors.
+func CollectErrors() SchemaOption {
+	return func(o *schemaOptions) {
+		o.collectErrors = true
+	}
+}
+
+// NewSchema inspects document samples and builds schema for them.
+// Problems with the samples are reported as *SchemaError.
+func NewSchema(docSamples []Document, opts ...SchemaOption) (*Schema, error) {
 	if len(docSamples) == 0 {
 		return nil, ErrNoDocSamples
 	}
+	o := schemaOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	s := Schema{
-		docTypes: map[string][]string{},
-		indeces:  []string{},
+		docTypes:     map[string]*docType{},
+		indeces:      []string{},
+		localIndeces: []string{},
+		projections:  o.projections,
 	}
+	errs := SchemaErrors{}
+	fail := func(err *SchemaError) bool {
+		errs = append(errs, err)
+		return !o.collectErrors
+	}
+	docTypeIDs := []string{}
 	for _, d := range docSamples {
-		docType := reflect.TypeOf(d)
+		goType := reflect.TypeOf(d)
 		docTypeID := d.Gonetable_TypeID()
 		if _, exists := s.docTypes[docTypeID]; exists {
-			return nil, ErrDuplicateTypeID
+			if fail(&SchemaError{TypeID: docTypeID, GoType: goType.String(), Reason: ErrDuplicateTypeID}) {
+				return nil, errs[0]
+			}
+			continue
+		}
+		docTypeIDs = append(docTypeIDs, docTypeID)
+		s.docTypes[docTypeID] = &docType{
+			goType:       goType,
+			indeces:      append([]string{""}, getIndexNames(goType)...),
+			localIndeces: getLocalIndexNames(goType),
+			version:      documentVersion(goType),
+			upcasters:    o.upcasters[docTypeID],
 		}
-
-		indeces := getIndexNames(docType)
-		s.indeces = append(s.indeces, indeces...)
-		s.docTypes[docTypeID] = append([]string{""}, indeces...)
 	}
 	uniqueIndeces := map[string]bool{}
-	for _, idx := range s.indeces {
-		if !indexRE.MatchString(idx) {
-			return nil, ErrIndexName
-		}
-		if _, exists := uniqueIndeces[idx]; !exists {
+	uniqueLocalIndeces := map[string]bool{}
+	for _, docTypeID := range docTypeIDs {
+		dt := s.docTypes[docTypeID]
+		for _, idx := range dt.indeces[1:] {
+			var reason error
+			if !indexRE.MatchString(idx) {
+				reason = ErrIndexName
+			} else if !isKeyMethod(dt.goType, idx) {
+				reason = ErrKeyMethod
+			}
+			if reason != nil {
+				if fail(&SchemaError{TypeID: docTypeID, GoType: dt.goType.String(), Index: idx, Reason: reason}) {
+					return nil, errs[0]
+				}
+				continue
+			}
 			uniqueIndeces[idx] = true
 		}
+		for _, idx := range dt.localIndeces {
+			var reason error
+			if !indexRE.MatchString(idx) {
+				reason = ErrIndexName
+			} else if isKeyMethod(dt.goType, idx+"Sort") {
+				// Gonetable_XSortKey() CompositeKey declared GSI "XSort"
+				// before LSIs were supported
+				idx, reason = idx+"Sort", ErrSortKeyName
+			} else if !isSortKeyMethod(dt.goType, idx) {
+				reason = ErrSortKeyMethod
+			}
+			if reason != nil {
+				if fail(&SchemaError{TypeID: docTypeID, GoType: dt.goType.String(), Index: idx, Reason: reason}) {
+					return nil, errs[0]
+				}
+				continue
+			}
+			uniqueLocalIndeces[idx] = true
+		}
 	}
-	s.indeces = make([]string, len(uniqueIndeces))
-	i := 0
+	for _, docTypeID := range docTypeIDs {
+		dt := s.docTypes[docTypeID]
+		for _, idx := range dt.indeces[1:] {
+			if uniqueLocalIndeces[idx] {
+				if fail(&SchemaError{TypeID: docTypeID, GoType: dt.goType.String(), Index: idx, Reason: ErrIndexKind}) {
+					return nil, errs[0]
+				}
+			}
+		}
+	}
+	if len(uniqueLocalIndeces) > maxLocalIndeces {
+		// not specific to any document type
+		fail(&SchemaError{Reason: ErrLocalIndexCount})
+	}
+	for _, idx := range sortedKeys(o.projections) {
+		if !uniqueIndeces[idx] && !uniqueLocalIndeces[idx] {
+			if fail(&SchemaError{Index: idx, Reason: ErrUnknownIndex}) {
+				return nil, errs[0]
+			}
+		}
+	}
+	patternNames := map[string]bool{}
+	allIndeces := map[string]bool{}
 	for idx := range uniqueIndeces {
-		s.indeces[i] = idx
-		i++
+		allIndeces[idx] = true
+	}
+	for idx := range uniqueLocalIndeces {
+		allIndeces[idx] = true
+	}
+	for _, p := range o.accessPatterns {
+		if err := p.validate(patternNames, allIndeces); err != nil {
+			if fail(&SchemaError{Index: p.Index, Reason: err}) {
+				return nil, errs[0]
+			}
+			continue
+		}
+		patternNames[p.Name] = true
+		s.accessPatterns = append(s.accessPatterns, p)
+	}
+	for _, err := range s.validateUpcasters(o.upcasters) {
+		if fail(err) {
+			return nil, errs[0]
+		}
+	}
+	s.reserved = fields.Reserved(sortedKeys(uniqueIndeces), sortedKeys(uniqueLocalIndeces))
+	for _, docTypeID := range docTypeIDs {
+		dt := s.docTypes[docTypeID]
+		for _, f := range fields.Attributes(dt.goType) {
+			what, ok := s.reserved[f.Name]
+			if !ok {
+				continue
+			}
+			reason := fmt.Errorf("%w: field %s uses the %s attribute %s", ErrReservedName, f.Path, what, f.Name)
+			if fail(&SchemaError{TypeID: docTypeID, GoType: dt.goType.String(), Reason: reason}) {
+				return nil, errs[0]
+			}
+		}
+	}
+	switch len(errs) {
+	case 0:
+	case 1:
+		return nil, errs[0]
+	default:
+		return nil, errs
 	}
+	s.indeces = sortedKeys(uniqueIndeces)
+	s.localIndeces = sortedKeys(uniqueLocalIndeces)
 	return &s, nil
 }
 
+// Index describes a secondary index of the table and the document
+// types that populate it.
+type Index struct {
+	Name       string
+	Local      bool
+	Projection Projection
+	TypeIDs    []string
+}
+
+// Returns indeces of the schema sorted by name. Type ids of each index
+// are sorted too.
+//
+// AttributeDefinitions, GlobalSecondaryIndexes and LocalSecondaryIndexes
+// use the same order.
+func (s *Schema) Indexes() []Index {
+	rv := []Index{}
+	for _, idx := range s.indexNames() {
+		index := Index{Name: idx, Projection: s.projection(idx), TypeIDs: []string{}}
+		for typeID, dt := range s.docTypes {
+			if contains(dt.indeces, idx) {
+				index.TypeIDs = append(index.TypeIDs, typeID)
+			} else if contains(dt.localIndeces, idx) {
+				index.Local = true
+				index.TypeIDs = append(index.TypeIDs, typeID)
+			}
+		}
+		sort.Strings(index.TypeIDs)
+		rv = append(rv, index)
+	}
+	return rv
+}
+
 // Returns attribute definitions for all partition and sort keys fields
-// of the table and GSIs
+// of the table, GSIs and LSIs. Attributes of the table key come first,
+// followed by index attributes in index name order.
 func (s *Schema) AttributeDefinitions() []types.AttributeDefinition {
 	rv := makeIndexAttributes("PK", "SK")
-	for _, idx := range s.indeces {
+	for _, idx := range s.indexNames() {
+		if contains(s.localIndeces, idx) {
+			rv = append(rv, types.AttributeDefinition{
+				AttributeName: aws.String(fmt.Sprintf("%sSK", idx)),
+				AttributeType: types.ScalarAttributeTypeS,
+			})
+			continue
+		}
 		rv = append(rv, makeIndexAttributes(
 			fmt.Sprintf("%sPK", idx),
 			fmt.Sprintf("%sSK", idx),
@@ -77,7 +325,7 @@ func (s *Schema) AttributeDefinitions() []types.AttributeDefinition {
 	return rv
 }
 
-// Returns definitions for GSIs
+// Returns definitions for GSIs sorted by index name
 func (s *Schema) GlobalSecondaryIndexes() []types.GlobalSecondaryIndex {
 	rv := []types.GlobalSecondaryIndex{}
 	for _, idx := range s.indeces {
@@ -93,9 +341,36 @@ func (s *Schema) GlobalSecondaryIndexes() []types.GlobalSecondaryIndex {
 					KeyType:       types.KeyTypeRange,
 				},
 			},
-			Projection: &types.Projection{
-				ProjectionType: types.ProjectionTypeAll,
+			Projection: s.projection(idx).toDDB(),
+		})
+	}
+	if len(rv) == 0 {
+		return nil
+	}
+	return rv
+}
+
+// Returns definitions for LSIs sorted by index name.
+//
+// LSIs share partition key PK with the table and use <Index>SK as
+// sort key. DynamoDB allows LSIs to be defined only when the table
+// is created.
+func (s *Schema) LocalSecondaryIndexes() []types.LocalSecondaryIndex {
+	rv := []types.LocalSecondaryIndex{}
+	for _, idx := range s.localIndeces {
+		rv = append(rv, types.LocalSecondaryIndex{
+			IndexName: aws.String(idx),
+			KeySchema: []types.KeySchemaElement{
+				{
+					AttributeName: aws.String("PK"),
+					KeyType:       types.KeyTypeHash,
+				},
+				{
+					AttributeName: aws.String(fmt.Sprintf("%sSK", idx)),
+					KeyType:       types.KeyTypeRange,
+				},
 			},
+			Projection: s.projection(idx).toDDB(),
 		})
 	}
 	if len(rv) == 0 {
@@ -122,40 +397,148 @@ func (s *Schema) KeySchema() []types.KeySchemaElement {
 // Marshals document to attribute value map.
 //
 // Uses documents Gonetable_*Key methods to populate fiels
-// for composite keys, and Gonetable_TypeID to include
-// document type to the marshaled value.
+// for composite keys, Gonetable_*SortKey methods to populate
+// sort keys of LSIs, and Gonetable_TypeID to include
+// document type to the marshaled value. Versioned documents
+// get their version to _V attribute.
 func (s *Schema) Marshal(doc Document) (map[string]types.AttributeValue, error) {
-	indeces, exists := s.docTypes[doc.Gonetable_TypeID()]
+	typeID := doc.Gonetable_TypeID()
+	dt, exists := s.docTypes[typeID]
 	if !exists {
-		return nil, ErrUnknownType
+		return nil, &SchemaError{
+			TypeID: typeID,
+			GoType: reflect.TypeOf(doc).String(),
+			Reason: ErrUnknownType,
+		}
 	}
 	av, err := attributevalue.MarshalMap(doc)
 	if err != nil {
 		return nil, err
 	}
-	for _, idx := range indeces {
-		method := reflect.ValueOf(doc).MethodByName(fmt.Sprintf("Gonetable_%sKey", idx))
-		value := method.Call([]reflect.Value{})
-		if !value[0].CanConvert(reflect.TypeOf(CompositeKey{})) {
-			return nil, ErrKeyMethod
-		}
-		key := value[0].Interface().(CompositeKey)
+	docValue := reflect.ValueOf(doc)
+	for _, idx := range dt.indeces {
+		method := docValue.MethodByName(fmt.Sprintf("Gonetable_%sKey", idx))
+		key := method.Call([]reflect.Value{})[0].Interface().(CompositeKey)
 		keyAV, err := key.Marshal()
 		if err != nil {
-			return nil, err
+			return nil, withKeyErrorContext(err, typeID, idx)
 		}
 		for k, v := range keyAV {
 			av[fmt.Sprintf("%s%s", idx, k)] = v
 		}
 	}
-	av["_Type"], err = attributevalue.Marshal(doc.Gonetable_TypeID())
+	for _, idx := range dt.localIndeces {
+		method := docValue.MethodByName(fmt.Sprintf("Gonetable_%sSortKey", idx))
+		segments := method.Call([]reflect.Value{})[0].Interface().([]string)
+		sk, err := joinKeySegments("SK", segments)
+		if err != nil {
+			return nil, withKeyErrorContext(err, typeID, idx)
+		}
+		av[fmt.Sprintf("%sSK", idx)], err = attributevalue.Marshal(sk)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := doc.(Versioned); ok {
+		av["_V"], err = attributevalue.Marshal(dt.version)
+		if err != nil {
+			return nil, err
+		}
+	}
+	av["_Type"], err = attributevalue.Marshal(typeID)
 	return av, err
 }
 
+// Unmarshals attribute value map to a document.
+//
+// Uses _Type attribute to choose the document type, so the map
+// must come from Schema.Marshal or from an index that projects
+// _Type attribute. Documents of older version are upcasted to
+// the current version before they are decoded.
+func (s *Schema) Unmarshal(av map[string]types.AttributeValue) (Document, error) {
+	doc, _, err := s.unmarshal(av)
+	return doc, err
+}
+
+// unmarshal returns also the upcasted attribute values, or nil if the
+// document was already at current version
+func (s *Schema) unmarshal(av map[string]types.AttributeValue) (Document, map[string]types.AttributeValue, error) {
+	typeAV, ok := av["_Type"]
+	if !ok {
+		return nil, nil, ErrNoTypeAttribute
+	}
+	var typeID string
+	if err := attributevalue.Unmarshal(typeAV, &typeID); err != nil {
+		return nil, nil, err
+	}
+	dt, exists := s.docTypes[typeID]
+	if !exists {
+		return nil, nil, &SchemaError{TypeID: typeID, Reason: ErrUnknownType}
+	}
+	av, upcasted, err := dt.upcast(typeID, av)
+	if err != nil {
+		return nil, nil, err
+	}
+	var upcastedAV map[string]types.AttributeValue
+	if upcasted {
+		upcastedAV = av
+	}
+	doc := newDocument(dt.goType)
+	if err := attributevalue.UnmarshalMap(s.withoutReserved(av), doc); err != nil {
+		return nil, nil, err
+	}
+	if dt.goType.Kind() != reflect.Pointer {
+		return reflect.ValueOf(doc).Elem().Interface().(Document), upcastedAV, nil
+	}
+	return doc.(Document), upcastedAV, nil
+}
+
+// withoutReserved returns copy of item without the attributes that
+// Marshal adds. Attributevalue matches field names case-insensitively,
+// so otherwise e.g. field Pk would be populated from PK.
+func (s *Schema) withoutReserved(av map[string]types.AttributeValue) map[string]types.AttributeValue {
+	rv := make(map[string]types.AttributeValue, len(av))
+	for k, v := range av {
+		if _, ok := s.reserved[k]; !ok {
+			rv[k] = v
+		}
+	}
+	return rv
+}
+
+// newDocument returns pointer to a new value of document type,
+// or to its element type if document type is pointer.
+func newDocument(goType reflect.Type) interface{} {
+	if goType.Kind() == reflect.Pointer {
+		return reflect.New(goType.Elem()).Interface()
+	}
+	return reflect.New(goType).Interface()
+}
+
+func withKeyErrorContext(err error, typeID, index string) error {
+	var keyErr *KeyError
+	if errors.As(err, &keyErr) {
+		keyErr.TypeID = typeID
+		keyErr.Index = index
+		keyErr.Attribute = index + keyErr.Attribute
+	}
+	return err
+}
+
+// all index names, global and local, sorted
+func (s *Schema) indexNames() []string {
+	rv := append(append([]string{}, s.indeces...), s.localIndeces...)
+	sort.Strings(rv)
+	return rv
+}
+
 func getIndexNames(documentType reflect.Type) []string {
 	indeces := []string{}
 	for i := 0; i < documentType.NumMethod(); i++ {
 		name := documentType.Method(i).Name
+		if sortKeyMethodRE.MatchString(name) {
+			continue
+		}
 		if matches := keyMethodRE.FindStringSubmatch(name); matches != nil {
 			indeces = append(indeces, matches[1])
 		}
@@ -163,6 +546,56 @@ func getIndexNames(documentType reflect.Type) []string {
 	return indeces
 }
 
+func getLocalIndexNames(documentType reflect.Type) []string {
+	indeces := []string{}
+	for i := 0; i < documentType.NumMethod(); i++ {
+		name := documentType.Method(i).Name
+		if matches := sortKeyMethodRE.FindStringSubmatch(name); matches != nil {
+			indeces = append(indeces, matches[1])
+		}
+	}
+	return indeces
+}
+
+func isKeyMethod(documentType reflect.Type, index string) bool {
+	method, ok := documentType.MethodByName(fmt.Sprintf("Gonetable_%sKey", index))
+	if !ok {
+		return false
+	}
+	// method type includes the receiver as first argument
+	return method.Type.NumIn() == 1 &&
+		method.Type.NumOut() == 1 &&
+		method.Type.Out(0) == reflect.TypeOf(CompositeKey{})
+}
+
+func isSortKeyMethod(documentType reflect.Type, index string) bool {
+	method, ok := documentType.MethodByName(fmt.Sprintf("Gonetable_%sSortKey", index))
+	if !ok {
+		return false
+	}
+	return method.Type.NumIn() == 1 &&
+		method.Type.NumOut() == 1 &&
+		method.Type.Out(0) == reflect.TypeOf([]string{})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	rv := make([]string, 0, len(m))
+	for k := range m {
+		rv = append(rv, k)
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func makeIndexAttributes(pk, sk string) []types.AttributeDefinition {
 	return []types.AttributeDefinition{
 		{
@@ -2,6 +2,7 @@ package gonetable_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"reflect"
@@ -43,6 +44,34 @@ func TestNewSchema(t *testing.T) {
 			},
 			wantErr: gonetable.ErrDuplicateTypeID,
 		},
+		{
+			name: "invalid key method",
+			args: args{
+				docSamples: []gonetable.Document{&InvalidKeyMethod{}},
+			},
+			wantErr: gonetable.ErrKeyMethod,
+		},
+		{
+			name: "too many local indeces",
+			args: args{
+				docSamples: []gonetable.Document{&TooManyLocalIndeces{}},
+			},
+			wantErr: gonetable.ErrLocalIndexCount,
+		},
+		{
+			name: "local and global index with same name",
+			args: args{
+				docSamples: []gonetable.Document{&LocalAndGlobal{}},
+			},
+			wantErr: gonetable.ErrIndexKind,
+		},
+		{
+			name: "global index name ending with Sort",
+			args: args{
+				docSamples: []gonetable.Document{&SortSuffixIndex{}},
+			},
+			wantErr: gonetable.ErrSortKeyName,
+		},
 		{
 			name: "simple doc",
 			args: args{
@@ -50,6 +79,13 @@ func TestNewSchema(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "local index",
+			args: args{
+				docSamples: []gonetable.Document{&WithLocalIndex{}},
+			},
+			wantErr: nil,
+		},
 		{
 			name: "one index",
 			args: args{
@@ -67,7 +103,7 @@ func TestNewSchema(t *testing.T) {
 				}
 				return
 			}
-			if tt.wantErr.Error() == err.Error() {
+			if errors.Is(err, tt.wantErr) {
 				return
 			}
 			t.Errorf("error = '%v', want '%v'", err.Error(), tt.wantErr.Error())
@@ -75,6 +111,74 @@ func TestNewSchema(t *testing.T) {
 	}
 }
 
+func TestNewSchema_SchemaError(t *testing.T) {
+	_, err := gonetable.NewSchema([]gonetable.Document{&MinimalDoc{}, &InvalidIndex{}})
+	var schemaErr *gonetable.SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("error = %v, want *SchemaError", err)
+	}
+	want := gonetable.SchemaError{
+		TypeID: "sd1",
+		GoType: "*gonetable_test.InvalidIndex",
+		Reason: gonetable.ErrDuplicateTypeID,
+	}
+	if *schemaErr != want {
+		t.Errorf("error = %#v, want %#v", *schemaErr, want)
+	}
+}
+
+func TestNewSchema_CollectErrors(t *testing.T) {
+	_, err := gonetable.NewSchema(
+		[]gonetable.Document{&InvalidIndex{}, &InvalidKeyMethod{}, &MinimalDoc{}},
+		gonetable.CollectErrors(),
+	)
+	var errs gonetable.SchemaErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error = %v, want SchemaErrors", err)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("got %d errors, want 3: %v", len(errs), err)
+	}
+	for _, sentinel := range []error{
+		gonetable.ErrIndexName,
+		gonetable.ErrKeyMethod,
+		gonetable.ErrDuplicateTypeID,
+	} {
+		if !errors.Is(err, sentinel) {
+			t.Errorf("errors.Is(%v) = false", sentinel)
+		}
+	}
+	if errors.Is(err, gonetable.ErrUnknownType) {
+		t.Error("errors.Is(ErrUnknownType) = true")
+	}
+}
+
+func TestNewSchema_ReservedName(t *testing.T) {
+	_, err := gonetable.NewSchema(
+		[]gonetable.Document{&WithIndex{}, &ReservedFields{}},
+		gonetable.CollectErrors(),
+	)
+	var errs gonetable.SchemaErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error = %v, want SchemaErrors", err)
+	}
+	want := []string{
+		`document "rf" (*gonetable_test.ReservedFields): field attribute name is reserved for key, type or version attribute: field PK uses the table partition key attribute PK`,
+		`document "rf" (*gonetable_test.ReservedFields): field attribute name is reserved for key, type or version attribute: field Cursor uses the GSI1 sort key attribute GSI1SK`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), err)
+	}
+	for i, e := range errs {
+		if !errors.Is(e, gonetable.ErrReservedName) {
+			t.Errorf("errors.Is(%v, ErrReservedName) = false", e)
+		}
+		if e.Error() != want[i] {
+			t.Errorf("error = %s, want %s", e, want[i])
+		}
+	}
+}
+
 func TestSchema_AttributeDefinitions(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -117,6 +221,54 @@ func TestSchema_AttributeDefinitions(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:       "two indeces",
+			docSamples: []gonetable.Document{&WithTwoIndeces{}, &WithIndex{}},
+			want: []types.AttributeDefinition{
+				{
+					AttributeName: aws.String("PK"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+				{
+					AttributeName: aws.String("SK"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+				{
+					AttributeName: aws.String("GSI1PK"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+				{
+					AttributeName: aws.String("GSI1SK"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+				{
+					AttributeName: aws.String("GSI2PK"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+				{
+					AttributeName: aws.String("GSI2SK"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+			},
+		},
+		{
+			name:       "local index",
+			docSamples: []gonetable.Document{&WithLocalIndex{}},
+			want: []types.AttributeDefinition{
+				{
+					AttributeName: aws.String("PK"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+				{
+					AttributeName: aws.String("SK"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+				{
+					AttributeName: aws.String("LSI1SK"),
+					AttributeType: types.ScalarAttributeTypeS,
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -133,6 +285,47 @@ func TestSchema_AttributeDefinitions(t *testing.T) {
 	}
 }
 
+func TestSchema_Indexes(t *testing.T) {
+	tests := []struct {
+		name       string
+		docSamples []gonetable.Document
+		want       []gonetable.Index
+	}{
+		{
+			name:       "minimal",
+			docSamples: []gonetable.Document{&MinimalDoc{}},
+			want:       []gonetable.Index{},
+		},
+		{
+			name:       "two indeces",
+			docSamples: []gonetable.Document{&WithTwoIndeces{}, &WithIndex{}},
+			want: []gonetable.Index{
+				{Name: "GSI1", Projection: gonetable.AllProjection(), TypeIDs: []string{"wi1", "wti"}},
+				{Name: "GSI2", Projection: gonetable.AllProjection(), TypeIDs: []string{"wti"}},
+			},
+		},
+		{
+			name:       "local index",
+			docSamples: []gonetable.Document{&WithLocalIndex{}, &WithIndex{}},
+			want: []gonetable.Index{
+				{Name: "GSI1", Projection: gonetable.AllProjection(), TypeIDs: []string{"wi1"}},
+				{Name: "LSI1", Local: true, Projection: gonetable.AllProjection(), TypeIDs: []string{"wli"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := gonetable.NewSchema(tt.docSamples)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Indexes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Schema.Indexes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSchema_KeySchema(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -214,6 +407,56 @@ func TestSchema_GlobalSecondaryIndexes(t *testing.T) {
 	}
 }
 
+func TestSchema_LocalSecondaryIndexes(t *testing.T) {
+	tests := []struct {
+		name       string
+		docSamples []gonetable.Document
+		want       []types.LocalSecondaryIndex
+	}{
+		{
+			name:       "no local indeces",
+			docSamples: []gonetable.Document{&MinimalDoc{}},
+			want:       nil,
+		},
+		{
+			name:       "local index",
+			docSamples: []gonetable.Document{&WithLocalIndex{}},
+			want: []types.LocalSecondaryIndex{
+				{
+					IndexName: aws.String("LSI1"),
+					KeySchema: []types.KeySchemaElement{
+						{
+							AttributeName: aws.String("PK"),
+							KeyType:       types.KeyTypeHash,
+						},
+						{
+							AttributeName: aws.String("LSI1SK"),
+							KeyType:       types.KeyTypeRange,
+						},
+					},
+					Projection: &types.Projection{
+						ProjectionType: types.ProjectionTypeAll,
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := gonetable.NewSchema(tt.docSamples)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.LocalSecondaryIndexes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Schema.LocalSecondaryIndexes() = %v, want %v", got, tt.want)
+			}
+			if got := s.GlobalSecondaryIndexes(); got != nil {
+				t.Errorf("Schema.GlobalSecondaryIndexes() = %v, want nil", got)
+			}
+		})
+	}
+}
+
 func TestSchema_Marshal(t *testing.T) {
 	type args struct {
 		docSamples []gonetable.Document
@@ -259,6 +502,25 @@ func TestSchema_Marshal(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "with local index",
+			args: args{
+				docSamples: []gonetable.Document{&WithLocalIndex{}},
+				doc: &WithLocalIndex{
+					Name:    "hiihaa",
+					Created: "2022-09-20",
+				},
+			},
+			want: map[string]types.AttributeValue{
+				"Name":    MustMarshal("hiihaa"),
+				"Created": MustMarshal("2022-09-20"),
+				"PK":      MustMarshal("wli#hiihaa"),
+				"SK":      MustMarshal("wli"),
+				"LSI1SK":  MustMarshal("created#2022-09-20"),
+				"_Type":   MustMarshal("wli"),
+			},
+			wantErr: false,
+		},
 		{
 			name: "wrong document type",
 			args: args{
@@ -289,6 +551,127 @@ func TestSchema_Marshal(t *testing.T) {
 	}
 }
 
+func TestSchema_MarshalKeyError(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&WithIndex{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Marshal(&WithIndex{Name: "a#b"})
+	var keyErr *gonetable.KeyError
+	if !errors.As(err, &keyErr) {
+		t.Fatalf("error = %v, want *KeyError", err)
+	}
+	if !errors.Is(err, gonetable.ErrKeyDelimiter) {
+		t.Errorf("errors.Is(ErrKeyDelimiter) = false")
+	}
+	want := `document "wi1", attribute PK, segment 1 "a#b": key delimiter used in key segment`
+	if err.Error() != want {
+		t.Errorf("error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestSchema_Unmarshal(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&WithIndex{}, &MinimalDoc{}, &CaseFolded{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		av      map[string]types.AttributeValue
+		want    gonetable.Document
+		wantErr error
+	}{
+		{
+			name: "with index",
+			av: map[string]types.AttributeValue{
+				"Name":   MustMarshal("hiihaa"),
+				"PK":     MustMarshal("wi#hiihaa"),
+				"SK":     MustMarshal("wi"),
+				"GSI1PK": MustMarshal("wi#hiihaa"),
+				"GSI1SK": MustMarshal("wi"),
+				"_Type":  MustMarshal("wi1"),
+			},
+			want: &WithIndex{Name: "hiihaa"},
+		},
+		{
+			name: "case folded key attributes",
+			av: map[string]types.AttributeValue{
+				"Pk":    MustMarshal("x"),
+				"PK":    MustMarshal("cf#x"),
+				"SK":    MustMarshal("cf"),
+				"_Type": MustMarshal("cf"),
+			},
+			want: &CaseFolded{Pk: "x"},
+		},
+		{
+			name: "no type",
+			av: map[string]types.AttributeValue{
+				"Name": MustMarshal("hiihaa"),
+			},
+			wantErr: gonetable.ErrNoTypeAttribute,
+		},
+		{
+			name: "unknown type",
+			av: map[string]types.AttributeValue{
+				"Name":  MustMarshal("hiihaa"),
+				"_Type": MustMarshal("xxx"),
+			},
+			wantErr: gonetable.ErrUnknownType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Unmarshal(tt.av)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Schema.Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Schema.Unmarshal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchema_Projection(t *testing.T) {
+	s, err := gonetable.NewSchema(
+		[]gonetable.Document{&WithTwoIndeces{}},
+		gonetable.WithProjection("GSI1", gonetable.KeysOnlyProjection()),
+		gonetable.WithProjection("GSI2", gonetable.IncludeProjection(&WithIndexSummary{})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*types.Projection{
+		{ProjectionType: types.ProjectionTypeKeysOnly},
+		{
+			ProjectionType:   types.ProjectionTypeInclude,
+			NonKeyAttributes: []string{"name", "_Type"},
+		},
+	}
+	gsis := s.GlobalSecondaryIndexes()
+	for i, gsi := range gsis {
+		if !reflect.DeepEqual(gsi.Projection, want[i]) {
+			t.Errorf("%s projection = %v, want %v", *gsi.IndexName, gsi.Projection, want[i])
+		}
+	}
+
+	_, err = gonetable.NewSchema(
+		[]gonetable.Document{&WithIndex{}},
+		gonetable.WithProjection("GSI2", gonetable.KeysOnlyProjection()),
+	)
+	if !errors.Is(err, gonetable.ErrUnknownIndex) {
+		t.Errorf("error = %v, want %v", err, gonetable.ErrUnknownIndex)
+	}
+}
+
+func TestIncludeProjection(t *testing.T) {
+	got := gonetable.IncludeProjection(EmbeddedSummary{}).NonKeyAttributes
+	want := []string{"Created", "Label", "name", "_Type"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NonKeyAttributes = %v, want %v", got, want)
+	}
+}
+
 func BenchmarkSchema_Marshal(b *testing.B) {
 	s, err := gonetable.NewSchema([]gonetable.Document{&WithIndex{}})
 	if err != nil {
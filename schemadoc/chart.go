@@ -0,0 +1,175 @@
+package schemadoc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juranki/gonetable"
+)
+
+// chart is the content of the documentation, shared by the formats
+type chart struct {
+	// key attribute names of the entity chart
+	Columns  []string
+	Entities []entityRow
+	Indexes  []indexRow
+	Patterns []patternRow
+}
+
+type entityRow struct {
+	TypeID string
+	GoType string
+	// key templates by Columns, empty if the type doesn't populate
+	// the attribute
+	Cells []string
+}
+
+type indexRow struct {
+	Name         string
+	Kind         string
+	PartitionKey string
+	SortKey      string
+	Projection   string
+	TypeIDs      []string
+}
+
+type patternRow struct {
+	Name       string
+	Index      string
+	Conditions []string
+	Order      string
+	TypeIDs    []string
+}
+
+func newChart(ss gonetable.SchemaSnapshot) chart {
+	c := chart{Columns: []string{"PK", "SK"}}
+	for _, is := range ss.Indexes {
+		row := indexRow{
+			Name:         is.Name,
+			Kind:         "GSI",
+			PartitionKey: is.Name + "PK",
+			SortKey:      is.Name + "SK",
+			Projection:   string(is.Projection),
+			TypeIDs:      is.TypeIDs,
+		}
+		if is.Local {
+			row.Kind = "LSI"
+			row.PartitionKey = "PK"
+		} else {
+			c.Columns = append(c.Columns, row.PartitionKey)
+		}
+		c.Columns = append(c.Columns, row.SortKey)
+		if len(is.NonKeyAttributes) > 0 {
+			row.Projection += ": " + strings.Join(is.NonKeyAttributes, ", ")
+		}
+		c.Indexes = append(c.Indexes, row)
+	}
+	for _, ts := range ss.Types {
+		row := entityRow{TypeID: ts.TypeID, GoType: ts.GoType, Cells: make([]string, len(c.Columns))}
+		cells := map[string]string{
+			"PK": join(ss, ts.Key.Hash),
+			"SK": join(ss, ts.Key.Range),
+		}
+		for idx, kt := range ts.Indexes {
+			if kt.Hash != nil {
+				cells[idx+"PK"] = join(ss, kt.Hash)
+			}
+			cells[idx+"SK"] = join(ss, kt.Range)
+		}
+		for i, col := range c.Columns {
+			row.Cells[i] = cells[col]
+		}
+		c.Entities = append(c.Entities, row)
+	}
+	for _, p := range ss.AccessPatterns {
+		c.Patterns = append(c.Patterns, newPatternRow(ss, p))
+	}
+	return c
+}
+
+func newPatternRow(ss gonetable.SchemaSnapshot, p gonetable.AccessPattern) patternRow {
+	row := patternRow{Name: p.Name, Index: p.Index, Order: "ascending", TypeIDs: []string{}}
+	pk, sk := "PK", "SK"
+	if p.Index == "" {
+		row.Index = "table"
+	} else {
+		sk = p.Index + "SK"
+		if !isLocal(ss, p.Index) {
+			pk = p.Index + "PK"
+		}
+	}
+	row.Conditions = []string{fmt.Sprintf("%s = %s", pk, join(ss, p.Hash))}
+	if len(p.RangePrefix) > 0 {
+		row.Conditions = append(row.Conditions, fmt.Sprintf("begins_with(%s, %s)", sk, join(ss, p.RangePrefix)))
+	}
+	if p.Descending {
+		row.Order = "descending"
+	}
+	for _, ts := range ss.Types {
+		if kt, ok := keyTemplate(ts, p.Index, isLocal(ss, p.Index)); ok && matches(p, kt) {
+			row.TypeIDs = append(row.TypeIDs, ts.TypeID)
+		}
+	}
+	return row
+}
+
+// keyTemplate returns the key template of the type for the index, with
+// hash segments of the table key for LSIs
+func keyTemplate(ts gonetable.TypeSnapshot, index string, local bool) (gonetable.KeyTemplate, bool) {
+	if index == "" {
+		return ts.Key, true
+	}
+	kt, ok := ts.Indexes[index]
+	if ok && local {
+		kt.Hash = ts.Key.Hash
+	}
+	return kt, ok
+}
+
+// matches reports whether key template can produce the hash and range
+// prefix of the pattern. Parameters of the pattern and fields of the
+// template match any segment. Last segment of range prefix matches
+// also the beginning of the template segment, like begins_with.
+func matches(p gonetable.AccessPattern, kt gonetable.KeyTemplate) bool {
+	if len(p.Hash) != len(kt.Hash) || len(p.RangePrefix) > len(kt.Range) {
+		return false
+	}
+	for i, segment := range p.Hash {
+		if !segmentMatches(segment, kt.Hash[i], false) {
+			return false
+		}
+	}
+	for i, segment := range p.RangePrefix {
+		if !segmentMatches(segment, kt.Range[i], i == len(p.RangePrefix)-1) {
+			return false
+		}
+	}
+	return true
+}
+
+func segmentMatches(pattern, template string, prefix bool) bool {
+	if isPlaceholder(pattern) || isPlaceholder(template) {
+		return true
+	}
+	if prefix {
+		return strings.HasPrefix(template, pattern)
+	}
+	return pattern == template
+}
+
+func isPlaceholder(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func isLocal(ss gonetable.SchemaSnapshot, index string) bool {
+	for _, is := range ss.Indexes {
+		if is.Name == index {
+			return is.Local
+		}
+	}
+	return false
+}
+
+func join(ss gonetable.SchemaSnapshot, segments []string) string {
+	return strings.Join(segments, ss.KeyDelimiter)
+}
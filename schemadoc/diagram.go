@@ -0,0 +1,139 @@
+package schemadoc
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/juranki/gonetable"
+)
+
+// collection is an item collection: items of the table or a GSI that
+// share partition key
+type collection struct {
+	// GSI name, empty for the table
+	Index string
+	// partition key template with fields replaced by *
+	Key     string
+	TypeIDs []string
+}
+
+func (c collection) label() string {
+	return fmt.Sprintf("%sPK = %s", c.Index, c.Key)
+}
+
+// collections groups document types by the partition key templates of
+// the table and each GSI. Types whose templates have the same literal
+// segments in the same positions share a collection, regardless of
+// which fields fill the other segments. LSIs use the collections of
+// the table.
+func collections(ss gonetable.SchemaSnapshot) []collection {
+	indexes := []string{""}
+	for _, is := range ss.Indexes {
+		if !is.Local {
+			indexes = append(indexes, is.Name)
+		}
+	}
+	rv := []collection{}
+	for _, idx := range indexes {
+		byKey := map[string]int{}
+		for _, ts := range ss.Types {
+			kt, ok := keyTemplate(ts, idx, false)
+			if !ok {
+				continue
+			}
+			key := collectionKey(ss, kt.Hash)
+			i, ok := byKey[key]
+			if !ok {
+				i = len(rv)
+				byKey[key] = i
+				rv = append(rv, collection{Index: idx, Key: key})
+			}
+			rv[i].TypeIDs = append(rv[i].TypeIDs, ts.TypeID)
+		}
+	}
+	return rv
+}
+
+func collectionKey(ss gonetable.SchemaSnapshot, hash []string) string {
+	segments := make([]string, len(hash))
+	for i, segment := range hash {
+		if isPlaceholder(segment) {
+			segment = "*"
+		}
+		segments[i] = segment
+	}
+	return join(ss, segments)
+}
+
+// typeIDs returns sorted type ids of the snapshot
+func typeIDs(ss gonetable.SchemaSnapshot) []string {
+	rv := make([]string, len(ss.Types))
+	for i, ts := range ss.Types {
+		rv[i] = ts.TypeID
+	}
+	sort.Strings(rv)
+	return rv
+}
+
+// Mermaid writes a flowchart of the document types and the item
+// collections of the table and GSIs they belong to. Collections shared
+// by several types show how the table is overloaded.
+func Mermaid(w io.Writer, ss gonetable.SchemaSnapshot) error {
+	b := &strings.Builder{}
+	b.WriteString("flowchart LR\n")
+	nodes := map[string]string{}
+	for i, typeID := range typeIDs(ss) {
+		nodes[typeID] = fmt.Sprintf("t%d", i)
+		fmt.Fprintf(b, "  t%d[\"%s\"]\n", i, mermaidText(typeID))
+	}
+	for i, c := range collections(ss) {
+		fmt.Fprintf(b, "  c%d[(\"%s\")]\n", i, mermaidText(c.label()))
+		for _, typeID := range c.TypeIDs {
+			if c.Index == "" {
+				fmt.Fprintf(b, "  %s --> c%d\n", nodes[typeID], i)
+			} else {
+				fmt.Fprintf(b, "  %s -->|%s| c%d\n", nodes[typeID], c.Index, i)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Graphviz writes the diagram of Mermaid in DOT language.
+func Graphviz(w io.Writer, ss gonetable.SchemaSnapshot) error {
+	b := &strings.Builder{}
+	b.WriteString("digraph schema {\n  rankdir=LR;\n  node [shape=box];\n")
+	nodes := map[string]string{}
+	for i, typeID := range typeIDs(ss) {
+		nodes[typeID] = fmt.Sprintf("t%d", i)
+		fmt.Fprintf(b, "  t%d [label=%s];\n", i, dotString(typeID))
+	}
+	for i, c := range collections(ss) {
+		fmt.Fprintf(b, "  c%d [label=%s, shape=cylinder];\n", i, dotString(c.label()))
+		for _, typeID := range c.TypeIDs {
+			if c.Index == "" {
+				fmt.Fprintf(b, "  %s -> c%d;\n", nodes[typeID], i)
+			} else {
+				fmt.Fprintf(b, "  %s -> c%d [label=%s];\n", nodes[typeID], i, dotString(c.Index))
+			}
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidText escapes text for quoted Mermaid labels. # starts entity
+// codes in Mermaid, so the key delimiter is escaped too.
+func mermaidText(s string) string {
+	s = strings.ReplaceAll(s, "#", "#35;")
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+func dotString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
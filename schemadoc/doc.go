@@ -0,0 +1,19 @@
+// Package schemadoc generates documentation of gonetable schemas from
+// schema snapshots.
+//
+// The entity chart lists document types with the key templates of the
+// table and each index, followed by the indexes and the access
+// patterns of the schema with the types that each pattern can return:
+//
+//	ss := schema.Snapshot()
+//	if err := schemadoc.Markdown(os.Stdout, ss); err != nil {
+//		return err
+//	}
+//
+// Mermaid and Graphviz draw the document types and the item
+// collections of the table and GSIs that they belong to. A collection
+// shared by several types is where the table is overloaded.
+//
+// A type matches an access pattern when its key template for the
+// queried index can produce the pattern's hash key and range prefix.
+package schemadoc
@@ -0,0 +1,55 @@
+package schemadoc
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/juranki/gonetable"
+)
+
+var htmlTemplate = template.Must(template.New("schema").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Schema</title>
+<style>
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+code { white-space: nowrap; }
+</style>
+</head>
+<body>
+<h2>Entities</h2>
+<table>
+<tr><th>Entity</th>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Entities}}
+<tr><td title="{{.GoType}}">{{.TypeID}}</td>{{range .Cells}}<td>{{if .}}<code>{{.}}</code>{{end}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- if .Indexes}}
+<h2>Indexes</h2>
+<table>
+<tr><th>Index</th><th>Type</th><th>Partition key</th><th>Sort key</th><th>Projection</th><th>Entities</th></tr>
+{{- range .Indexes}}
+<tr><td>{{.Name}}</td><td>{{.Kind}}</td><td>{{.PartitionKey}}</td><td>{{.SortKey}}</td><td>{{.Projection}}</td><td>{{range $i, $t := .TypeIDs}}{{if $i}}, {{end}}{{$t}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Patterns}}
+<h2>Access patterns</h2>
+<table>
+<tr><th>Access pattern</th><th>Index</th><th>Key condition</th><th>Order</th><th>Entities</th></tr>
+{{- range .Patterns}}
+<tr><td>{{.Name}}</td><td>{{.Index}}</td><td>{{range $i, $c := .Conditions}}{{if $i}} and {{end}}<code>{{$c}}</code>{{end}}</td><td>{{.Order}}</td><td>{{range $i, $t := .TypeIDs}}{{if $i}}, {{end}}{{$t}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// HTML writes entity chart, indexes and access patterns of the schema
+// as an HTML page.
+func HTML(w io.Writer, ss gonetable.SchemaSnapshot) error {
+	return htmlTemplate.Execute(w, newChart(ss))
+}
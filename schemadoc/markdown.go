@@ -0,0 +1,85 @@
+package schemadoc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/juranki/gonetable"
+)
+
+// Markdown writes entity chart, indexes and access patterns of the
+// schema as Markdown tables.
+func Markdown(w io.Writer, ss gonetable.SchemaSnapshot) error {
+	c := newChart(ss)
+	b := &strings.Builder{}
+
+	b.WriteString("## Entities\n\n")
+	header := append([]string{"Entity"}, c.Columns...)
+	rows := [][]string{}
+	for _, e := range c.Entities {
+		row := []string{e.TypeID}
+		for _, cell := range e.Cells {
+			row = append(row, code(cell))
+		}
+		rows = append(rows, row)
+	}
+	writeTable(b, header, rows)
+
+	if len(c.Indexes) > 0 {
+		b.WriteString("\n## Indexes\n\n")
+		rows = [][]string{}
+		for _, idx := range c.Indexes {
+			rows = append(rows, []string{
+				idx.Name, idx.Kind, idx.PartitionKey, idx.SortKey, idx.Projection,
+				strings.Join(idx.TypeIDs, ", "),
+			})
+		}
+		writeTable(b, []string{"Index", "Type", "Partition key", "Sort key", "Projection", "Entities"}, rows)
+	}
+
+	if len(c.Patterns) > 0 {
+		b.WriteString("\n## Access patterns\n\n")
+		rows = [][]string{}
+		for _, p := range c.Patterns {
+			conditions := make([]string, len(p.Conditions))
+			for i, cond := range p.Conditions {
+				conditions[i] = code(cond)
+			}
+			rows = append(rows, []string{
+				p.Name, p.Index, strings.Join(conditions, " and "), p.Order,
+				strings.Join(p.TypeIDs, ", "),
+			})
+		}
+		writeTable(b, []string{"Access pattern", "Index", "Key condition", "Order", "Entities"}, rows)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeTable(b *strings.Builder, header []string, rows [][]string) {
+	writeRow(b, header)
+	sep := make([]string, len(header))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	writeRow(b, sep)
+	for _, row := range rows {
+		writeRow(b, row)
+	}
+}
+
+func writeRow(b *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(cell, "|", `\|`)
+	}
+	fmt.Fprintf(b, "| %s |\n", strings.Join(escaped, " | "))
+}
+
+func code(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
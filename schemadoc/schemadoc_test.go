@@ -0,0 +1,162 @@
+package schemadoc_test
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/schemadoc"
+)
+
+type Customer struct {
+	ID   string
+	Name string
+}
+
+func (c *Customer) Gonetable_TypeID() string { return "customer" }
+func (c *Customer) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"customer", c.ID},
+		RangeSegments: []string{"customer"},
+	}
+}
+
+type Order struct {
+	Customer string
+	Number   int
+	Status   string
+	Created  string
+}
+
+func (o *Order) Gonetable_TypeID() string { return "order" }
+func (o *Order) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"customer", o.Customer},
+		RangeSegments: []string{"order", strconv.Itoa(o.Number)},
+	}
+}
+func (o *Order) Gonetable_GSI1Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"status", o.Status},
+		RangeSegments: []string{o.Created},
+	}
+}
+func (o *Order) Gonetable_LSI1SortKey() []string { return []string{"created", o.Created} }
+
+func snapshot(t *testing.T) gonetable.SchemaSnapshot {
+	s, err := gonetable.NewSchema(
+		[]gonetable.Document{&Customer{}, &Order{}},
+		gonetable.WithProjection("GSI1", gonetable.IncludeProjection(struct{ Customer string }{})),
+		gonetable.WithAccessPattern(gonetable.AccessPattern{
+			Name: "customer with orders",
+			Hash: []string{"customer", "{Customer}"},
+		}),
+		gonetable.WithAccessPattern(gonetable.AccessPattern{
+			Name:        "orders by customer, newest first",
+			Index:       "LSI1",
+			Hash:        []string{"customer", "{Customer}"},
+			RangePrefix: []string{"created"},
+			Descending:  true,
+		}),
+		gonetable.WithAccessPattern(gonetable.AccessPattern{
+			Name:  "orders by status",
+			Index: "GSI1",
+			Hash:  []string{"status", "{Status}"},
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Snapshot()
+}
+
+const wantMarkdown = "## Entities\n\n" +
+	"| Entity | PK | SK | GSI1PK | GSI1SK | LSI1SK |\n" +
+	"| --- | --- | --- | --- | --- | --- |\n" +
+	"| customer | `customer#{ID}` | `customer` |  |  |  |\n" +
+	"| order | `customer#{Customer}` | `order#{Number}` | `status#{Status}` | `{Created}` | `created#{Created}` |\n" +
+	"\n## Indexes\n\n" +
+	"| Index | Type | Partition key | Sort key | Projection | Entities |\n" +
+	"| --- | --- | --- | --- | --- | --- |\n" +
+	"| GSI1 | GSI | GSI1PK | GSI1SK | INCLUDE: Customer, _Type | order |\n" +
+	"| LSI1 | LSI | PK | LSI1SK | ALL | order |\n" +
+	"\n## Access patterns\n\n" +
+	"| Access pattern | Index | Key condition | Order | Entities |\n" +
+	"| --- | --- | --- | --- | --- |\n" +
+	"| customer with orders | table | `PK = customer#{Customer}` | ascending | customer, order |\n" +
+	"| orders by customer, newest first | LSI1 | `PK = customer#{Customer}` and `begins_with(LSI1SK, created)` | descending | order |\n" +
+	"| orders by status | GSI1 | `GSI1PK = status#{Status}` | ascending | order |\n"
+
+func TestMarkdown(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := schemadoc.Markdown(buf, snapshot(t)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != wantMarkdown {
+		t.Errorf("Markdown:\n%s\nwant:\n%s", buf, wantMarkdown)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := schemadoc.HTML(buf, snapshot(t)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<tr><th>Entity</th><th>PK</th><th>SK</th><th>GSI1PK</th><th>GSI1SK</th><th>LSI1SK</th></tr>`,
+		`<tr><td title="*schemadoc_test.Customer">customer</td><td><code>customer#{ID}</code></td><td><code>customer</code></td><td></td><td></td><td></td></tr>`,
+		`<tr><td>customer with orders</td><td>table</td><td><code>PK = customer#{Customer}</code></td><td>ascending</td><td>customer, order</td></tr>`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("HTML doesn't contain %s:\n%s", want, buf)
+		}
+	}
+}
+
+const wantMermaid = `flowchart LR
+  t0["customer"]
+  t1["order"]
+  c0[("PK = customer#35;*")]
+  t0 --> c0
+  t1 --> c0
+  c1[("GSI1PK = status#35;*")]
+  t1 -->|GSI1| c1
+`
+
+const wantGraphviz = `digraph schema {
+  rankdir=LR;
+  node [shape=box];
+  t0 [label="customer"];
+  t1 [label="order"];
+  c0 [label="PK = customer#*", shape=cylinder];
+  t0 -> c0;
+  t1 -> c0;
+  c1 [label="GSI1PK = status#*", shape=cylinder];
+  t1 -> c1 [label="GSI1"];
+}
+`
+
+func TestDiagrams(t *testing.T) {
+	tests := []struct {
+		name   string
+		render func(w io.Writer, ss gonetable.SchemaSnapshot) error
+		want   string
+	}{
+		{"mermaid", schemadoc.Mermaid, wantMermaid},
+		{"graphviz", schemadoc.Graphviz, wantGraphviz},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := tt.render(buf, snapshot(t)); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("diagram:\n%s\nwant:\n%s", buf, tt.want)
+			}
+		})
+	}
+}
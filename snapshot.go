@@ -0,0 +1,144 @@
+package gonetable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Version of the snapshot format written by SchemaSnapshot.Write
+const SnapshotVersion = 1
+
+var ErrSnapshotVersion = errors.New("unsupported schema snapshot version")
+
+// SchemaSnapshot is serializable description of a schema. It can be
+// committed to the repository and compared with the schema of the
+// current code using DiffSnapshots.
+type SchemaSnapshot struct {
+	Version        int             `json:"version"`
+	KeyDelimiter   string          `json:"keyDelimiter"`
+	Types          []TypeSnapshot  `json:"types"`
+	Indexes        []IndexSnapshot `json:"indexes"`
+	AccessPatterns []AccessPattern `json:"accessPatterns,omitempty"`
+}
+
+// TypeSnapshot describes document type and its key templates.
+type TypeSnapshot struct {
+	TypeID  string                 `json:"typeId"`
+	GoType  string                 `json:"goType"`
+	Key     KeyTemplate            `json:"key"`
+	Indexes map[string]KeyTemplate `json:"indexes,omitempty"`
+}
+
+// IndexSnapshot describes secondary index.
+type IndexSnapshot struct {
+	Name             string               `json:"name"`
+	Local            bool                 `json:"local,omitempty"`
+	Projection       types.ProjectionType `json:"projection"`
+	NonKeyAttributes []string             `json:"nonKeyAttributes,omitempty"`
+	TypeIDs          []string             `json:"typeIds"`
+}
+
+// Returns snapshot of the schema. Types are sorted by type id and
+// indeces by name. Access patterns are in declaration order.
+func (s *Schema) Snapshot() SchemaSnapshot {
+	ss := SchemaSnapshot{
+		Version:      SnapshotVersion,
+		KeyDelimiter: KeyDelimiter,
+		Types:        []TypeSnapshot{},
+		Indexes:      []IndexSnapshot{},
+	}
+	for _, typeID := range sortedKeys(s.docTypes) {
+		dt := s.docTypes[typeID]
+		templates := dt.keyTemplates()
+		ts := TypeSnapshot{
+			TypeID: typeID,
+			GoType: dt.goType.String(),
+			Key:    templates[""],
+		}
+		delete(templates, "")
+		if len(templates) > 0 {
+			ts.Indexes = templates
+		}
+		ss.Types = append(ss.Types, ts)
+	}
+	for _, idx := range s.Indexes() {
+		ss.Indexes = append(ss.Indexes, IndexSnapshot{
+			Name:             idx.Name,
+			Local:            idx.Local,
+			Projection:       idx.Projection.Type,
+			NonKeyAttributes: idx.Projection.NonKeyAttributes,
+			TypeIDs:          idx.TypeIDs,
+		})
+	}
+	if len(s.accessPatterns) > 0 {
+		ss.AccessPatterns = s.AccessPatterns()
+	}
+	return ss
+}
+
+// Write writes the snapshot as indented JSON.
+func (ss SchemaSnapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ss)
+}
+
+// ReadSnapshot reads snapshot written by SchemaSnapshot.Write.
+func ReadSnapshot(r io.Reader) (SchemaSnapshot, error) {
+	ss := SchemaSnapshot{}
+	if err := json.NewDecoder(r).Decode(&ss); err != nil {
+		return ss, err
+	}
+	if ss.Version != SnapshotVersion {
+		return ss, fmt.Errorf("%w: %d", ErrSnapshotVersion, ss.Version)
+	}
+	sort.Slice(ss.Types, func(i, j int) bool { return ss.Types[i].TypeID < ss.Types[j].TypeID })
+	sort.Slice(ss.Indexes, func(i, j int) bool { return ss.Indexes[i].Name < ss.Indexes[j].Name })
+	return ss, nil
+}
+
+// CreateTableInput returns input for creating a table for the schema
+// the snapshot was taken from, like Schema.CreateTableInput. It lets
+// tools that have only the snapshot define the table.
+func (ss SchemaSnapshot) CreateTableInput(name string, opts ...CreateTableOption) (*dynamodb.CreateTableInput, error) {
+	s := &Schema{
+		indeces:      []string{},
+		localIndeces: []string{},
+		projections:  map[string]Projection{},
+	}
+	for _, is := range ss.Indexes {
+		if is.Local {
+			s.localIndeces = append(s.localIndeces, is.Name)
+		} else {
+			s.indeces = append(s.indeces, is.Name)
+		}
+		s.projections[is.Name] = Projection{Type: is.Projection, NonKeyAttributes: is.NonKeyAttributes}
+	}
+	sort.Strings(s.indeces)
+	sort.Strings(s.localIndeces)
+	return s.CreateTableInput(name, opts...)
+}
+
+func (ss SchemaSnapshot) typeByID(typeID string) (TypeSnapshot, bool) {
+	for _, ts := range ss.Types {
+		if ts.TypeID == typeID {
+			return ts, true
+		}
+	}
+	return TypeSnapshot{}, false
+}
+
+func (ss SchemaSnapshot) indexByName(name string) (IndexSnapshot, bool) {
+	for _, is := range ss.Indexes {
+		if is.Name == name {
+			return is, true
+		}
+	}
+	return IndexSnapshot{}, false
+}
@@ -0,0 +1,134 @@
+package gonetable_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/juranki/gonetable"
+)
+
+const wantSnapshot = `{
+  "version": 1,
+  "keyDelimiter": "#",
+  "types": [
+    {
+      "typeId": "order",
+      "goType": "*gonetable_test.Order",
+      "key": {
+        "hash": [
+          "customer",
+          "{Customer}"
+        ],
+        "range": [
+          "order",
+          "{Number}"
+        ]
+      }
+    },
+    {
+      "typeId": "wli",
+      "goType": "*gonetable_test.WithLocalIndex",
+      "key": {
+        "hash": [
+          "wli",
+          "{Name}"
+        ],
+        "range": [
+          "wli"
+        ]
+      },
+      "indexes": {
+        "LSI1": {
+          "range": [
+            "created",
+            "{Created}"
+          ]
+        }
+      }
+    }
+  ],
+  "indexes": [
+    {
+      "name": "LSI1",
+      "local": true,
+      "projection": "ALL",
+      "typeIds": [
+        "wli"
+      ]
+    }
+  ]
+}
+`
+
+func TestSchema_Snapshot(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&WithLocalIndex{}, &Order{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := s.Snapshot().Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != wantSnapshot {
+		t.Errorf("snapshot =\n%s\nwant\n%s", buf.String(), wantSnapshot)
+	}
+	ss, err := gonetable.ReadSnapshot(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ss, s.Snapshot()) {
+		t.Errorf("ReadSnapshot() = %v, want %v", ss, s.Snapshot())
+	}
+	_, err = gonetable.ReadSnapshot(strings.NewReader(`{"version": 99}`))
+	if !errors.Is(err, gonetable.ErrSnapshotVersion) {
+		t.Errorf("error = %v, want %v", err, gonetable.ErrSnapshotVersion)
+	}
+}
+
+func TestSchemaSnapshot_CreateTableInput(t *testing.T) {
+	s, err := gonetable.NewSchema(
+		[]gonetable.Document{&WithTwoIndeces{}, &WithLocalIndex{}},
+		gonetable.WithProjection("GSI1", gonetable.KeysOnlyProjection()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := []gonetable.CreateTableOption{gonetable.WithProvisionedThroughput(2, 1)}
+	want, err := s.CreateTableInput("test", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Snapshot().CreateTableInput("test", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CreateTableInput() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSchema_DescribeKeys(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&WithTwoIndeces{}, &WithLocalIndex{}, &Order{}, &Invoice{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, kd := range s.DescribeKeys() {
+		got = append(got, kd.String())
+	}
+	want := []string{
+		"invoice: invoice#{Series+Number} / invoice",
+		"order: customer#{Customer} / order#{Number}",
+		"wli: wli#{Name} / wli",
+		"wli LSI1: created#{Created}",
+		"wti: wti#{Name} / wti",
+		"wti GSI1: wti#{Name} / wti",
+		"wti GSI2: wti / {Name}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DescribeKeys() = %q, want %q", got, want)
+	}
+}
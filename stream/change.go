@@ -0,0 +1,122 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/juranki/gonetable"
+)
+
+var (
+	ErrEventName = errors.New("unknown stream event name")
+	ErrNoKeys    = errors.New("stream record has no PK and SK")
+)
+
+// ChangeKind tells what happened to an item.
+type ChangeKind int
+
+const (
+	Insert ChangeKind = iota + 1
+	Modify
+	Remove
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Insert:
+		return "INSERT"
+	case Modify:
+		return "MODIFY"
+	case Remove:
+		return "REMOVE"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change is a decoded stream record.
+//
+// Old and New are nil when the stream view type doesn't include the
+// image, and also for inserts (Old) and removes (New). Key is split
+// from PK and SK with gonetable.KeyDelimiter.
+type Change struct {
+	Kind           ChangeKind
+	Key            gonetable.CompositeKey
+	Old            gonetable.Document
+	New            gonetable.Document
+	SequenceNumber string
+}
+
+// Decode decodes record read with DynamoDB Streams API. Images are
+// decoded with the schema, so items without _Type or with a type that
+// is not in the schema return an error.
+func Decode(schema *gonetable.Schema, record streamtypes.Record) (Change, error) {
+	if record.Dynamodb == nil {
+		return Change{}, ErrNoKeys
+	}
+	sr := record.Dynamodb
+	keys, err := attributevalue.FromDynamoDBStreamsMap(sr.Keys)
+	if err != nil {
+		return Change{}, err
+	}
+	oldImage, err := attributevalue.FromDynamoDBStreamsMap(sr.OldImage)
+	if err != nil {
+		return Change{}, err
+	}
+	newImage, err := attributevalue.FromDynamoDBStreamsMap(sr.NewImage)
+	if err != nil {
+		return Change{}, err
+	}
+	return decode(schema, string(record.EventName), aws.ToString(sr.SequenceNumber), keys, oldImage, newImage)
+}
+
+func decode(schema *gonetable.Schema, eventName, sequenceNumber string, keys, oldImage, newImage map[string]types.AttributeValue) (Change, error) {
+	change := Change{SequenceNumber: sequenceNumber}
+	switch eventName {
+	case string(streamtypes.OperationTypeInsert):
+		change.Kind = Insert
+	case string(streamtypes.OperationTypeModify):
+		change.Kind = Modify
+	case string(streamtypes.OperationTypeRemove):
+		change.Kind = Remove
+	default:
+		return Change{}, fmt.Errorf("%w: %q", ErrEventName, eventName)
+	}
+	key, err := compositeKey(keys)
+	if err != nil {
+		return Change{}, err
+	}
+	change.Key = key
+	if len(oldImage) > 0 {
+		if change.Old, err = schema.Unmarshal(oldImage); err != nil {
+			return Change{}, fmt.Errorf("old image of %s: %w", keyString(key), err)
+		}
+	}
+	if len(newImage) > 0 {
+		if change.New, err = schema.Unmarshal(newImage); err != nil {
+			return Change{}, fmt.Errorf("new image of %s: %w", keyString(key), err)
+		}
+	}
+	return change, nil
+}
+
+func compositeKey(keys map[string]types.AttributeValue) (gonetable.CompositeKey, error) {
+	pk, okPK := keys["PK"].(*types.AttributeValueMemberS)
+	sk, okSK := keys["SK"].(*types.AttributeValueMemberS)
+	if !okPK || !okSK {
+		return gonetable.CompositeKey{}, ErrNoKeys
+	}
+	return gonetable.CompositeKey{
+		HashSegments:  strings.Split(pk.Value, gonetable.KeyDelimiter),
+		RangeSegments: strings.Split(sk.Value, gonetable.KeyDelimiter),
+	}, nil
+}
+
+func keyString(key gonetable.CompositeKey) string {
+	return strings.Join(key.HashSegments, gonetable.KeyDelimiter) + " / " +
+		strings.Join(key.RangeSegments, gonetable.KeyDelimiter)
+}
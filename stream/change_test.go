@@ -0,0 +1,135 @@
+package stream_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/stream"
+)
+
+type Item struct {
+	ID    string
+	Title string
+}
+
+func (i *Item) Gonetable_TypeID() string { return "item" }
+func (i *Item) Gonetable_Key() gonetable.CompositeKey {
+	return gonetable.CompositeKey{
+		HashSegments:  []string{"item", i.ID},
+		RangeSegments: []string{"item"},
+	}
+}
+
+func testSchema(t *testing.T) *gonetable.Schema {
+	s, err := gonetable.NewSchema([]gonetable.Document{&Item{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func streamImage(id, title string) map[string]streamtypes.AttributeValue {
+	return map[string]streamtypes.AttributeValue{
+		"PK":    &streamtypes.AttributeValueMemberS{Value: "item#" + id},
+		"SK":    &streamtypes.AttributeValueMemberS{Value: "item"},
+		"ID":    &streamtypes.AttributeValueMemberS{Value: id},
+		"Title": &streamtypes.AttributeValueMemberS{Value: title},
+		"_Type": &streamtypes.AttributeValueMemberS{Value: "item"},
+	}
+}
+
+func TestDecode(t *testing.T) {
+	record := streamtypes.Record{
+		EventName: streamtypes.OperationTypeModify,
+		Dynamodb: &streamtypes.StreamRecord{
+			Keys: map[string]streamtypes.AttributeValue{
+				"PK": &streamtypes.AttributeValueMemberS{Value: "item#1"},
+				"SK": &streamtypes.AttributeValueMemberS{Value: "item"},
+			},
+			OldImage:       streamImage("1", "old"),
+			NewImage:       streamImage("1", "new"),
+			SequenceNumber: aws.String("100"),
+		},
+	}
+	got, err := stream.Decode(testSchema(t), record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := stream.Change{
+		Kind:           stream.Modify,
+		Key:            (&Item{ID: "1"}).Gonetable_Key(),
+		Old:            &Item{ID: "1", Title: "old"},
+		New:            &Item{ID: "1", Title: "new"},
+		SequenceNumber: "100",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %+v, want %+v", got, want)
+	}
+
+	record.EventName = "TRUNCATE"
+	if _, err := stream.Decode(testSchema(t), record); !errors.Is(err, stream.ErrEventName) {
+		t.Errorf("error = %v, want %v", err, stream.ErrEventName)
+	}
+}
+
+const lambdaEvent = `{
+  "Records": [
+    {
+      "eventID": "1",
+      "eventName": "INSERT",
+      "eventVersion": "1.1",
+      "eventSource": "aws:dynamodb",
+      "awsRegion": "eu-west-1",
+      "dynamodb": {
+        "Keys": {"PK": {"S": "item#1"}, "SK": {"S": "item"}},
+        "NewImage": {
+          "PK": {"S": "item#1"}, "SK": {"S": "item"},
+          "ID": {"S": "1"}, "Title": {"S": "new"}, "_Type": {"S": "item"}
+        },
+        "SequenceNumber": "111",
+        "SizeBytes": 26,
+        "StreamViewType": "NEW_AND_OLD_IMAGES"
+      },
+      "eventSourceARN": "arn:aws:dynamodb:eu-west-1:123456789012:table/test/stream/2022-01-01T00:00:00.000"
+    },
+    {
+      "eventID": "2",
+      "eventName": "REMOVE",
+      "dynamodb": {
+        "Keys": {"PK": {"S": "item#2"}, "SK": {"S": "item"}},
+        "OldImage": {"PK": {"S": "item#2"}, "SK": {"S": "item"}, "Title": {"S": "x"}},
+        "SequenceNumber": "222"
+      }
+    }
+  ]
+}`
+
+func TestDecodeEvent(t *testing.T) {
+	event := stream.Event{}
+	if err := json.Unmarshal([]byte(lambdaEvent), &event); err != nil {
+		t.Fatal(err)
+	}
+	got, err := stream.DecodeEvent(testSchema(t), event.Records[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := stream.Change{
+		Kind:           stream.Insert,
+		Key:            (&Item{ID: "1"}).Gonetable_Key(),
+		New:            &Item{ID: "1", Title: "new"},
+		SequenceNumber: "111",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeEvent() = %+v, want %+v", got, want)
+	}
+
+	// old image is not a gonetable document
+	if _, err := stream.DecodeEvent(testSchema(t), event.Records[1]); !errors.Is(err, gonetable.ErrNoTypeAttribute) {
+		t.Errorf("error = %v, want %v", err, gonetable.ErrNoTypeAttribute)
+	}
+}
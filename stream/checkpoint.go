@@ -0,0 +1,30 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/juranki/gonetable/internal/checkpoint"
+)
+
+// ShardCheckpoint tells how far a shard has been processed.
+// SequenceNumber is the last processed record, and Closed is set when
+// all records of a closed shard have been processed.
+type ShardCheckpoint struct {
+	SequenceNumber string `json:"sequenceNumber,omitempty"`
+	Closed         bool   `json:"closed,omitempty"`
+}
+
+// CheckpointStore persists progress of shards by shard id, so that
+// StreamConsumer can continue where it stopped.
+type CheckpointStore interface {
+	Load(ctx context.Context) (map[string]ShardCheckpoint, error)
+	Save(ctx context.Context, shardID string, checkpoint ShardCheckpoint) error
+}
+
+// MemoryCheckpoints keeps shard checkpoints in memory. It is the
+// default store of StreamConsumer.
+type MemoryCheckpoints = checkpoint.Memory[string, ShardCheckpoint]
+
+// FileCheckpoints keeps shard checkpoints in a JSON file, so that the
+// consumer can continue after restart.
+type FileCheckpoints = checkpoint.File[string, ShardCheckpoint]
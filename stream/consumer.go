@@ -0,0 +1,282 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/juranki/gonetable"
+)
+
+var ErrNoStream = errors.New("table has no stream")
+
+// StreamsClient is the part of dynamodbstreams.Client that
+// StreamConsumer uses.
+type StreamsClient interface {
+	DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error)
+	GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error)
+}
+
+// StreamConsumer reads the stream of a table with DynamoDB Streams API
+// and feeds the changes to a router. It is meant for development and
+// tests, e.g. against DynamoDB Local, where there is no Lambda event
+// source mapping.
+//
+// Shards are processed one at a time, and a child shard is processed
+// only after its parent is closed and fully processed, so changes to
+// an item are handled in order also when shards split. Shards without
+// a checkpoint are read from the oldest record.
+//
+// When a handler fails, Run returns the error. Checkpoint is at the
+// last successful record, so the failed record is handled again on
+// the next run.
+type StreamConsumer struct {
+	Client StreamsClient
+	Router *Router
+	// Stream to read. If empty, the latest stream of Table is used.
+	StreamArn string
+	Table     *gonetable.Table
+	// Default keeps checkpoints in memory
+	Checkpoints CheckpointStore
+	// Wait between polls when there are no new records, default 1s
+	PollInterval time.Duration
+	// Maximum number of records per GetRecords call
+	Limit int32
+
+	checkpoints map[string]ShardCheckpoint
+	iterators   map[string]string
+}
+
+// Run polls the stream until context is cancelled or a handler fails.
+func (c *StreamConsumer) Run(ctx context.Context) error {
+	interval := c.PollInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+	for {
+		n, err := c.Poll(ctx)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Poll reads available records from all shards whose turn it is, and
+// returns the number of records handled.
+func (c *StreamConsumer) Poll(ctx context.Context) (int, error) {
+	if err := c.init(ctx); err != nil {
+		return 0, err
+	}
+	shards, err := c.shards(ctx)
+	if err != nil {
+		return 0, err
+	}
+	handled := 0
+	for {
+		// closing a shard can make its children ready
+		progressed := false
+		for _, shard := range shards {
+			id := aws.ToString(shard.ShardId)
+			if c.checkpoints[id].Closed || !c.parentClosed(shard, shards) {
+				continue
+			}
+			n, closed, err := c.readShard(ctx, id)
+			handled += n
+			if err != nil {
+				return handled, err
+			}
+			progressed = progressed || closed
+		}
+		if !progressed {
+			return handled, nil
+		}
+	}
+}
+
+func (c *StreamConsumer) init(ctx context.Context) error {
+	if c.Checkpoints == nil {
+		c.Checkpoints = &MemoryCheckpoints{}
+	}
+	if c.checkpoints == nil {
+		checkpoints, err := c.Checkpoints.Load(ctx)
+		if err != nil {
+			return err
+		}
+		c.checkpoints = checkpoints
+		c.iterators = map[string]string{}
+	}
+	if c.StreamArn == "" {
+		out, err := c.Table.Client().DescribeTable(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(c.Table.Name()),
+		})
+		if err != nil {
+			return err
+		}
+		if out.Table.LatestStreamArn == nil {
+			return ErrNoStream
+		}
+		c.StreamArn = *out.Table.LatestStreamArn
+	}
+	return nil
+}
+
+// shards lists shards of the stream ordered by their first sequence
+// number
+func (c *StreamConsumer) shards(ctx context.Context) ([]streamtypes.Shard, error) {
+	shards := []streamtypes.Shard{}
+	var start *string
+	for {
+		out, err := c.Client.DescribeStream(ctx, &dynamodbstreams.DescribeStreamInput{
+			StreamArn:             aws.String(c.StreamArn),
+			ExclusiveStartShardId: start,
+		})
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, out.StreamDescription.Shards...)
+		start = out.StreamDescription.LastEvaluatedShardId
+		if start == nil {
+			break
+		}
+	}
+	sort.SliceStable(shards, func(i, j int) bool {
+		return lessSequenceNumber(startingSequenceNumber(shards[i]), startingSequenceNumber(shards[j]))
+	})
+	return shards, nil
+}
+
+func startingSequenceNumber(shard streamtypes.Shard) string {
+	if shard.SequenceNumberRange == nil {
+		return ""
+	}
+	return aws.ToString(shard.SequenceNumberRange.StartingSequenceNumber)
+}
+
+// lessSequenceNumber compares sequence numbers, which are decimal
+// strings of varying length
+func lessSequenceNumber(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// parentClosed tells whether the parent of the shard has been fully
+// processed. Parents that have been trimmed from the stream count as
+// processed.
+func (c *StreamConsumer) parentClosed(shard streamtypes.Shard, shards []streamtypes.Shard) bool {
+	parent := aws.ToString(shard.ParentShardId)
+	if parent == "" || c.checkpoints[parent].Closed {
+		return true
+	}
+	for _, s := range shards {
+		if aws.ToString(s.ShardId) == parent {
+			return false
+		}
+	}
+	return true
+}
+
+// readShard handles records until the shard has no more records
+// available. Reports whether the shard was closed.
+func (c *StreamConsumer) readShard(ctx context.Context, shardID string) (int, bool, error) {
+	handled := 0
+	for {
+		iterator, err := c.iterator(ctx, shardID)
+		if err != nil {
+			return handled, false, err
+		}
+		out, err := c.Client.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{
+			ShardIterator: aws.String(iterator),
+			Limit:         c.limit(),
+		})
+		var expired *streamtypes.ExpiredIteratorException
+		if errors.As(err, &expired) {
+			delete(c.iterators, shardID)
+			continue
+		}
+		if err != nil {
+			return handled, false, err
+		}
+		for _, record := range out.Records {
+			if err := c.handle(ctx, record); err != nil {
+				// next run starts from the checkpoint
+				delete(c.iterators, shardID)
+				return handled, false, err
+			}
+			handled++
+			checkpoint := ShardCheckpoint{SequenceNumber: aws.ToString(record.Dynamodb.SequenceNumber)}
+			if err := c.save(ctx, shardID, checkpoint); err != nil {
+				return handled, false, err
+			}
+		}
+		if out.NextShardIterator == nil {
+			delete(c.iterators, shardID)
+			checkpoint := c.checkpoints[shardID]
+			checkpoint.Closed = true
+			return handled, true, c.save(ctx, shardID, checkpoint)
+		}
+		c.iterators[shardID] = *out.NextShardIterator
+		if len(out.Records) == 0 {
+			return handled, false, nil
+		}
+	}
+}
+
+func (c *StreamConsumer) iterator(ctx context.Context, shardID string) (string, error) {
+	if iterator, ok := c.iterators[shardID]; ok {
+		return iterator, nil
+	}
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(c.StreamArn),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: streamtypes.ShardIteratorTypeTrimHorizon,
+	}
+	if seq := c.checkpoints[shardID].SequenceNumber; seq != "" {
+		input.ShardIteratorType = streamtypes.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = aws.String(seq)
+	}
+	out, err := c.Client.GetShardIterator(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	c.iterators[shardID] = aws.ToString(out.ShardIterator)
+	return c.iterators[shardID], nil
+}
+
+func (c *StreamConsumer) handle(ctx context.Context, record streamtypes.Record) error {
+	change, err := Decode(c.Router.schema, record)
+	if Skippable(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return c.Router.Dispatch(ctx, change)
+}
+
+func (c *StreamConsumer) save(ctx context.Context, shardID string, checkpoint ShardCheckpoint) error {
+	c.checkpoints[shardID] = checkpoint
+	return c.Checkpoints.Save(ctx, shardID, checkpoint)
+}
+
+func (c *StreamConsumer) limit() *int32 {
+	if c.Limit == 0 {
+		return nil
+	}
+	return aws.Int32(c.Limit)
+}
@@ -0,0 +1,162 @@
+package stream_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/juranki/gonetable/stream"
+)
+
+type testShard struct {
+	id      string
+	parent  string
+	closed  bool
+	records []streamtypes.Record
+}
+
+// streamsStub serves shards one record per GetRecords call. Iterators
+// are "<shard>:<position>".
+type streamsStub struct {
+	shards []*testShard
+}
+
+func (ss *streamsStub) shard(id string) *testShard {
+	for _, s := range ss.shards {
+		if s.id == id {
+			return s
+		}
+	}
+	panic("no shard " + id)
+}
+
+func (ss *streamsStub) DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+	out := &dynamodbstreams.DescribeStreamOutput{StreamDescription: &streamtypes.StreamDescription{}}
+	for _, s := range ss.shards {
+		shard := streamtypes.Shard{
+			ShardId: aws.String(s.id),
+			SequenceNumberRange: &streamtypes.SequenceNumberRange{
+				StartingSequenceNumber: s.records[0].Dynamodb.SequenceNumber,
+			},
+		}
+		if s.parent != "" {
+			shard.ParentShardId = aws.String(s.parent)
+		}
+		out.StreamDescription.Shards = append(out.StreamDescription.Shards, shard)
+	}
+	return out, nil
+}
+
+func (ss *streamsStub) GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	pos := 0
+	if params.ShardIteratorType == streamtypes.ShardIteratorTypeAfterSequenceNumber {
+		for i, r := range ss.shard(*params.ShardId).records {
+			if *r.Dynamodb.SequenceNumber == *params.SequenceNumber {
+				pos = i + 1
+			}
+		}
+	}
+	return &dynamodbstreams.GetShardIteratorOutput{
+		ShardIterator: aws.String(fmt.Sprintf("%s:%d", *params.ShardId, pos)),
+	}, nil
+}
+
+func (ss *streamsStub) GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+	parts := strings.Split(*params.ShardIterator, ":")
+	pos, _ := strconv.Atoi(parts[1])
+	shard := ss.shard(parts[0])
+	out := &dynamodbstreams.GetRecordsOutput{}
+	if pos < len(shard.records) {
+		out.Records = shard.records[pos : pos+1]
+		pos++
+	}
+	if pos < len(shard.records) || !shard.closed || len(out.Records) > 0 {
+		out.NextShardIterator = aws.String(fmt.Sprintf("%s:%d", shard.id, pos))
+	}
+	return out, nil
+}
+
+func insertRecord(seq, id, title string) streamtypes.Record {
+	return streamtypes.Record{
+		EventName: streamtypes.OperationTypeInsert,
+		Dynamodb: &streamtypes.StreamRecord{
+			Keys: map[string]streamtypes.AttributeValue{
+				"PK": &streamtypes.AttributeValueMemberS{Value: "item#" + id},
+				"SK": &streamtypes.AttributeValueMemberS{Value: "item"},
+			},
+			NewImage:       streamImage(id, title),
+			SequenceNumber: aws.String(seq),
+		},
+	}
+}
+
+func TestStreamConsumer_Poll(t *testing.T) {
+	stub := &streamsStub{shards: []*testShard{
+		// children are listed first, but must wait for the parent
+		{id: "child1", parent: "root", records: []streamtypes.Record{insertRecord("300", "3", "c")}},
+		{id: "child2", parent: "root", records: []streamtypes.Record{insertRecord("400", "4", "d")}},
+		{id: "root", closed: true, records: []streamtypes.Record{
+			insertRecord("98", "1", "a"),
+			insertRecord("99", "2", "fail"),
+			insertRecord("100", "2", "b"),
+		}},
+	}}
+	handled := []string{}
+	fail := true
+	router := stream.NewRouter(testSchema(t))
+	stream.OnInsert(router, func(ctx context.Context, doc *Item) error {
+		if doc.Title == "fail" && fail {
+			return errors.New("failed")
+		}
+		handled = append(handled, doc.Title)
+		return nil
+	})
+	checkpoints := &stream.MemoryCheckpoints{}
+	consumer := &stream.StreamConsumer{
+		Client:      stub,
+		Router:      router,
+		StreamArn:   "arn",
+		Checkpoints: checkpoints,
+	}
+	ctx := context.Background()
+	if _, err := consumer.Poll(ctx); err == nil {
+		t.Fatal("expected handler error")
+	}
+	got, _ := checkpoints.Load(ctx)
+	if !reflect.DeepEqual(got, map[string]stream.ShardCheckpoint{"root": {SequenceNumber: "98"}}) {
+		t.Errorf("checkpoints = %v", got)
+	}
+
+	fail = false
+	n, err := consumer.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || !reflect.DeepEqual(handled, []string{"a", "fail", "b", "c", "d"}) {
+		t.Errorf("handled %d: %v", n, handled)
+	}
+	got, _ = checkpoints.Load(ctx)
+	want := map[string]stream.ShardCheckpoint{
+		"root":   {SequenceNumber: "100", Closed: true},
+		"child1": {SequenceNumber: "300"},
+		"child2": {SequenceNumber: "400"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("checkpoints = %v, want %v", got, want)
+	}
+
+	// new consumer continues from checkpoints
+	stub.shards[0].records = append(stub.shards[0].records, insertRecord("301", "5", "e"))
+	handled = nil
+	consumer = &stream.StreamConsumer{Client: stub, Router: router, StreamArn: "arn", Checkpoints: checkpoints}
+	if n, err := consumer.Poll(ctx); err != nil || n != 1 || !reflect.DeepEqual(handled, []string{"e"}) {
+		t.Errorf("handled %d: %v, error = %v", n, handled, err)
+	}
+}
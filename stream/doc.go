@@ -0,0 +1,3 @@
+// Package stream decodes DynamoDB Streams records of a gonetable table
+// into typed document changes.
+package stream
@@ -0,0 +1,42 @@
+package stream
+
+import (
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/internal/ddbjson"
+)
+
+// Event is the JSON payload that Lambda receives from a DynamoDB
+// stream event source mapping. It has the same shape as
+// events.DynamoDBEvent of aws-lambda-go, so the same JSON can be
+// unmarshaled into either.
+type Event struct {
+	Records []EventRecord `json:"Records"`
+}
+
+// EventRecord is a stream record in Lambda event.
+type EventRecord struct {
+	AWSRegion      string            `json:"awsRegion"`
+	Change         EventStreamRecord `json:"dynamodb"`
+	EventID        string            `json:"eventID"`
+	EventName      string            `json:"eventName"`
+	EventSource    string            `json:"eventSource"`
+	EventVersion   string            `json:"eventVersion"`
+	EventSourceArn string            `json:"eventSourceARN"`
+}
+
+// EventStreamRecord has keys and images of an item in DynamoDB JSON.
+type EventStreamRecord struct {
+	ApproximateCreationDateTime float64     `json:"ApproximateCreationDateTime,omitempty"`
+	Keys                        ddbjson.Map `json:"Keys,omitempty"`
+	NewImage                    ddbjson.Map `json:"NewImage,omitempty"`
+	OldImage                    ddbjson.Map `json:"OldImage,omitempty"`
+	SequenceNumber              string      `json:"SequenceNumber"`
+	SizeBytes                   int64       `json:"SizeBytes"`
+	StreamViewType              string      `json:"StreamViewType"`
+}
+
+// DecodeEvent decodes record of Lambda event, see Decode.
+func DecodeEvent(schema *gonetable.Schema, record EventRecord) (Change, error) {
+	return decode(schema, record.EventName, record.Change.SequenceNumber,
+		record.Change.Keys, record.Change.OldImage, record.Change.NewImage)
+}
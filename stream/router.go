@@ -0,0 +1,135 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/juranki/gonetable"
+)
+
+// Router calls handlers registered for document types and change
+// kinds. Register handlers with OnInsert, OnModify and OnRemove.
+//
+// Changes are routed by the Go type of their image, New for inserts
+// and modifies and Old for removes, so the stream must include the
+// images (NEW_IMAGE, OLD_IMAGE or NEW_AND_OLD_IMAGES).
+type Router struct {
+	// Called with the record that failed in HandleEvent, e.g. for
+	// logging
+	OnError  func(ctx context.Context, sequenceNumber string, err error)
+	schema   *gonetable.Schema
+	handlers map[routeKey][]func(context.Context, Change) error
+}
+
+type routeKey struct {
+	kind   ChangeKind
+	goType reflect.Type
+}
+
+// BatchResponse is the partial batch response of a Lambda function,
+// the same shape as events.DynamoDBEventResponse of aws-lambda-go.
+type BatchResponse struct {
+	BatchItemFailures []BatchItemFailure `json:"batchItemFailures"`
+}
+
+// BatchItemFailure identifies failed record by its sequence number.
+type BatchItemFailure struct {
+	ItemIdentifier string `json:"itemIdentifier"`
+}
+
+// NewRouter returns router that decodes records with the schema.
+func NewRouter(schema *gonetable.Schema) *Router {
+	return &Router{
+		schema:   schema,
+		handlers: map[routeKey][]func(context.Context, Change) error{},
+	}
+}
+
+// OnInsert registers handler for new documents of type T.
+func OnInsert[T gonetable.Document](r *Router, fn func(ctx context.Context, doc T) error) {
+	r.add(Insert, typeOf[T](), func(ctx context.Context, c Change) error {
+		return fn(ctx, c.New.(T))
+	})
+}
+
+// OnModify registers handler for modified documents of type T. Old is
+// the zero value of T if the stream doesn't include old images.
+func OnModify[T gonetable.Document](r *Router, fn func(ctx context.Context, old, new T) error) {
+	r.add(Modify, typeOf[T](), func(ctx context.Context, c Change) error {
+		old, _ := c.Old.(T)
+		return fn(ctx, old, c.New.(T))
+	})
+}
+
+// OnRemove registers handler for removed documents of type T.
+func OnRemove[T gonetable.Document](r *Router, fn func(ctx context.Context, doc T) error) {
+	r.add(Remove, typeOf[T](), func(ctx context.Context, c Change) error {
+		return fn(ctx, c.Old.(T))
+	})
+}
+
+func (r *Router) add(kind ChangeKind, goType reflect.Type, handler func(context.Context, Change) error) {
+	key := routeKey{kind: kind, goType: goType}
+	r.handlers[key] = append(r.handlers[key], handler)
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Dispatch calls the handlers of the change in registration order and
+// returns the first error. Changes without handlers are ignored.
+func (r *Router) Dispatch(ctx context.Context, change Change) error {
+	doc := change.New
+	if change.Kind == Remove {
+		doc = change.Old
+	}
+	if doc == nil {
+		return nil
+	}
+	for _, handler := range r.handlers[routeKey{kind: change.Kind, goType: reflect.TypeOf(doc)}] {
+		if err := handler(ctx, change); err != nil {
+			return fmt.Errorf("%s %s: %w", change.Kind, keyString(change.Key), err)
+		}
+	}
+	return nil
+}
+
+// HandleEvent decodes and dispatches records of Lambda event in order.
+// It can be used as the body of a Lambda handler with
+// ReportBatchItemFailures enabled.
+//
+// Processing stops at the first record that fails, because Lambda
+// retries the stream from the lowest failed sequence number, and
+// handling later records now would only process them twice. Records
+// that are not gonetable documents, or whose type is not in the
+// schema, are skipped.
+func (r *Router) HandleEvent(ctx context.Context, event Event) (BatchResponse, error) {
+	resp := BatchResponse{BatchItemFailures: []BatchItemFailure{}}
+	for _, record := range event.Records {
+		change, err := DecodeEvent(r.schema, record)
+		if err == nil {
+			err = r.Dispatch(ctx, change)
+		} else if Skippable(err) {
+			err = nil
+		}
+		if err != nil {
+			if r.OnError != nil {
+				r.OnError(ctx, record.Change.SequenceNumber, err)
+			}
+			resp.BatchItemFailures = append(resp.BatchItemFailures, BatchItemFailure{
+				ItemIdentifier: record.Change.SequenceNumber,
+			})
+			break
+		}
+	}
+	return resp, nil
+}
+
+// Skippable tells whether decode error is caused by an item that is
+// not a document of the schema.
+func Skippable(err error) bool {
+	return errors.Is(err, gonetable.ErrNoTypeAttribute) || errors.Is(err, gonetable.ErrUnknownType)
+}
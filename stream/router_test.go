@@ -0,0 +1,103 @@
+package stream_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/juranki/gonetable/stream"
+)
+
+const routerEvent = `{
+  "Records": [
+    {
+      "eventName": "INSERT",
+      "dynamodb": {
+        "Keys": {"PK": {"S": "item#1"}, "SK": {"S": "item"}},
+        "NewImage": {"ID": {"S": "1"}, "Title": {"S": "a"}, "_Type": {"S": "item"}},
+        "SequenceNumber": "1"
+      }
+    },
+    {
+      "eventName": "MODIFY",
+      "dynamodb": {
+        "Keys": {"PK": {"S": "other"}, "SK": {"S": "other"}},
+        "NewImage": {"PK": {"S": "other"}, "SK": {"S": "other"}},
+        "SequenceNumber": "2"
+      }
+    },
+    {
+      "eventName": "MODIFY",
+      "dynamodb": {
+        "Keys": {"PK": {"S": "item#1"}, "SK": {"S": "item"}},
+        "OldImage": {"ID": {"S": "1"}, "Title": {"S": "a"}, "_Type": {"S": "item"}},
+        "NewImage": {"ID": {"S": "1"}, "Title": {"S": "fail"}, "_Type": {"S": "item"}},
+        "SequenceNumber": "3"
+      }
+    },
+    {
+      "eventName": "REMOVE",
+      "dynamodb": {
+        "Keys": {"PK": {"S": "item#1"}, "SK": {"S": "item"}},
+        "OldImage": {"ID": {"S": "1"}, "Title": {"S": "fail"}, "_Type": {"S": "item"}},
+        "SequenceNumber": "4"
+      }
+    }
+  ]
+}`
+
+func TestRouter_HandleEvent(t *testing.T) {
+	event := stream.Event{}
+	if err := json.Unmarshal([]byte(routerEvent), &event); err != nil {
+		t.Fatal(err)
+	}
+	calls := []string{}
+	router := stream.NewRouter(testSchema(t))
+	stream.OnInsert(router, func(ctx context.Context, doc *Item) error {
+		calls = append(calls, "insert "+doc.Title)
+		return nil
+	})
+	stream.OnModify(router, func(ctx context.Context, old, new *Item) error {
+		calls = append(calls, "modify "+old.Title+" -> "+new.Title)
+		if new.Title == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	stream.OnRemove(router, func(ctx context.Context, doc *Item) error {
+		calls = append(calls, "remove "+doc.Title)
+		return nil
+	})
+	failed := []string{}
+	router.OnError = func(ctx context.Context, sequenceNumber string, err error) {
+		failed = append(failed, sequenceNumber)
+	}
+
+	resp, err := router.HandleEvent(context.Background(), event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := stream.BatchResponse{BatchItemFailures: []stream.BatchItemFailure{{ItemIdentifier: "3"}}}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("HandleEvent() = %+v, want %+v", resp, want)
+	}
+	if !reflect.DeepEqual(calls, []string{"insert a", "modify a -> fail"}) {
+		t.Errorf("calls = %v", calls)
+	}
+	if !reflect.DeepEqual(failed, []string{"3"}) {
+		t.Errorf("OnError calls = %v", failed)
+	}
+
+	// retried batch starts from the failed record
+	event.Records = event.Records[2:]
+	event.Records[0].Change.NewImage["Title"] = event.Records[0].Change.OldImage["Title"]
+	calls = nil
+	if resp, _ := router.HandleEvent(context.Background(), event); len(resp.BatchItemFailures) != 0 {
+		t.Errorf("failures = %v", resp.BatchItemFailures)
+	}
+	if !reflect.DeepEqual(calls, []string{"modify a -> a", "remove fail"}) {
+		t.Errorf("calls = %v", calls)
+	}
+}
@@ -0,0 +1,387 @@
+package gonetable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable/internal/batch"
+)
+
+var (
+	ErrNotFound       = errors.New("item not found")
+	ErrQueryOutput    = errors.New("query output must be pointer to slice")
+	ErrNoHashSegments = errors.New("query needs hash segments")
+	ErrConsistentRead = errors.New("global secondary indeces don't support consistent reads")
+)
+
+// DynamoDB allows at most 100 keys in one BatchGetItem call
+const maxBatchGetKeys = 100
+
+// Client is the subset of DynamoDB API that Table uses.
+// *dynamodb.Client implements it.
+type Client interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error)
+}
+
+// Table reads and writes documents of a schema to a DynamoDB table.
+type Table struct {
+	name            string
+	schema          *Schema
+	client          Client
+	upcastWriteBack bool
+}
+
+// TableOption modifies how Table works.
+type TableOption func(*Table)
+
+// WithUpcastWriteBack makes Get and Query write upcasted documents
+// back to the table, so that old versions are gradually replaced.
+// Only the attributes changed by upcasting are updated, and only if
+// none of the attributes that were read has changed since.
+func WithUpcastWriteBack() TableOption {
+	return func(t *Table) {
+		t.upcastWriteBack = true
+	}
+}
+
+func NewTable(name string, schema *Schema, client Client, opts ...TableOption) *Table {
+	t := &Table{
+		name:   name,
+		schema: schema,
+		client: client,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *Table) Name() string       { return t.name }
+func (t *Table) Schema() *Schema    { return t.schema }
+func (t *Table) Client() Client     { return t.client }
+func (t *Table) tableName() *string { return aws.String(t.name) }
+
+// Put writes document to the table, replacing existing document
+// with the same key.
+func (t *Table) Put(ctx context.Context, doc Document) error {
+	item, err := t.schema.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = t.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: t.tableName(),
+		Item:      item,
+	})
+	return err
+}
+
+// Get reads document with the key from the table. Returns ErrNotFound
+// if there is no such document.
+func (t *Table) Get(ctx context.Context, key CompositeKey) (Document, error) {
+	keyAV, err := key.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	out, err := t.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: t.tableName(),
+		Key:       keyAV,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, ErrNotFound
+	}
+	return t.unmarshal(ctx, out.Item)
+}
+
+// unmarshal decodes item read from the table, and writes it back if
+// it was upcasted and write back is enabled.
+func (t *Table) unmarshal(ctx context.Context, item map[string]types.AttributeValue) (Document, error) {
+	doc, upcasted, err := t.schema.unmarshal(item)
+	if err != nil || upcasted == nil || !t.upcastWriteBack {
+		return doc, err
+	}
+	upgraded, err := t.schema.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	input := t.upcastUpdate(item, upcasted, upgraded)
+	if input == nil {
+		return doc, nil
+	}
+	_, err = t.client.UpdateItem(ctx, input)
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		// changed by someone else, who has hopefully upgraded it
+		return doc, nil
+	}
+	return doc, err
+}
+
+// upcastUpdate returns update from item to upgraded, or nil if the
+// upgraded item has different key. Attributes that upcasters removed
+// are removed, and attributes that the document type doesn't know
+// about are left as is. The update is conditioned on all attributes of
+// the item being unchanged since it was read.
+func (t *Table) upcastUpdate(item, upcasted, upgraded map[string]types.AttributeValue) *dynamodb.UpdateItemInput {
+	if !reflect.DeepEqual(item["PK"], upgraded["PK"]) || !reflect.DeepEqual(item["SK"], upgraded["SK"]) {
+		return nil
+	}
+	attributes := map[string]bool{}
+	for k := range item {
+		attributes[k] = true
+	}
+	for k := range upgraded {
+		attributes[k] = true
+	}
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+	set, remove, conditions := []string{}, []string{}, []string{}
+	for i, attr := range sortedKeys(attributes) {
+		name := fmt.Sprintf("#a%d", i)
+		names[name] = attr
+		read, inItem := item[attr]
+		want, inUpgraded := upgraded[attr]
+		_, inUpcasted := upcasted[attr]
+		if inItem {
+			values[fmt.Sprintf(":o%d", i)] = read
+			conditions = append(conditions, fmt.Sprintf("%s = :o%d", name, i))
+		}
+		switch {
+		case inUpgraded && !(inItem && reflect.DeepEqual(read, want)):
+			values[fmt.Sprintf(":n%d", i)] = want
+			set = append(set, fmt.Sprintf("%s = :n%d", name, i))
+		case inItem && !inUpgraded && !inUpcasted:
+			remove = append(remove, name)
+		}
+	}
+	update := "SET " + strings.Join(set, ", ")
+	if len(remove) > 0 {
+		update += " REMOVE " + strings.Join(remove, ", ")
+	}
+	return &dynamodb.UpdateItemInput{
+		TableName:                 t.tableName(),
+		Key:                       map[string]types.AttributeValue{"PK": item["PK"], "SK": item["SK"]},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(strings.Join(conditions, " AND ")),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+}
+
+// Delete removes document with the key from the table.
+func (t *Table) Delete(ctx context.Context, key CompositeKey) error {
+	keyAV, err := key.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = t.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: t.tableName(),
+		Key:       keyAV,
+	})
+	return err
+}
+
+// Query selects items from the table or from one of its indeces.
+//
+// Items are selected by exact match of hash segments and, if
+// range prefix is given, by prefix match of range segments. Segments
+// of the range prefix are joined with KeyDelimiter and matched with
+// begins_with, so the last segment is a string prefix: {"ed"} matches
+// both "ed#1" and "editor#1". End the prefix with an empty segment,
+// e.g. {"ed", ""}, to match only keys that continue after complete
+// "ed" segment; it doesn't match key "ed" itself.
+type Query struct {
+	// Index to query, empty for the table
+	Index        string
+	HashSegments []string
+	RangePrefix  []string
+	Descending   bool
+	// Not supported by global secondary indeces
+	ConsistentRead bool
+	// Maximum number of items returned, 0 for no limit
+	Limit int
+}
+
+// Query returns documents matching the query.
+//
+// Indeces that don't project all attributes return partial items.
+// Query reads full documents for them from the table with BatchGetItem.
+// Use QueryProjection to read just the projected attributes.
+func (t *Table) Query(ctx context.Context, q Query) ([]Document, error) {
+	items, err := t.queryItems(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if q.Index != "" && t.schema.projection(q.Index).Type != types.ProjectionTypeAll {
+		items, err = t.batchGetItems(ctx, items)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rv := make([]Document, len(items))
+	for i, item := range items {
+		rv[i], err = t.unmarshal(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+// QueryProjection decodes items matching the query to out, that
+// must be pointer to slice of structs. It is meant for reading partial
+// items from indeces with KEYS_ONLY or INCLUDE projection, typically
+// to the same struct type that was given to IncludeProjection.
+func (t *Table) QueryProjection(ctx context.Context, q Query, out interface{}) error {
+	if v := reflect.ValueOf(out); v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Slice {
+		return ErrQueryOutput
+	}
+	items, err := t.queryItems(ctx, q)
+	if err != nil {
+		return err
+	}
+	return attributevalue.UnmarshalListOfMaps(items, out)
+}
+
+func (t *Table) queryItems(ctx context.Context, q Query) ([]map[string]types.AttributeValue, error) {
+	input, err := t.queryInput(q)
+	if err != nil {
+		return nil, err
+	}
+	items := []map[string]types.AttributeValue{}
+	for {
+		out, err := t.client.Query(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, out.Items...)
+		if q.Limit > 0 && len(items) >= q.Limit {
+			return items[:q.Limit], nil
+		}
+		if out.LastEvaluatedKey == nil {
+			return items, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+func (t *Table) queryInput(q Query) (*dynamodb.QueryInput, error) {
+	if len(q.HashSegments) == 0 {
+		return nil, ErrNoHashSegments
+	}
+	pkName, skName := "PK", "SK"
+	switch {
+	case q.Index == "":
+	case contains(t.schema.indeces, q.Index):
+		if q.ConsistentRead {
+			return nil, &SchemaError{Index: q.Index, Reason: ErrConsistentRead}
+		}
+		pkName, skName = q.Index+"PK", q.Index+"SK"
+	case contains(t.schema.localIndeces, q.Index):
+		skName = q.Index + "SK"
+	default:
+		return nil, &SchemaError{Index: q.Index, Reason: ErrUnknownIndex}
+	}
+	pk, err := joinKeySegments(pkName, q.HashSegments)
+	if err != nil {
+		return nil, err
+	}
+	input := &dynamodb.QueryInput{
+		TableName:              t.tableName(),
+		KeyConditionExpression: aws.String("#pk = :pk"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk": pkName,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: pk},
+		},
+		ScanIndexForward: aws.Bool(!q.Descending),
+	}
+	if q.Index != "" {
+		input.IndexName = aws.String(q.Index)
+	}
+	if q.ConsistentRead {
+		input.ConsistentRead = aws.Bool(true)
+	}
+	if len(q.RangePrefix) > 0 {
+		for i, s := range q.RangePrefix {
+			if strings.Contains(s, KeyDelimiter) {
+				return nil, &KeyError{Attribute: skName, Segment: i, Value: s, Err: ErrKeyDelimiter}
+			}
+		}
+		input.KeyConditionExpression = aws.String("#pk = :pk AND begins_with(#sk, :sk)")
+		input.ExpressionAttributeNames["#sk"] = skName
+		input.ExpressionAttributeValues[":sk"] = &types.AttributeValueMemberS{
+			Value: strings.Join(q.RangePrefix, KeyDelimiter),
+		}
+	}
+	if q.Limit > 0 {
+		input.Limit = aws.Int32(int32(q.Limit))
+	}
+	return input, nil
+}
+
+// batchGetItems reads full items from the table for partial items
+// read from an index. Order of the items is preserved, items that
+// were deleted in the meantime are dropped.
+func (t *Table) batchGetItems(ctx context.Context, partial []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	full := map[string]map[string]types.AttributeValue{}
+	for start := 0; start < len(partial); start += maxBatchGetKeys {
+		end := start + maxBatchGetKeys
+		if end > len(partial) {
+			end = len(partial)
+		}
+		keys := make([]map[string]types.AttributeValue, end-start)
+		for i, item := range partial[start:end] {
+			keys[i] = map[string]types.AttributeValue{"PK": item["PK"], "SK": item["SK"]}
+		}
+		out, err := batch.Get(ctx, t.client, map[string]types.KeysAndAttributes{
+			t.name: {Keys: keys},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range out[t.name] {
+			full[itemKey(item)] = item
+		}
+	}
+	rv := []map[string]types.AttributeValue{}
+	for _, item := range partial {
+		if fullItem, ok := full[itemKey(item)]; ok {
+			rv = append(rv, fullItem)
+		}
+	}
+	return rv, nil
+}
+
+// itemKey returns PK and SK of the item as a string
+func itemKey(item map[string]types.AttributeValue) string {
+	var pk, sk string
+	if v, ok := item["PK"].(*types.AttributeValueMemberS); ok {
+		pk = v.Value
+	}
+	if v, ok := item["SK"].(*types.AttributeValueMemberS); ok {
+		sk = v.Value
+	}
+	return fmt.Sprintf("%q %q", pk, sk)
+}
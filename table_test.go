@@ -0,0 +1,225 @@
+package gonetable_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/memddb"
+)
+
+// queryStub returns canned query results and full items by PK
+type queryStub struct {
+	gonetable.Client
+	queryItems []map[string]types.AttributeValue
+	fullItems  map[string]map[string]types.AttributeValue
+	queries    []*dynamodb.QueryInput
+}
+
+func (qs *queryStub) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	qs.queries = append(qs.queries, params)
+	return &dynamodb.QueryOutput{Items: qs.queryItems}, nil
+}
+
+func (qs *queryStub) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	out := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{}}
+	for table, req := range params.RequestItems {
+		for _, key := range req.Keys {
+			pk := key["PK"].(*types.AttributeValueMemberS).Value
+			if item, ok := qs.fullItems[pk]; ok {
+				out.Responses[table] = append(out.Responses[table], item)
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestTable_Query(t *testing.T) {
+	s, err := gonetable.NewSchema(
+		[]gonetable.Document{&WithIndex{}},
+		gonetable.WithProjection("GSI1", gonetable.KeysOnlyProjection()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &queryStub{
+		queryItems: []map[string]types.AttributeValue{
+			{
+				"PK":     MustMarshal("wi#b"),
+				"SK":     MustMarshal("wi"),
+				"GSI1PK": MustMarshal("wi#b"),
+				"GSI1SK": MustMarshal("wi"),
+			},
+			{
+				"PK":     MustMarshal("wi#a"),
+				"SK":     MustMarshal("wi"),
+				"GSI1PK": MustMarshal("wi#a"),
+				"GSI1SK": MustMarshal("wi"),
+			},
+		},
+		fullItems: map[string]map[string]types.AttributeValue{
+			"wi#a": {
+				"Name":  MustMarshal("a"),
+				"PK":    MustMarshal("wi#a"),
+				"SK":    MustMarshal("wi"),
+				"_Type": MustMarshal("wi1"),
+			},
+			"wi#b": {
+				"Name":  MustMarshal("b"),
+				"PK":    MustMarshal("wi#b"),
+				"SK":    MustMarshal("wi"),
+				"_Type": MustMarshal("wi1"),
+			},
+		},
+	}
+	table := gonetable.NewTable("test", s, stub)
+	got, err := table.Query(context.Background(), gonetable.Query{
+		Index:        "GSI1",
+		HashSegments: []string{"wi"},
+		RangePrefix:  []string{"wi"},
+		Descending:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gonetable.Document{&WithIndex{Name: "b"}, &WithIndex{Name: "a"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Table.Query() = %v, want %v", got, want)
+	}
+	wantInput := &dynamodb.QueryInput{
+		TableName:              aws.String("test"),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("#pk = :pk AND begins_with(#sk, :sk)"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk": "GSI1PK",
+			"#sk": "GSI1SK",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": MustMarshal("wi"),
+			":sk": MustMarshal("wi"),
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+	if !reflect.DeepEqual(stub.queries[0], wantInput) {
+		t.Errorf("QueryInput = %v, want %v", stub.queries[0], wantInput)
+	}
+}
+
+func TestTable_QueryProjection(t *testing.T) {
+	s, err := gonetable.NewSchema(
+		[]gonetable.Document{&WithIndex{}},
+		gonetable.WithProjection("GSI1", gonetable.IncludeProjection(&WithIndexSummary{})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &queryStub{
+		queryItems: []map[string]types.AttributeValue{
+			{
+				"PK":    MustMarshal("wi#a"),
+				"SK":    MustMarshal("wi"),
+				"name":  MustMarshal("a"),
+				"_Type": MustMarshal("wi1"),
+			},
+		},
+	}
+	table := gonetable.NewTable("test", s, stub)
+	got := []WithIndexSummary{}
+	err = table.QueryProjection(context.Background(), gonetable.Query{
+		Index:        "GSI1",
+		HashSegments: []string{"wi", "a"},
+	}, &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WithIndexSummary{{Name: "a"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Table.QueryProjection() = %v, want %v", got, want)
+	}
+	if err := table.QueryProjection(context.Background(), gonetable.Query{
+		Index:        "GSI1",
+		HashSegments: []string{"wi", "a"},
+	}, got); err != gonetable.ErrQueryOutput {
+		t.Errorf("error = %v, want %v", err, gonetable.ErrQueryOutput)
+	}
+}
+
+func TestTable_Query_RangePrefix(t *testing.T) {
+	ctx := context.Background()
+	s, err := gonetable.NewSchema([]gonetable.Document{&Order{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := memddb.New()
+	input, err := s.CreateTableInput("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateTable(ctx, input); err != nil {
+		t.Fatal(err)
+	}
+	table := gonetable.NewTable("test", s, client)
+	for _, n := range []int{1, 2, 10, 12} {
+		if err := table.Put(ctx, &Order{Customer: "c", Number: n}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		prefix []string
+		want   []int
+	}{
+		{[]string{"order", "1"}, []int{1, 10, 12}},
+		{[]string{"order", ""}, []int{1, 10, 12, 2}},
+		// "order#1#" is not a prefix of "order#1"
+		{[]string{"order", "1", ""}, []int{}},
+	}
+	for _, tt := range tests {
+		got, err := table.Query(ctx, gonetable.Query{
+			HashSegments: []string{"customer", "c"},
+			RangePrefix:  tt.prefix,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		numbers := []int{}
+		for _, doc := range got {
+			numbers = append(numbers, doc.(*Order).Number)
+		}
+		if !reflect.DeepEqual(numbers, tt.want) {
+			t.Errorf("RangePrefix %q matched %v, want %v", tt.prefix, numbers, tt.want)
+		}
+	}
+}
+
+func TestTable_Query_ConsistentRead(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&WithIndex{}, &WithLocalIndex{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &queryStub{}
+	table := gonetable.NewTable("test", s, stub)
+	_, err = table.Query(context.Background(), gonetable.Query{
+		Index:          "LSI1",
+		HashSegments:   []string{"wli", "a"},
+		ConsistentRead: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stub.queries[0].ConsistentRead; got == nil || !*got {
+		t.Errorf("LSI query ConsistentRead = %v, want true", got)
+	}
+	_, err = table.Query(context.Background(), gonetable.Query{
+		Index:          "GSI1",
+		HashSegments:   []string{"wi"},
+		ConsistentRead: true,
+	})
+	if !errors.Is(err, gonetable.ErrConsistentRead) {
+		t.Errorf("GSI query error = %v, want %v", err, gonetable.ErrConsistentRead)
+	}
+}
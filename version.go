@@ -0,0 +1,133 @@
+package gonetable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	ErrMissingUpcaster = errors.New("no upcaster for document version")
+	ErrUpcasterVersion = errors.New("upcaster version out of range")
+	ErrFutureVersion   = errors.New("document version is newer than the schema")
+)
+
+// Upcaster transforms attribute value map of a document from one
+// version to the next. It gets a copy of the map, so it can modify
+// and return it.
+type Upcaster func(av map[string]types.AttributeValue) (map[string]types.AttributeValue, error)
+
+// Versioned documents carry their version in _V attribute.
+//
+// Documents that don't implement Versioned are version 1, and items
+// without _V attribute are version 1 too, so versioning can be
+// introduced without touching the existing items. When the version of
+// a document type is increased, an upcaster from every earlier version
+// to the next must be registered with WithUpcaster.
+type Versioned interface {
+	Gonetable_Version() int
+}
+
+// WithUpcaster registers upcaster that transforms documents of the
+// type from version fromVersion to fromVersion+1 when they are
+// unmarshaled.
+func WithUpcaster(typeID string, fromVersion int, upcaster Upcaster) SchemaOption {
+	return func(o *schemaOptions) {
+		if o.upcasters == nil {
+			o.upcasters = map[string]map[int]Upcaster{}
+		}
+		if o.upcasters[typeID] == nil {
+			o.upcasters[typeID] = map[int]Upcaster{}
+		}
+		o.upcasters[typeID][fromVersion] = upcaster
+	}
+}
+
+// documentVersion returns the current version of document type
+func documentVersion(goType reflect.Type) int {
+	if v, ok := newDocumentValue(goType).Interface().(Versioned); ok {
+		return v.Gonetable_Version()
+	}
+	return 1
+}
+
+// newDocumentValue returns new document of the type, allocating the
+// struct if the type is pointer
+func newDocumentValue(goType reflect.Type) reflect.Value {
+	if goType.Kind() == reflect.Pointer {
+		return reflect.New(goType.Elem())
+	}
+	return reflect.New(goType).Elem()
+}
+
+// validateUpcasters checks that upcasters of document types form a
+// chain from version 1 to the current version.
+func (s *Schema) validateUpcasters(upcasters map[string]map[int]Upcaster) []*SchemaError {
+	errs := []*SchemaError{}
+	for _, typeID := range sortedKeys(upcasters) {
+		dt, ok := s.docTypes[typeID]
+		if !ok {
+			errs = append(errs, &SchemaError{TypeID: typeID, Reason: ErrUnknownType})
+			continue
+		}
+		for from := range upcasters[typeID] {
+			if from < 1 || from >= dt.version {
+				errs = append(errs, &SchemaError{
+					TypeID: typeID,
+					GoType: dt.goType.String(),
+					Reason: fmt.Errorf("%w: %d", ErrUpcasterVersion, from),
+				})
+			}
+		}
+	}
+	for _, typeID := range sortedKeys(s.docTypes) {
+		dt := s.docTypes[typeID]
+		for from := 1; from < dt.version; from++ {
+			if _, ok := upcasters[typeID][from]; !ok {
+				errs = append(errs, &SchemaError{
+					TypeID: typeID,
+					GoType: dt.goType.String(),
+					Reason: fmt.Errorf("%w: %d", ErrMissingUpcaster, from),
+				})
+			}
+		}
+	}
+	return errs
+}
+
+// upcast runs upcasters on attribute value map until it is at the
+// current version of the document type. Returns the original map if
+// it is already current.
+func (dt *docType) upcast(typeID string, av map[string]types.AttributeValue) (map[string]types.AttributeValue, bool, error) {
+	version := 1
+	if versionAV, ok := av["_V"]; ok {
+		if err := attributevalue.Unmarshal(versionAV, &version); err != nil {
+			return nil, false, err
+		}
+	}
+	if version > dt.version {
+		return nil, false, &SchemaError{
+			TypeID: typeID,
+			GoType: dt.goType.String(),
+			Reason: fmt.Errorf("%w: %d", ErrFutureVersion, version),
+		}
+	}
+	if version == dt.version {
+		return av, false, nil
+	}
+	for ; version < dt.version; version++ {
+		cp := make(map[string]types.AttributeValue, len(av))
+		for k, v := range av {
+			cp[k] = v
+		}
+		var err error
+		av, err = dt.upcasters[version](cp)
+		if err != nil {
+			return nil, false, fmt.Errorf("upcast %s from version %d: %w", typeID, version, err)
+		}
+	}
+	return av, true, nil
+}
@@ -0,0 +1,218 @@
+package gonetable_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/juranki/gonetable"
+	"github.com/juranki/gonetable/memddb"
+)
+
+func profileUpcasters() []gonetable.SchemaOption {
+	return []gonetable.SchemaOption{
+		gonetable.WithUpcaster("profile", 1, func(av map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+			av["Name"] = av["Nick"]
+			delete(av, "Nick")
+			return av, nil
+		}),
+		gonetable.WithUpcaster("profile", 2, func(av map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+			var tags string
+			if err := attributevalue.Unmarshal(av["Tags"], &tags); err != nil {
+				return nil, err
+			}
+			av["Tags"] = MustMarshal(strings.Split(tags, ","))
+			return av, nil
+		}),
+	}
+}
+
+func TestSchema_Upcast(t *testing.T) {
+	s, err := gonetable.NewSchema([]gonetable.Document{&Profile{}}, profileUpcasters()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marshaled, err := s.Marshal(&Profile{ID: "1", Name: "a", Tags: []string{"x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(marshaled["_V"], MustMarshal(3)) {
+		t.Errorf("_V = %v, want 3", marshaled["_V"])
+	}
+
+	v1 := map[string]types.AttributeValue{
+		"PK":    MustMarshal("profile#1"),
+		"SK":    MustMarshal("profile"),
+		"ID":    MustMarshal("1"),
+		"Nick":  MustMarshal("a"),
+		"Tags":  MustMarshal("x,y"),
+		"_Type": MustMarshal("profile"),
+	}
+	got, err := s.Unmarshal(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Profile{ID: "1", Name: "a", Tags: []string{"x", "y"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() = %v, want %v", got, want)
+	}
+	if _, ok := v1["Nick"]; !ok {
+		t.Error("upcaster modified the original map")
+	}
+
+	v1["_V"] = MustMarshal(4)
+	if _, err := s.Unmarshal(v1); !errors.Is(err, gonetable.ErrFutureVersion) {
+		t.Errorf("error = %v, want %v", err, gonetable.ErrFutureVersion)
+	}
+}
+
+func TestNewSchema_Upcasters(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []gonetable.SchemaOption
+		wantErr error
+	}{
+		{
+			name:    "missing upcasters",
+			wantErr: gonetable.ErrMissingUpcaster,
+		},
+		{
+			name: "upcaster from current version",
+			opts: append(profileUpcasters(),
+				gonetable.WithUpcaster("profile", 3, func(av map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+					return av, nil
+				})),
+			wantErr: gonetable.ErrUpcasterVersion,
+		},
+		{
+			name: "unknown type",
+			opts: append(profileUpcasters(),
+				gonetable.WithUpcaster("xxx", 1, func(av map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+					return av, nil
+				})),
+			wantErr: gonetable.ErrUnknownType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := gonetable.NewSchema([]gonetable.Document{&Profile{}}, tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// concurrentUpdater puts an item before the first update, as if
+// another writer changed it after it was read
+type concurrentUpdater struct {
+	*memddb.Client
+	item    map[string]types.AttributeValue
+	updates int
+}
+
+func (cu *concurrentUpdater) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	cu.updates++
+	if cu.item != nil {
+		if _, err := cu.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("test"), Item: cu.item}); err != nil {
+			return nil, err
+		}
+		cu.item = nil
+	}
+	return cu.Client.UpdateItem(ctx, params, optFns...)
+}
+
+func profileV1(name string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK":    MustMarshal("profile#1"),
+		"SK":    MustMarshal("profile"),
+		"ID":    MustMarshal("1"),
+		"Nick":  MustMarshal(name),
+		"Tags":  MustMarshal("x"),
+		"Extra": MustMarshal("kept"),
+		"_Type": MustMarshal("profile"),
+	}
+}
+
+func TestTable_UpcastWriteBack(t *testing.T) {
+	ctx := context.Background()
+	s, err := gonetable.NewSchema([]gonetable.Document{&Profile{}}, profileUpcasters()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := (&Profile{ID: "1"}).Gonetable_Key()
+	keyAV, err := key.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newClient := func(t *testing.T) *concurrentUpdater {
+		client := &concurrentUpdater{Client: memddb.New()}
+		input, err := s.CreateTableInput("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.CreateTable(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("test"), Item: profileV1("a")}); err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+	stored := func(t *testing.T, client *concurrentUpdater) map[string]types.AttributeValue {
+		out, err := client.GetItem(ctx, &dynamodb.GetItemInput{TableName: aws.String("test"), Key: keyAV})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out.Item
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		client := newClient(t)
+		if _, err := gonetable.NewTable("test", s, client).Get(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if client.updates != 0 {
+			t.Errorf("got %d updates without write back", client.updates)
+		}
+	})
+
+	t.Run("upgraded", func(t *testing.T) {
+		client := newClient(t)
+		table := gonetable.NewTable("test", s, client, gonetable.WithUpcastWriteBack())
+		if _, err := table.Get(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]types.AttributeValue{
+			"PK":    MustMarshal("profile#1"),
+			"SK":    MustMarshal("profile"),
+			"ID":    MustMarshal("1"),
+			"Name":  MustMarshal("a"),
+			"Tags":  MustMarshal([]string{"x"}),
+			"Extra": MustMarshal("kept"),
+			"_Type": MustMarshal("profile"),
+			"_V":    MustMarshal(3),
+		}
+		if got := stored(t, client); !reflect.DeepEqual(got, want) {
+			t.Errorf("item = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("changed after read", func(t *testing.T) {
+		client := newClient(t)
+		client.item = profileV1("b")
+		table := gonetable.NewTable("test", s, client, gonetable.WithUpcastWriteBack())
+		if _, err := table.Get(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if got := stored(t, client); !reflect.DeepEqual(got, profileV1("b")) {
+			t.Errorf("item = %v, want the concurrent write %v", got, profileV1("b"))
+		}
+	})
+}